@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/steevenmentech/bifrost/internal/config"
+	"github.com/steevenmentech/bifrost/internal/sftp"
+)
+
+// globList collects a repeatable glob flag
+type globList []string
+
+func (g *globList) String() string {
+	return strings.Join(*g, ",")
+}
+
+func (g *globList) Set(value string) error {
+	*g = append(*g, value)
+	return nil
+}
+
+// connectSaved opens an SFTP connection to a saved connection for a CLI command
+func connectSaved(name string) (*sftp.Client, *config.Config, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	conn, err := findConnection(cfg, name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	password, err := getConnectionPassword(*conn)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get password: %w", err)
+	}
+	tuning := sftp.Tuning{MaxPacket: conn.MaxPacket, Concurrency: conn.TransferConcurrency}
+	client, err := sftp.ConnectFromConfig(conn.Host, conn.Port, conn.Username, password, tuning)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect: %w", err)
+	}
+	return client, cfg, nil
+}
+
+// findConnection looks up a saved connection by ID or label
+func findConnection(cfg *config.Config, name string) (*config.Connection, error) {
+	for i, conn := range cfg.Connections {
+		if conn.ID == name || strings.EqualFold(conn.Label, name) {
+			return &cfg.Connections[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no saved connection named %q", name)
+}
@@ -19,6 +19,26 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		var err error
+		switch os.Args[1] {
+		case "sync":
+			err = runSync(os.Args[2:])
+		case "watch":
+			err = runWatch(os.Args[2:])
+		case "tail":
+			err = runTail(os.Args[2:])
+		default:
+			fmt.Printf("Unknown command: %s\n", os.Args[1])
+			os.Exit(1)
+		}
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	for {
 		// Create the TUI model
 		model, err := tui.New()
@@ -57,34 +77,20 @@ func main() {
 		connType := tuiModel.GetConnectionType()
 
 		// Start appropriate session
+		var result *ssh.SessionResult
+		protocol := "SSH"
 		if connType == 0 {
-			// SSH
-			if err := startSSHSession(*selectedConn); err != nil {
-				fmt.Printf("\nSSH Error: %v\n", err)
-				fmt.Println("Press Enter to continue...")
-				var input string
-				fmt.Scanln(&input)
-				continue
-			}
-
-			fmt.Println("\nDisconnected from server.")
-			fmt.Println("Press Enter to return to Bifrost...")
-			var input string
-			fmt.Scanln(&input)
+			result, err = startSSHSession(*selectedConn)
 		} else {
-			// SFTP
-			if err := startSFTPSession(*selectedConn); err != nil {
-				fmt.Printf("\nSFTP Error: %v\n", err)
-				fmt.Println("Press Enter to continue...")
-				var input string
-				fmt.Scanln(&input)
-				continue
-			}
+			protocol = "SFTP"
+			err = startSFTPSession(*selectedConn)
+		}
 
-			fmt.Println("\nDisconnected from server.")
-			fmt.Println("Press Enter to return to Bifrost...")
-			var input string
-			fmt.Scanln(&input)
+		// Show how the session ended before returning to the main menu
+		summary := views.NewSessionSummary(*selectedConn, protocol, result, err)
+		if _, err := tea.NewProgram(summary, tea.WithAltScreen()).Run(); err != nil {
+			fmt.Printf("Error running app: %v\n", err)
+			os.Exit(1)
 		}
 	}
 }
@@ -130,12 +136,12 @@ func getConnectionPassword(conn config.Connection) (string, error) {
 	return password, nil
 }
 
-// startSSHSession connects to a server via SSH
-func startSSHSession(conn config.Connection) error {
+// startSSHSession connects to a server via SSH and returns how the shell session ended
+func startSSHSession(conn config.Connection) (*ssh.SessionResult, error) {
 	// Get password based on auth type
 	password, err := getConnectionPassword(conn)
 	if err != nil {
-		return fmt.Errorf("failed to get password: %w", err)
+		return nil, fmt.Errorf("failed to get password: %w", err)
 	}
 
 	// Create and connect SSH client
@@ -143,16 +149,17 @@ func startSSHSession(conn config.Connection) error {
 
 	sshClient, err := ssh.ConnectFromConfig(conn.Host, conn.Port, conn.Username, password)
 	if err != nil {
-		return fmt.Errorf("failed to connect: %w", err)
+		return nil, fmt.Errorf("failed to connect: %w", err)
 	}
 	defer sshClient.Close()
 
 	// Start interactive session
-	if err := sshClient.StartInteractiveSession(); err != nil {
-		return fmt.Errorf("session error: %w", err)
+	result, err := sshClient.StartInteractiveSession()
+	if err != nil {
+		return result, fmt.Errorf("session error: %w", err)
 	}
 
-	return nil
+	return result, nil
 }
 
 // startSFTPSession connects to a server via SFTP and shows the file browser
@@ -166,19 +173,32 @@ func startSFTPSession(conn config.Connection) error {
 	// Create and connect SFTP client
 	fmt.Printf("\nConnecting to %s@%s:%d via SFTP...\n", conn.Username, conn.Host, conn.Port)
 
-	sftpClient, err := sftp.ConnectFromConfig(conn.Host, conn.Port, conn.Username, password)
+	tuning := sftp.Tuning{MaxPacket: conn.MaxPacket, Concurrency: conn.TransferConcurrency}
+	sftpClient, err := sftp.ConnectFromConfig(conn.Host, conn.Port, conn.Username, password, tuning)
 	if err != nil {
 		return fmt.Errorf("failed to connect: %w", err)
 	}
 	defer sftpClient.Close()
 
+	// Transfers outlive the browser while a file is being edited
+	queueOpts := sftp.QueueOptions{Workers: sftp.DefaultTransferWorkers}
+	if cfg, err := config.Load(); err == nil {
+		queueOpts.Workers = cfg.Settings.TransferWorkers
+		queueOpts.Verify = cfg.Settings.VerifyTransfers == "true"
+		queueOpts.VerifyRetries = cfg.Settings.VerifyRetries
+	}
+	transfers := sftp.NewTransferManager(sftpClient, queueOpts)
+	defer transfers.Close()
+
 	fmt.Println("Connected! Loading SFTP browser...")
 
+	// Create SFTP browser model, kept across edits so the directory,
+	// selection and search results are still there afterwards
+	browser := views.NewSFTPBrowser(sftpClient, transfers, keys.DefaultKeyMap())
+	browser.LoadSavedSort(conn.ID)
+
 	// Loop to handle file editing
 	for {
-		// Create SFTP browser model
-		browser := views.NewSFTPBrowser(sftpClient, keys.DefaultKeyMap())
-
 		// Create and run the Bubble Tea program
 		p := tea.NewProgram(
 			browser,
@@ -196,6 +216,9 @@ func startSFTPSession(conn config.Connection) error {
 		if !ok {
 			break
 		}
+		if err := browserModel.SaveSort(); err != nil {
+			fmt.Printf("\nSort Error: %v\n", err)
+		}
 
 		fileToEdit := browserModel.GetFileToEdit()
 		if fileToEdit == "" {
@@ -204,19 +227,37 @@ func startSFTPSession(conn config.Connection) error {
 		}
 
 		// Edit the file
-		if err := editRemoteFile(sftpClient, fileToEdit); err != nil {
+		if err := editRemoteFile(sftpClient, fileToEdit, browserModel.GetLineToEdit()); err != nil {
 			fmt.Printf("\nEdit Error: %v\n", err)
 			fmt.Println("Press Enter to continue...")
 			var input string
 			fmt.Scanln(&input)
 		}
+		browserModel.ResumeAfterEdit()
 	}
 
 	return nil
 }
 
-// editRemoteFile downloads a file, opens it in an editor, and uploads it back
-func editRemoteFile(client *sftp.Client, remotePath string) error {
+// editorFileArgs returns the arguments opening path in editor, at line when
+// it is above 0. Only editors known to take a line get one, as others would
+// open a file named after it.
+func editorFileArgs(editor, path string, line int) []string {
+	if line > 0 {
+		name := filepath.Base(editor)
+		switch strings.TrimSuffix(name, filepath.Ext(name)) {
+		case "vi", "vim", "nvim", "nano", "emacs", "micro", "kak":
+			return []string{fmt.Sprintf("+%d", line), path}
+		case "code", "codium":
+			return []string{"--goto", fmt.Sprintf("%s:%d", path, line)}
+		}
+	}
+	return []string{path}
+}
+
+// editRemoteFile downloads a file, opens it in an editor, and uploads it back.
+// A line above 0 is only passed on to the editors editorFileArgs knows.
+func editRemoteFile(client *sftp.Client, remotePath string, line int) error {
 	// Extract filename from path
 	fileName := filepath.Base(remotePath)
 
@@ -271,8 +312,8 @@ func editRemoteFile(client *sftp.Client, remotePath string) error {
 		editorCmd = strings.TrimSpace(editor)
 	}
 
-	// Build command with editor args + file path
-	args := append(editorArgs, localPath)
+	// Build command with editor args + line + file path
+	args := append(editorArgs, editorFileArgs(editorCmd, localPath, line)...)
 	cmd := exec.Command(editorCmd, args...)
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
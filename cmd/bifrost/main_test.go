@@ -0,0 +1,31 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEditorFileArgs(t *testing.T) {
+	tests := []struct {
+		editor string
+		line   int
+		want   []string
+	}{
+		{"vim", 12, []string{"+12", "/tmp/f"}},
+		{"/usr/bin/nvim", 12, []string{"+12", "/tmp/f"}},
+		{"nano", 3, []string{"+3", "/tmp/f"}},
+		{"emacs", 3, []string{"+3", "/tmp/f"}},
+		{"kak", 3, []string{"+3", "/tmp/f"}},
+		{"vim", 0, []string{"/tmp/f"}},
+		{"code", 12, []string{"--goto", "/tmp/f:12"}},
+		{"code", 0, []string{"/tmp/f"}},
+		{"subl", 12, []string{"/tmp/f"}},
+		{"gedit", 12, []string{"/tmp/f"}},
+	}
+
+	for _, tt := range tests {
+		if got := editorFileArgs(tt.editor, "/tmp/f", tt.line); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("editorFileArgs(%q, %d) = %q, want %q", tt.editor, tt.line, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/steevenmentech/bifrost/internal/sftp"
+	"github.com/steevenmentech/bifrost/internal/tui/views"
+)
+
+// runSync implements `bifrost sync`, mirroring a directory to or from a saved connection
+func runSync(args []string) error {
+	flags := flag.NewFlagSet("sync", flag.ContinueOnError)
+	download := flags.Bool("download", false, "mirror the remote directory locally instead of uploading")
+	deleteExtra := flags.Bool("delete", false, "delete destination files that are missing from the source")
+	checksum := flags.Bool("checksum", false, "compare same-size files by SHA-256 instead of modification time")
+	dryRun := flags.Bool("dry-run", false, "only print the planned operations")
+	var include, exclude globList
+	flags.Var(&include, "include", "only sync files matching `glob` (repeatable)")
+	flags.Var(&exclude, "exclude", "skip files and directories matching `glob` (repeatable)")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: bifrost sync [flags] <connection> <local-dir> <remote-dir>")
+		fmt.Fprintln(flags.Output(), "\nMirrors local-dir to remote-dir, or remote-dir to local-dir with -download.")
+		fmt.Fprintln(flags.Output(), "The connection is a saved connection label or ID.\n\nFlags:")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if flags.NArg() != 3 {
+		flags.Usage()
+		return fmt.Errorf("expected a connection, a local directory and a remote directory")
+	}
+
+	client, cfg, err := connectSaved(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	opts := sftp.SyncOptions{
+		Direction: sftp.SyncUpload,
+		Checksum:  *checksum,
+		Delete:    *deleteExtra,
+		Include:   include,
+		Exclude:   exclude,
+	}
+	if *download {
+		opts.Direction = sftp.SyncDownload
+	}
+
+	plan, err := client.PlanSync(flags.Arg(1), flags.Arg(2), opts)
+	if err != nil {
+		return err
+	}
+	printSyncPlan(plan)
+
+	if *dryRun || len(plan.Actions) == 0 {
+		return nil
+	}
+
+	transferOpts := sftp.TransferOptions{
+		Verify:        cfg.Settings.VerifyTransfers == "true",
+		VerifyRetries: cfg.Settings.VerifyRetries,
+	}
+	total := plan.Bytes()
+	var lastPrint time.Time
+	result, err := client.ApplySync(context.Background(), plan, transferOpts, func(p sftp.TransferProgress) {
+		if time.Since(lastPrint) < 200*time.Millisecond {
+			return
+		}
+		lastPrint = time.Now()
+		fmt.Printf("\r  %s of %s, %d files   ", views.FormatFileSize(p.Bytes), views.FormatFileSize(total), p.Files)
+	})
+	fmt.Print("\r\033[K")
+	if err != nil {
+		return fmt.Errorf("sync failed: %w", err)
+	}
+
+	fmt.Printf("Synced %d files (%s), created %d directories, deleted %d entries\n", result.Files, views.FormatFileSize(result.Bytes), result.Dirs, result.Deleted)
+	for _, f := range result.Failed {
+		fmt.Printf("  failed: %s: %v\n", f.Path, f.Err)
+	}
+	if len(result.Failed) > 0 {
+		return fmt.Errorf("%d entries failed", len(result.Failed))
+	}
+	return nil
+}
+
+// printSyncPlan lists the planned operations and their totals
+func printSyncPlan(plan *sftp.SyncPlan) {
+	from, to := plan.LocalRoot, plan.RemoteRoot
+	if plan.Options.Direction == sftp.SyncDownload {
+		from, to = to, from
+	}
+	fmt.Printf("Sync %s -> %s\n\n", from, to)
+
+	for _, a := range plan.Actions {
+		name := a.Path
+		if a.IsDir {
+			name += "/"
+		}
+		detail := a.Reason
+		if !a.IsDir && a.Kind != sftp.SyncDelete {
+			detail = fmt.Sprintf("%s, %s", views.FormatFileSize(a.Size), a.Reason)
+		}
+		fmt.Printf("  %-7s %s  (%s)\n", a.Kind, name, detail)
+	}
+	for _, c := range plan.Conflicts {
+		fmt.Printf("  %-7s %s  (%s)\n", "skip", c.Path, c.Reason)
+	}
+
+	fmt.Printf("\n%d to copy, %d to update, %d directories to create, %d to delete, %d unchanged, %d left out - %s to transfer\n",
+		plan.Count(sftp.SyncCopy), plan.Count(sftp.SyncUpdate), plan.Count(sftp.SyncMkdir),
+		plan.Count(sftp.SyncDelete), plan.Unchanged, len(plan.Conflicts), views.FormatFileSize(plan.Bytes()))
+}
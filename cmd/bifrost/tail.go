@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"time"
+)
+
+// runTail implements `bifrost tail`, printing lines appended to a remote file until interrupted
+func runTail(args []string) error {
+	flags := flag.NewFlagSet("tail", flag.ContinueOnError)
+	lines := flags.Int("n", 10, "print the last `lines` lines before following")
+	interval := flags.Duration("interval", time.Second, "check the file for new lines this often")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: bifrost tail [flags] <connection> <remote-file>")
+		fmt.Fprintln(flags.Output(), "\nPrints the end of remote-file, then new lines as they are written, until Ctrl-C.")
+		fmt.Fprintln(flags.Output(), "A truncated or rotated file is followed from its start.\n\nFlags:")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return fmt.Errorf("expected a connection and a remote file")
+	}
+	if *interval <= 0 {
+		return fmt.Errorf("interval must be positive")
+	}
+
+	client, _, err := connectSaved(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	remotePath := flags.Arg(1)
+	file, err := client.OpenRemote(remotePath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if file != nil {
+			file.Close()
+		}
+	}()
+
+	size, err := file.Size()
+	if err != nil {
+		return err
+	}
+	known, err := lastLinesOffset(file, size, *lines)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	for {
+		if file != nil {
+			n, err := io.Copy(os.Stdout, io.NewSectionReader(file, known, size-known))
+			known += n
+			if err != nil {
+				return fmt.Errorf("failed to read remote file: %w", err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		// A replaced file is opened again on the next tick. The path may be
+		// missing for a moment while the file is rotated.
+		if file == nil {
+			if file, err = client.OpenRemote(remotePath); err != nil {
+				continue
+			}
+			known = 0
+			if size, err = file.Size(); err != nil {
+				size = 0
+			}
+			continue
+		}
+
+		change, err := client.PollRemote(remotePath, file, known)
+		if err != nil {
+			size = known
+			continue
+		}
+		size = change.Size
+		if change.Replaced {
+			fmt.Fprintf(os.Stderr, "bifrost: %s was truncated or replaced, following it from the start\n", remotePath)
+			file.Close()
+			file = nil
+		}
+	}
+}
+
+// lastLinesOffset returns where the last n lines of a file start, reading
+// it backwards from the end
+func lastLinesOffset(r io.ReaderAt, size int64, n int) (int64, error) {
+	if n <= 0 {
+		return size, nil
+	}
+
+	const chunk = 64 * 1024
+	buf := make([]byte, chunk)
+	end := size
+	for end > 0 {
+		start := max(0, end-chunk)
+		read, err := r.ReadAt(buf[:end-start], start)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return 0, fmt.Errorf("failed to read remote file: %w", err)
+		}
+		data := buf[:read]
+
+		// A newline at the very end closes the last line rather than starting one
+		if end == size && len(data) > 0 && data[len(data)-1] == '\n' {
+			data = data[:len(data)-1]
+		}
+		for i := len(data); ; {
+			i = bytes.LastIndexByte(data[:i], '\n')
+			if i < 0 {
+				break
+			}
+			if n--; n == 0 {
+				return start + int64(i) + 1, nil
+			}
+		}
+		end = start
+	}
+	return 0, nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/steevenmentech/bifrost/internal/sftp"
+	"github.com/steevenmentech/bifrost/internal/tui/views"
+)
+
+// runWatch implements `bifrost watch`, pushing local changes to a saved connection until interrupted
+func runWatch(args []string) error {
+	flags := flag.NewFlagSet("watch", flag.ContinueOnError)
+	debounce := flags.Duration("debounce", sftp.DefaultWatchDebounce, "wait this long after the last change before uploading a file")
+	var ignore globList
+	flags.Var(&ignore, "ignore", "skip paths matching a .gitignore-style `pattern` (repeatable)")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: bifrost watch [flags] <connection> <local-dir> <remote-dir>")
+		fmt.Fprintln(flags.Output(), "\nUploads files below local-dir to remote-dir as they change, until Ctrl-C.")
+		fmt.Fprintln(flags.Output(), "Paths matched by .gitignore files in local-dir are not uploaded.\n\nFlags:")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if flags.NArg() != 3 {
+		flags.Usage()
+		return fmt.Errorf("expected a connection, a local directory and a remote directory")
+	}
+
+	client, _, err := connectSaved(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	fmt.Printf("Watching %s -> %s (Ctrl-C to stop)\n", flags.Arg(1), flags.Arg(2))
+	opts := sftp.WatchOptions{Debounce: *debounce, Ignore: ignore}
+	return client.WatchPush(ctx, flags.Arg(1), flags.Arg(2), opts, func(e sftp.PushEvent) {
+		stamp := e.Time.Format("15:04:05")
+		switch {
+		case e.Err != nil && e.Path == "":
+			fmt.Printf("%s  %v\n", stamp, e.Err)
+			return
+		case e.Err != nil:
+			fmt.Printf("%s  failed  %s: %v\n", stamp, e.Path, e.Err)
+			return
+		}
+		fmt.Printf("%s  pushed  %s (%s)\n", stamp, e.Path, views.FormatFileSize(e.Bytes))
+	})
+}
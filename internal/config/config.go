@@ -25,6 +25,9 @@ type Settings struct {
 	ShowHiddenFiles string `yaml:"show_hidden_files" mapstructure:"show_hidden_files"`
 	ConfirmDelete   string `yaml:"confirm_delete" mapstructure:"confirm_delete"`
 	DefaultPort     int    `yaml:"default_port" mapstructure:"default_port"`
+	TransferWorkers int    `yaml:"transfer_workers" mapstructure:"transfer_workers"` // concurrent SFTP transfers
+	VerifyTransfers string `yaml:"verify_transfers" mapstructure:"verify_transfers"` // compare SHA-256 after each transfer, off by default
+	VerifyRetries   int    `yaml:"verify_retries" mapstructure:"verify_retries"`     // re-transfers after a failed check
 }
 
 // Connection repesents a sing SSH/SFTP connection.
@@ -38,6 +41,15 @@ type Connection struct {
 	AuthType     string `yaml:"auth_type" mapstructure:"auth_type"`           // "password" | "key" | "credential"
 	CredentialID string `yaml:"credential_id" mapstructure:"credential_id"` // if using shared credential
 	KeyPath      string `yaml:"key_path" mapstructure:"key_path"`           // if using SSH key
+
+	// SFTP transfer tuning, zero keeps the defaults
+	MaxPacket           int `yaml:"max_packet,omitempty" mapstructure:"max_packet"`                     // bytes per request
+	TransferConcurrency int `yaml:"transfer_concurrency,omitempty" mapstructure:"transfer_concurrency"` // requests in flight per file
+
+	// Order of the SFTP browser listing, zero values sort by name with directories first
+	SortKey     string `yaml:"sort_key,omitempty" mapstructure:"sort_key"` // "name" | "size" | "modified" | "extension" | "type"
+	SortDesc    bool   `yaml:"sort_desc,omitempty" mapstructure:"sort_desc"`
+	SortMixDirs bool   `yaml:"sort_mix_dirs,omitempty" mapstructure:"sort_mix_dirs"` // directories among files instead of first
 }
 
 // Credential represents shared authentication details.
@@ -117,6 +129,9 @@ func defaultConfig() *Config {
 			ShowHiddenFiles: "false",
 			ConfirmDelete:   "true",
 			DefaultPort:     22,
+			TransferWorkers: 2,
+			VerifyTransfers: "false",
+			VerifyRetries:   1,
 		},
 		Connections: []Connection{},
 		Credentials: []Credential{},
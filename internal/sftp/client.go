@@ -6,6 +6,7 @@ import (
 	"io/fs"
 	"os"
 	"path"
+	"sync"
 	"time"
 
 	"github.com/pkg/sftp"
@@ -43,6 +44,48 @@ type Client struct {
 	host       string
 	port       int
 	currentDir string
+	tuning     Tuning
+
+	hashOnce    sync.Once
+	hashCommand string // SHA-256 tool on the server, empty if there is none
+
+	findOnce   sync.Once
+	hasFind    bool // the server runs find over exec
+	findPrintf bool // its find prints metadata with -printf, like GNU find
+
+	grepOnce sync.Once
+	grepTool string // rg when the server has it, grep otherwise
+	grepNull bool   // grepTool can end paths with a NUL
+
+	accountsOnce sync.Once
+	accounts     *Accounts // user and group names, read once per connection
+
+	rawMu sync.Mutex
+	raw   *rawSFTP // second SFTP session for extensions, opened on first use
+}
+
+// Tuning controls the throughput of file transfers on a connection.
+// Zero values keep the defaults, which every server supports.
+type Tuning struct {
+	MaxPacket   int // payload bytes per request, defaults to 32 KiB
+	Concurrency int // outstanding requests per file, defaults to 64
+}
+
+// clientOptions turns the tuning into pkg/sftp options. Reads and writes of
+// a file are pipelined so throughput does not collapse on high latency links.
+func (t Tuning) clientOptions() []sftp.ClientOption {
+	opts := []sftp.ClientOption{
+		sftp.UseConcurrentReads(true),
+		sftp.UseConcurrentWrites(true),
+	}
+	if t.MaxPacket > 0 {
+		// The user picked the size for this server, so allow more than 32 KiB
+		opts = append(opts, sftp.MaxPacketUnchecked(t.MaxPacket))
+	}
+	if t.Concurrency > 0 {
+		opts = append(opts, sftp.MaxConcurrentRequestsPerFile(t.Concurrency))
+	}
+	return opts
 }
 
 // FileInfo represents file/directory information
@@ -53,6 +96,14 @@ type FileInfo struct {
 	ModTime     time.Time
 	IsDir       bool
 	Permissions string
+	UID         int // owner, -1 when the server does not report it
+	GID         int
+	Owner       string // names of UID and GID, the ids themselves when they have none
+	Group       string
+	IsLink      bool   // a symlink; the other fields describe the link itself
+	LinkTarget  string // where a symlink points, as stored in the link
+	LinkBroken  bool   // the target of a symlink does not exist
+	LinksToDir  bool   // the target of a symlink is a directory
 }
 
 // NewClient creates a new SFTP client
@@ -83,7 +134,7 @@ func (c *Client) Connect() error {
 	c.sshClient = sshClient
 
 	// Then create SFTP session
-	sftpClient, err := sftp.NewClient(sshClient)
+	sftpClient, err := sftp.NewClient(sshClient, c.tuning.clientOptions()...)
 	if err != nil {
 		c.sshClient.Close()
 		return fmt.Errorf("failed to create SFTP client: %w", err)
@@ -112,21 +163,59 @@ func (c *Client) ListDir(dirPath string) ([]FileInfo, error) {
 		return nil, fmt.Errorf("failed to read directory: %w", err)
 	}
 
+	accounts, err := c.Accounts()
+	if err != nil {
+		return nil, err
+	}
+
 	fileInfos := make([]FileInfo, 0, len(entries))
 	for _, entry := range entries {
-		fileInfos = append(fileInfos, FileInfo{
-			Name:        entry.Name(),
-			Size:        entry.Size(),
-			Mode:        entry.Mode(),
-			ModTime:     entry.ModTime(),
-			IsDir:       entry.IsDir(),
-			Permissions: entry.Mode().String(),
-		})
+		fileInfos = append(fileInfos, newFileInfo(entry, accounts))
 	}
+	c.resolveLinks(dirPath, fileInfos)
 
 	return fileInfos, nil
 }
 
+// linkWorkers bounds the symlinks of a listing that are resolved at once
+const linkWorkers = 16
+
+// resolveLinks fills in where the symlinks among the entries of dirPath
+// point. Each takes two round trips, so several are resolved at a time.
+func (c *Client) resolveLinks(dirPath string, infos []FileInfo) {
+	slots := make(chan struct{}, linkWorkers)
+	var wg sync.WaitGroup
+	for i := range infos {
+		if infos[i].Mode&fs.ModeSymlink == 0 {
+			continue
+		}
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(info *FileInfo) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			c.resolveLink(path.Join(dirPath, info.Name), info)
+		}(&infos[i])
+	}
+	wg.Wait()
+}
+
+// resolveLink fills in where the symlink at linkPath points
+func (c *Client) resolveLink(linkPath string, info *FileInfo) {
+	info.IsLink = true
+	if target, err := c.sftpClient.ReadLink(linkPath); err == nil {
+		info.LinkTarget = target
+	}
+	target, err := c.sftpClient.Stat(linkPath)
+	if err != nil {
+		info.LinkBroken = true
+		return
+	}
+	info.LinksToDir = target.IsDir()
+}
+
 // Stat gets file/directory metadata
 func (c *Client) Stat(filePath string) (*FileInfo, error) {
 	if c.sftpClient == nil {
@@ -138,14 +227,59 @@ func (c *Client) Stat(filePath string) (*FileInfo, error) {
 		return nil, fmt.Errorf("failed to stat file: %w", err)
 	}
 
-	return &FileInfo{
+	accounts, err := c.Accounts()
+	if err != nil {
+		return nil, err
+	}
+
+	fileInfo := newFileInfo(info, accounts)
+	return &fileInfo, nil
+}
+
+// Lstat gets metadata like Stat, describing a symlink rather than its target
+func (c *Client) Lstat(filePath string) (*FileInfo, error) {
+	if c.sftpClient == nil {
+		return nil, fmt.Errorf("not connected")
+	}
+
+	info, err := c.sftpClient.Lstat(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	accounts, err := c.Accounts()
+	if err != nil {
+		return nil, err
+	}
+
+	fileInfo := c.toFileInfo(filePath, info, accounts)
+	return &fileInfo, nil
+}
+
+// toFileInfo describes the entry at filePath from its lstat result
+func (c *Client) toFileInfo(filePath string, info os.FileInfo, accounts *Accounts) FileInfo {
+	fileInfo := newFileInfo(info, accounts)
+	if info.Mode()&fs.ModeSymlink != 0 {
+		c.resolveLink(filePath, &fileInfo)
+	}
+	return fileInfo
+}
+
+// newFileInfo describes an entry from its lstat result, without following links
+func newFileInfo(info os.FileInfo, accounts *Accounts) FileInfo {
+	uid, gid := fileOwner(info)
+	return FileInfo{
 		Name:        info.Name(),
 		Size:        info.Size(),
 		Mode:        info.Mode(),
 		ModTime:     info.ModTime(),
 		IsDir:       info.IsDir(),
 		Permissions: info.Mode().String(),
-	}, nil
+		UID:         uid,
+		GID:         gid,
+		Owner:       accounts.UserName(uid),
+		Group:       accounts.GroupName(gid),
+	}
 }
 
 // ChangeDir changes the current working directory
@@ -181,6 +315,7 @@ func (c *Client) GetWorkingDir() string {
 
 // Close closes the SFTP and SSH connections
 func (c *Client) Close() error {
+	c.closeRawSession()
 	if c.sftpClient != nil {
 		c.sftpClient.Close()
 	}
@@ -225,8 +360,8 @@ func (c *Client) Delete(itemPath string) error {
 		return fmt.Errorf("not connected")
 	}
 
-	// Check if it's a directory
-	info, err := c.sftpClient.Stat(itemPath)
+	// Check if it's a directory (Lstat so a symlink is removed, not its target)
+	info, err := c.sftpClient.Lstat(itemPath)
 	if err != nil {
 		return fmt.Errorf("failed to stat item: %w", err)
 	}
@@ -260,6 +395,45 @@ func (c *Client) Rename(oldPath, newPath string) error {
 	return nil
 }
 
+// ReadLink returns the target of a symlink
+func (c *Client) ReadLink(linkPath string) (string, error) {
+	if c.sftpClient == nil {
+		return "", fmt.Errorf("not connected")
+	}
+
+	target, err := c.sftpClient.ReadLink(linkPath)
+	if err != nil {
+		return "", wrapSFTPError(err, "failed to read link")
+	}
+	return target, nil
+}
+
+// Symlink creates a symbolic link at linkPath pointing to target. A relative
+// target is resolved from the directory of the link.
+func (c *Client) Symlink(target, linkPath string) error {
+	if c.sftpClient == nil {
+		return fmt.Errorf("not connected")
+	}
+
+	if err := c.sftpClient.Symlink(target, linkPath); err != nil {
+		return wrapSFTPError(err, "failed to create symlink")
+	}
+	return nil
+}
+
+// Link creates a hard link at linkPath to the file at target. Servers
+// without the hardlink@openssh.com extension refuse it.
+func (c *Client) Link(target, linkPath string) error {
+	if c.sftpClient == nil {
+		return fmt.Errorf("not connected")
+	}
+
+	if err := c.sftpClient.Link(target, linkPath); err != nil {
+		return wrapSFTPError(err, "failed to create hard link")
+	}
+	return nil
+}
+
 // DownloadFile downloads a file from the remote server to local path
 func (c *Client) DownloadFile(remotePath, localPath string) error {
 	if c.sftpClient == nil {
@@ -279,8 +453,8 @@ func (c *Client) DownloadFile(remotePath, localPath string) error {
 		return fmt.Errorf("failed to create local file: %w", err)
 	}
 
-	// Copy contents
-	_, err = io.Copy(localFile, remoteFile)
+	// WriteTo reads several chunks ahead instead of waiting for each one
+	_, err = remoteFile.WriteTo(localFile)
 	if err != nil {
 		localFile.Close()
 		return fmt.Errorf("failed to download file: %w", err)
@@ -313,9 +487,10 @@ func (c *Client) UploadFile(localPath, remotePath string) error {
 		return wrapSFTPError(err, "failed to create remote file")
 	}
 
-	// Copy contents using io.Copy (uses ReadFrom internally for better performance)
-	_, err = io.Copy(remoteFile, localFile)
+	// Keep several writes in flight instead of waiting for each one
+	_, err = remoteFile.ReadFromWithConcurrency(localFile, 0)
 	if err != nil {
+		truncateAtOffset(remoteFile)
 		remoteFile.Close()
 		return wrapSFTPError(err, "failed to write to remote file")
 	}
@@ -328,12 +503,22 @@ func (c *Client) UploadFile(localPath, remotePath string) error {
 	return nil
 }
 
+// truncateAtOffset cuts a remote file after a failed concurrent write. Writes
+// beyond the first failure may have landed, leaving holes, while the file
+// offset marks where the data is still contiguous.
+func truncateAtOffset(f *sftp.File) {
+	if off, err := f.Seek(0, io.SeekCurrent); err == nil {
+		f.Truncate(off)
+	}
+}
+
 // ConnectFromConfig creates and connects an SFTP client using connection details
-func ConnectFromConfig(host string, port int, username, password string) (*Client, error) {
+func ConnectFromConfig(host string, port int, username, password string, tuning Tuning) (*Client, error) {
 	client, err := NewClient(host, port, username, password)
 	if err != nil {
 		return nil, err
 	}
+	client.tuning = tuning
 
 	if err := client.Connect(); err != nil {
 		return nil, err
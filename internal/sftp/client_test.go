@@ -0,0 +1,52 @@
+package sftp
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestListDirLinks(t *testing.T) {
+	c := newPipeClient(t, 0)
+	dir := t.TempDir()
+	os.Mkdir(filepath.Join(dir, "sub"), 0755)
+	os.WriteFile(filepath.Join(dir, "file"), []byte("x"), 0644)
+	os.Symlink("sub", filepath.Join(dir, "to-dir"))
+	os.Symlink("file", filepath.Join(dir, "to-file"))
+	os.Symlink("missing", filepath.Join(dir, "broken"))
+	// More links than resolve at once
+	for i := 0; i < 3*linkWorkers; i++ {
+		os.Symlink("sub", filepath.Join(dir, fmt.Sprintf("link%02d", i)))
+	}
+
+	entries, err := c.ListDir(filepath.ToSlash(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type link struct {
+		isLink, broken, toDir bool
+		target                string
+	}
+	want := map[string]link{
+		"sub":     {},
+		"file":    {},
+		"to-dir":  {isLink: true, toDir: true, target: "sub"},
+		"to-file": {isLink: true, target: "file"},
+		"broken":  {isLink: true, broken: true, target: "missing"},
+	}
+	for i := 0; i < 3*linkWorkers; i++ {
+		want[fmt.Sprintf("link%02d", i)] = link{isLink: true, toDir: true, target: "sub"}
+	}
+
+	if len(entries) != len(want) {
+		t.Fatalf("got %d entries, want %d", len(entries), len(want))
+	}
+	for _, e := range entries {
+		got := link{isLink: e.IsLink, broken: e.LinkBroken, toDir: e.LinksToDir, target: e.LinkTarget}
+		if got != want[e.Name] {
+			t.Errorf("%s: got %+v, want %+v", e.Name, got, want[e.Name])
+		}
+	}
+}
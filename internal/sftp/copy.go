@@ -0,0 +1,239 @@
+package sftp
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// CopyMethod is how a remote copy was made
+type CopyMethod int
+
+const (
+	CopyServerSide CopyMethod = iota // copy-data SFTP extension, data never leaves the server
+	CopyCommand                      // cp -a over an exec channel
+	CopyStreamed                     // read and written back through this client
+)
+
+func (m CopyMethod) String() string {
+	switch m {
+	case CopyServerSide:
+		return "server-side"
+	case CopyCommand:
+		return "cp -a"
+	default:
+		return "streamed"
+	}
+}
+
+// Copy duplicates a remote file, symlink or directory tree at dst, which
+// must not exist. It uses the copy-data extension when the server has it,
+// then cp -a, and finally streams the data through the client, returning
+// the method that worked.
+func (c *Client) Copy(src, dst string) (CopyMethod, error) {
+	if c.sftpClient == nil {
+		return 0, fmt.Errorf("not connected")
+	}
+
+	src, dst = path.Clean(src), path.Clean(dst)
+	info, err := c.sftpClient.Lstat(src)
+	if err != nil {
+		return 0, wrapSFTPError(err, "failed to stat item")
+	}
+	if _, err := c.sftpClient.Lstat(dst); err == nil {
+		return 0, fmt.Errorf("%s already exists", dst)
+	}
+	if info.IsDir() && (dst == src || strings.HasPrefix(dst, src+"/")) {
+		return 0, fmt.Errorf("cannot copy a directory into itself")
+	}
+
+	// Each fallback starts over, so a partial copy is removed first. dst
+	// did not exist, so only what the failed attempt created goes.
+	if _, ok := c.sftpClient.HasExtension("copy-data"); ok {
+		if err := c.copyTree(src, dst, c.copyDataFile); err == nil {
+			return CopyServerSide, nil
+		}
+		c.removePartialCopy(dst)
+	}
+
+	if _, err := c.RunCommand("cp -a -- " + shellQuote(src) + " " + shellQuote(dst)); err == nil {
+		return CopyCommand, nil
+	}
+	c.removePartialCopy(dst)
+
+	if err := c.copyTree(src, dst, c.streamFile); err != nil {
+		return CopyStreamed, err
+	}
+	return CopyStreamed, nil
+}
+
+// removePartialCopy deletes what a failed copy attempt left at dst
+func (c *Client) removePartialCopy(dst string) {
+	if _, err := c.sftpClient.Lstat(dst); err == nil {
+		c.DeleteRecursive(dst, nil)
+	}
+}
+
+// copyTree recreates the tree at src under dst entry by entry, copying the
+// data of regular files with copyFile. Modes and modification times are kept.
+func (c *Client) copyTree(src, dst string, copyFile func(src, dst string, info os.FileInfo) error) error {
+	var dirs []string // directories created, to set their times once their contents are in
+	times := make(map[string]os.FileInfo)
+
+	walker := c.sftpClient.Walk(src)
+	for walker.Step() {
+		if err := walker.Err(); err != nil {
+			return wrapSFTPError(err, "failed to read directory")
+		}
+		info := walker.Stat()
+		target := dst + strings.TrimPrefix(walker.Path(), src)
+
+		switch {
+		case info.IsDir():
+			if err := c.sftpClient.Mkdir(target); err != nil {
+				return wrapSFTPError(err, "failed to create directory")
+			}
+			if err := c.sftpClient.Chmod(target, info.Mode()&(fs.ModePerm|specialBits)); err != nil {
+				return wrapSFTPError(err, "failed to set mode")
+			}
+			dirs = append(dirs, target)
+			times[target] = info
+		case info.Mode()&fs.ModeSymlink != 0:
+			link, err := c.sftpClient.ReadLink(walker.Path())
+			if err != nil {
+				return wrapSFTPError(err, "failed to read link")
+			}
+			if err := c.sftpClient.Symlink(link, target); err != nil {
+				return wrapSFTPError(err, "failed to create symlink")
+			}
+		case info.Mode().IsRegular():
+			if err := copyFile(walker.Path(), target, info); err != nil {
+				return err
+			}
+			if err := c.sftpClient.Chmod(target, info.Mode()&(fs.ModePerm|specialBits)); err != nil {
+				return wrapSFTPError(err, "failed to set mode")
+			}
+			c.sftpClient.Chtimes(target, info.ModTime(), info.ModTime())
+		}
+		// Devices, sockets and pipes are not copied
+	}
+
+	for i := len(dirs) - 1; i >= 0; i-- {
+		c.sftpClient.Chtimes(dirs[i], times[dirs[i]].ModTime(), times[dirs[i]].ModTime())
+	}
+	return nil
+}
+
+// copyDataFile copies a file on the server with the copy-data extension
+func (c *Client) copyDataFile(src, dst string, info os.FileInfo) error {
+	raw, err := c.rawSession()
+	if err != nil {
+		return err
+	}
+
+	from, err := raw.open(src, fxfRead, 0)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", src, err)
+	}
+	defer raw.close(from)
+
+	to, err := raw.open(dst, fxfWrite|fxfCreat|fxfExcl, uint32(info.Mode().Perm()))
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", dst, err)
+	}
+	if err := raw.copyData(from, to); err != nil {
+		raw.close(to)
+		return fmt.Errorf("failed to copy %s: %w", src, err)
+	}
+	if err := raw.close(to); err != nil {
+		return fmt.Errorf("failed to copy %s: %w", src, err)
+	}
+	return nil
+}
+
+// streamFile copies a file by reading it and writing it back
+func (c *Client) streamFile(src, dst string, info os.FileInfo) error {
+	from, err := c.sftpClient.Open(src)
+	if err != nil {
+		return wrapSFTPError(err, "failed to open remote file")
+	}
+	defer from.Close()
+
+	to, err := c.sftpClient.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL)
+	if err != nil {
+		return wrapSFTPError(err, "failed to create remote file")
+	}
+	if _, err := to.ReadFrom(from); err != nil {
+		to.Close()
+		return wrapSFTPError(err, "failed to copy remote file")
+	}
+	if err := to.Close(); err != nil {
+		return wrapSFTPError(err, "failed to copy remote file")
+	}
+	return nil
+}
+
+// Replace moves or copies src to dst, removing what is already at dst. The
+// new entry is first put next to dst, so dst is only removed once it is complete.
+func (c *Client) Replace(src, dst string, move bool) error {
+	if c.sftpClient == nil {
+		return fmt.Errorf("not connected")
+	}
+
+	src, dst = path.Clean(src), path.Clean(dst)
+	if src == dst {
+		return fmt.Errorf("cannot replace %s with itself", path.Base(dst))
+	}
+
+	staged := path.Join(path.Dir(dst), fmt.Sprintf(".%s.bifrost-%d", path.Base(dst), time.Now().UnixNano()))
+	if move {
+		if err := c.Rename(src, staged); err != nil {
+			return err
+		}
+	} else if _, err := c.Copy(src, staged); err != nil {
+		return err
+	}
+
+	failures, err := c.DeleteRecursive(dst, nil)
+	if err == nil && len(failures) > 0 {
+		err = failures[0].Err
+	}
+	if err != nil {
+		// Leave things as they were as far as possible
+		if move {
+			c.sftpClient.Rename(staged, src)
+		} else {
+			c.removePartialCopy(staged)
+		}
+		return fmt.Errorf("failed to remove %s: %w", dst, err)
+	}
+	return c.Rename(staged, dst)
+}
+
+// FreeName returns name, or name numbered like "report (2).txt", so that it
+// does not exist yet in dir
+func (c *Client) FreeName(dir, name string, isDir bool) (string, error) {
+	if c.sftpClient == nil {
+		return "", fmt.Errorf("not connected")
+	}
+
+	stem, ext := name, ""
+	if !isDir && path.Ext(name) != name {
+		ext = path.Ext(name)
+		stem = strings.TrimSuffix(name, ext)
+	}
+	candidate := name
+	for n := 2; ; n++ {
+		if _, err := c.sftpClient.Lstat(path.Join(dir, candidate)); err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return candidate, nil
+			}
+			return "", wrapSFTPError(err, "failed to stat item")
+		}
+		candidate = fmt.Sprintf("%s (%d)%s", stem, n, ext)
+	}
+}
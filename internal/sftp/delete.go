@@ -0,0 +1,101 @@
+package sftp
+
+import (
+	"fmt"
+	"path"
+)
+
+// DeleteProgress reports the state of a recursive delete
+type DeleteProgress struct {
+	Done    int    // entries processed so far
+	Total   int    // entries to process
+	Current string // path being removed
+}
+
+// DeleteRecursive removes root and everything below it. Symlinks are removed,
+// never followed. Entries that cannot be removed are returned instead of
+// aborting the whole operation; progress, if not nil, is called before each removal.
+func (c *Client) DeleteRecursive(root string, progress func(DeleteProgress)) ([]FailedEntry, error) {
+	if c.sftpClient == nil {
+		return nil, fmt.Errorf("not connected")
+	}
+
+	root = path.Clean(root)
+
+	// Collect entries first so the total is known; the walk is pre-order,
+	// so removing in reverse deletes children before their parents
+	type entry struct {
+		path  string
+		isDir bool
+	}
+	var entries []entry
+	var failures []FailedEntry
+
+	walker := c.sftpClient.Walk(root)
+	for walker.Step() {
+		if err := walker.Err(); err != nil {
+			if walker.Path() == root && walker.Stat() == nil {
+				return nil, wrapSFTPError(err, "failed to stat item")
+			}
+			failures = append(failures, FailedEntry{Path: walker.Path(), Err: wrapSFTPError(err, "failed to read directory")})
+			continue
+		}
+		entries = append(entries, entry{path: walker.Path(), isDir: walker.Stat().IsDir()})
+	}
+
+	// Directories holding an entry that failed cannot be removed either
+	blocked := make(map[string]bool)
+	block := func(p string) {
+		for p != root && p != "/" && p != "." {
+			p = path.Dir(p)
+			blocked[p] = true
+		}
+	}
+	for _, f := range failures {
+		blocked[f.Path] = true
+		block(f.Path)
+	}
+
+	total := len(entries)
+	for i := total - 1; i >= 0; i-- {
+		e := entries[i]
+		if progress != nil {
+			progress(DeleteProgress{Done: total - 1 - i, Total: total, Current: e.path})
+		}
+
+		if e.isDir && blocked[e.path] {
+			if !hasFailure(failures, e.path) {
+				failures = append(failures, FailedEntry{Path: e.path, Err: fmt.Errorf("directory not empty")})
+			}
+			block(e.path)
+			continue
+		}
+
+		var err error
+		if e.isDir {
+			err = c.sftpClient.RemoveDirectory(e.path)
+		} else {
+			err = c.sftpClient.Remove(e.path)
+		}
+		if err != nil {
+			failures = append(failures, FailedEntry{Path: e.path, Err: wrapSFTPError(err, "failed to delete")})
+			block(e.path)
+		}
+	}
+
+	if progress != nil {
+		progress(DeleteProgress{Done: total, Total: total})
+	}
+
+	return failures, nil
+}
+
+// hasFailure reports whether p has already been recorded as failed
+func hasFailure(failures []FailedEntry, p string) bool {
+	for _, f := range failures {
+		if f.Path == p {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,433 @@
+package sftp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// SymlinkMode controls how symbolic links are handled when copying a tree
+type SymlinkMode int
+
+const (
+	SymlinkSkip     SymlinkMode = iota // leave links out and report them as skipped
+	SymlinkFollow                      // copy what the link points to
+	SymlinkPreserve                    // recreate the link with the same target, see safeLinkTarget for downloads
+)
+
+// String returns a short label for the mode
+func (m SymlinkMode) String() string {
+	switch m {
+	case SymlinkFollow:
+		return "follow"
+	case SymlinkPreserve:
+		return "preserve"
+	default:
+		return "skip"
+	}
+}
+
+// TransferOptions controls how a tree is copied
+type TransferOptions struct {
+	Symlinks      SymlinkMode
+	Resume        bool // continue partial destination files instead of rewriting them
+	Verify        bool // compare SHA-256 of each file when the server can compute it
+	VerifyRetries int  // times a file is copied again after a failed check
+}
+
+// TransferProgress reports the state of a multi-file transfer
+type TransferProgress struct {
+	Files   int    // files completed so far
+	Bytes   int64  // bytes at the destination so far, including resumed data
+	Current string // path being transferred
+}
+
+// TreeResult summarizes a recursive transfer
+type TreeResult struct {
+	Files    int
+	Dirs     int
+	Links    int
+	Bytes    int64
+	Resumed  int // files continued from a partial destination
+	Verified int // files whose SHA-256 matched the source
+	Deleted  int // destination entries removed by a sync
+	Skipped  []FailedEntry
+	Failed   []FailedEntry
+}
+
+// merge adds the counts and entries of another result
+func (r *TreeResult) merge(other *TreeResult) {
+	r.Files += other.Files
+	r.Dirs += other.Dirs
+	r.Links += other.Links
+	r.Bytes += other.Bytes
+	r.Resumed += other.Resumed
+	r.Verified += other.Verified
+	r.Deleted += other.Deleted
+	r.Skipped = append(r.Skipped, other.Skipped...)
+	r.Failed = append(r.Failed, other.Failed...)
+}
+
+// treeDownload holds the state of a single DownloadTree call
+type treeDownload struct {
+	ctx      context.Context
+	client   *Client
+	opts     TransferOptions
+	progress func(TransferProgress)
+	result   *TreeResult
+	visited  map[string]bool // resolved paths of walked directories, to break link loops
+	root     string          // local directory the tree is downloaded to
+}
+
+// DownloadTree downloads a remote file or directory to localRoot. Directories
+// are downloaded recursively, preserving the directory structure, permissions
+// and modification times. Entries that fail are collected in the result and
+// the rest of the tree is still downloaded. Cancelling ctx stops the download
+// and returns the context error along with what was transferred so far.
+// With opts.Resume, partial local files are continued where they stopped.
+func (c *Client) DownloadTree(ctx context.Context, remoteRoot, localRoot string, opts TransferOptions, progress func(TransferProgress)) (*TreeResult, error) {
+	if c.sftpClient == nil {
+		return nil, fmt.Errorf("not connected")
+	}
+
+	info, err := c.sftpClient.Stat(remoteRoot)
+	if err != nil {
+		return nil, wrapSFTPError(err, "failed to stat remote file")
+	}
+
+	d := &treeDownload{
+		ctx:      ctx,
+		client:   c,
+		opts:     opts,
+		progress: progress,
+		result:   &TreeResult{},
+		visited:  make(map[string]bool),
+		root:     localRoot,
+	}
+	resolved := path.Clean(remoteRoot)
+	if real, err := c.sftpClient.RealPath(remoteRoot); err == nil {
+		resolved = real
+	}
+
+	if !info.IsDir() {
+		d.downloadFile(remoteRoot, localRoot, info)
+		return d.result, ctx.Err()
+	}
+
+	if err := d.downloadDir(remoteRoot, resolved, localRoot, info); err != nil {
+		return d.result, err
+	}
+
+	return d.result, ctx.Err()
+}
+
+// downloadDir creates localDir and downloads the contents of remoteDir into it.
+// resolved is remoteDir with any followed links replaced by their targets.
+func (d *treeDownload) downloadDir(remoteDir, resolved, localDir string, info fs.FileInfo) error {
+	if err := os.MkdirAll(localDir, 0755); err != nil {
+		return fmt.Errorf("failed to create local directory: %w", err)
+	}
+	d.visited[resolved] = true
+	d.result.Dirs++
+
+	entries, err := d.client.sftpClient.ReadDir(remoteDir)
+	if err != nil {
+		d.fail(remoteDir, wrapSFTPError(err, "failed to read directory"))
+	}
+
+	for _, entry := range entries {
+		if err := d.ctx.Err(); err != nil {
+			return err
+		}
+		remotePath := path.Join(remoteDir, entry.Name())
+		if !safeEntryName(entry.Name()) {
+			d.fail(remotePath, fmt.Errorf("unsafe name %q from the server", entry.Name()))
+			continue
+		}
+		localPath := filepath.Join(localDir, entry.Name())
+
+		if entry.Mode()&fs.ModeSymlink != 0 {
+			d.downloadLink(remotePath, path.Join(resolved, entry.Name()), localPath)
+			continue
+		}
+
+		if entry.IsDir() {
+			if err := d.downloadDir(remotePath, path.Join(resolved, entry.Name()), localPath, entry); err != nil {
+				d.fail(remotePath, err)
+			}
+			continue
+		}
+
+		if !entry.Mode().IsRegular() {
+			d.skip(remotePath, fmt.Errorf("not a regular file"))
+			continue
+		}
+
+		d.downloadFile(remotePath, localPath, entry)
+	}
+
+	// Apply directory metadata last, writing the contents changes the mtime
+	os.Chmod(localDir, info.Mode().Perm()|0700)
+	os.Chtimes(localDir, info.ModTime(), info.ModTime())
+
+	return nil
+}
+
+// safeEntryName reports whether a name listed by the server stays inside the
+// local directory it is written to
+func safeEntryName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, `/\`)
+}
+
+// safeLinkTarget reports whether a link target from the server, for a link
+// depth directories below the download root, can only lead to entries of
+// the download. Otherwise a hostile server could plant links to anywhere on
+// the local machine. Absolute targets are refused, and so is ".." after a
+// name, as that name could itself be a link to somewhere else.
+func safeLinkTarget(target string, depth int) bool {
+	if target == "" || path.IsAbs(target) || filepath.IsAbs(target) || filepath.VolumeName(target) != "" {
+		return false
+	}
+	climbing := true
+	elems := strings.FieldsFunc(target, func(r rune) bool { return r == '/' || r == filepath.Separator })
+	for _, elem := range elems {
+		switch elem {
+		case ".":
+		case "..":
+			if !climbing || depth == 0 {
+				return false
+			}
+			depth--
+		default:
+			climbing = false
+		}
+	}
+	return true
+}
+
+// linkDepth counts the directories of a path relative to the download root
+func linkDepth(rel string) int {
+	if rel == "." {
+		return 0
+	}
+	return len(strings.Split(rel, string(filepath.Separator)))
+}
+
+// downloadLink handles a symlink according to the selected mode
+func (d *treeDownload) downloadLink(remotePath, resolved, localPath string) {
+	switch d.opts.Symlinks {
+	case SymlinkPreserve:
+		target, err := d.client.sftpClient.ReadLink(remotePath)
+		if err != nil {
+			d.fail(remotePath, wrapSFTPError(err, "failed to read link"))
+			return
+		}
+		rel, err := filepath.Rel(d.root, filepath.Dir(localPath))
+		if err != nil || !safeLinkTarget(target, linkDepth(rel)) {
+			d.skip(remotePath, fmt.Errorf("link to %q leads outside the download", target))
+			return
+		}
+		if err := os.Symlink(target, localPath); err != nil {
+			d.fail(remotePath, fmt.Errorf("failed to create link: %w", err))
+			return
+		}
+		d.result.Links++
+
+	case SymlinkFollow:
+		info, err := d.client.sftpClient.Stat(remotePath)
+		if err != nil {
+			d.skip(remotePath, fmt.Errorf("broken link"))
+			return
+		}
+		if info.IsDir() {
+			target := d.resolveLink(resolved)
+			if d.visited[target] {
+				d.skip(remotePath, fmt.Errorf("link loop or directory already downloaded"))
+				return
+			}
+			if err := d.downloadDir(remotePath, target, localPath, info); err != nil {
+				d.fail(remotePath, err)
+			}
+			return
+		}
+		if !info.Mode().IsRegular() {
+			d.skip(remotePath, fmt.Errorf("not a regular file"))
+			return
+		}
+		d.downloadFile(remotePath, localPath, info)
+
+	default:
+		d.skip(remotePath, fmt.Errorf("symbolic link"))
+	}
+}
+
+// resolveLink follows a chain of links starting at linkPath and returns the final path
+func (d *treeDownload) resolveLink(linkPath string) string {
+	current := linkPath
+	for range 32 {
+		target, err := d.client.sftpClient.ReadLink(current)
+		if err != nil {
+			break
+		}
+		if !path.IsAbs(target) {
+			target = path.Join(path.Dir(current), target)
+		}
+		current = path.Clean(target)
+	}
+
+	// Servers that resolve links in realpath give the canonical answer
+	if real, err := d.client.sftpClient.RealPath(current); err == nil {
+		return real
+	}
+	return current
+}
+
+// downloadFile copies a single remote file, checks the copy and applies its mode and mtime
+func (d *treeDownload) downloadFile(remotePath, localPath string, info fs.FileInfo) {
+	if d.progress != nil {
+		d.progress(TransferProgress{Files: d.result.Files, Bytes: d.result.Bytes, Current: remotePath})
+	}
+
+	bytesBefore := d.result.Bytes
+	for attempt := 0; ; attempt++ {
+		// A retry starts over, the partial data is what failed the check
+		err := d.copyFile(remotePath, localPath, info, d.opts.Resume && attempt == 0)
+		if err == nil {
+			err = d.verify(localPath, remotePath)
+		}
+		if err == nil {
+			break
+		}
+		if errors.Is(err, ErrVerifyFailed) && attempt < d.opts.VerifyRetries && d.ctx.Err() == nil {
+			d.result.Bytes = bytesBefore
+			continue
+		}
+		d.fail(remotePath, err)
+		return
+	}
+
+	// OpenFile applies the umask, set the exact mode afterwards
+	os.Chmod(localPath, info.Mode().Perm())
+	os.Chtimes(localPath, info.ModTime(), info.ModTime())
+	d.result.Files++
+
+	if d.progress != nil {
+		d.progress(TransferProgress{Files: d.result.Files, Bytes: d.result.Bytes, Current: remotePath})
+	}
+}
+
+// copyFile writes the contents of a remote file to localPath
+func (d *treeDownload) copyFile(remotePath, localPath string, info fs.FileInfo, resume bool) error {
+	remoteFile, err := d.client.sftpClient.Open(remotePath)
+	if err != nil {
+		return wrapSFTPError(err, "failed to open remote file")
+	}
+	defer remoteFile.Close()
+
+	var offset int64
+	if resume {
+		offset = localPartialOffset(remoteFile, info.Size(), localPath)
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if offset > 0 {
+		flags = os.O_WRONLY
+	}
+	localFile, err := os.OpenFile(localPath, flags, info.Mode().Perm())
+	if err != nil {
+		return fmt.Errorf("failed to create local file: %w", err)
+	}
+
+	if offset > 0 {
+		if err := seekBoth(localFile, remoteFile, offset); err != nil {
+			localFile.Close()
+			return err
+		}
+		d.result.Bytes += offset
+		d.result.Resumed++
+	}
+
+	// Report progress as data arrives so large files don't look stalled
+	writer := &progressWriter{w: contextWriter{ctx: d.ctx, w: localFile}, onWrite: func(n int64) {
+		d.result.Bytes += n
+		if d.progress != nil {
+			d.progress(TransferProgress{Files: d.result.Files, Bytes: d.result.Bytes, Current: remotePath})
+		}
+	}}
+
+	// WriteTo reads several chunks ahead instead of waiting for each one
+	_, err = remoteFile.WriteTo(writer)
+	if err != nil {
+		localFile.Close()
+		return fmt.Errorf("failed to download file: %w", err)
+	}
+	if err := localFile.Close(); err != nil {
+		return fmt.Errorf("failed to close local file: %w", err)
+	}
+	return nil
+}
+
+// verify compares a downloaded file with its source
+func (d *treeDownload) verify(localPath, remotePath string) error {
+	hashed, err := d.client.VerifyFile(localPath, remotePath, d.opts.Verify)
+	if hashed && err == nil {
+		d.result.Verified++
+	}
+	return err
+}
+
+// progressWriter calls onWrite with the size of every write that passes through it
+type progressWriter struct {
+	w       io.Writer
+	onWrite func(n int64)
+}
+
+func (pw *progressWriter) Write(p []byte) (int, error) {
+	n, err := pw.w.Write(p)
+	pw.onWrite(int64(n))
+	return n, err
+}
+
+// contextReader fails reads once ctx is cancelled, stopping a copy between chunks
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (cr contextReader) Read(p []byte) (int, error) {
+	if err := cr.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return cr.r.Read(p)
+}
+
+// contextWriter fails writes once ctx is cancelled, stopping a copy between chunks
+type contextWriter struct {
+	ctx context.Context
+	w   io.Writer
+}
+
+func (cw contextWriter) Write(p []byte) (int, error) {
+	if err := cw.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return cw.w.Write(p)
+}
+
+func (d *treeDownload) fail(p string, err error) {
+	// Entries interrupted by cancellation are not failures of their own
+	if d.ctx.Err() != nil {
+		return
+	}
+	d.result.Failed = append(d.result.Failed, FailedEntry{Path: p, Err: err})
+}
+
+func (d *treeDownload) skip(p string, reason error) {
+	d.result.Skipped = append(d.result.Skipped, FailedEntry{Path: p, Err: reason})
+}
@@ -0,0 +1,109 @@
+package sftp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestSafeEntryName(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"file.txt", true},
+		{".hidden", true},
+		{"..dots", true},
+		{"", false},
+		{".", false},
+		{"..", false},
+		{"../escape", false},
+		{"a/b", false},
+		{`a\b`, false},
+		{`..\escape`, false},
+	}
+
+	for _, tt := range tests {
+		if got := safeEntryName(tt.name); got != tt.want {
+			t.Errorf("safeEntryName(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSafeLinkTarget(t *testing.T) {
+	tests := []struct {
+		target string
+		depth  int
+		want   bool
+	}{
+		{"file", 0, true},
+		{"sub/file", 0, true},
+		{"./file", 0, true},
+		{"../file", 1, true},
+		{"../../a/b", 2, true},
+		{"", 0, false},
+		{"/etc/passwd", 3, false},
+		{"../file", 0, false},
+		{"../../file", 1, false},
+		{"sub/../file", 1, false}, // sub could be a link itself
+		{"sub/..", 0, false},
+		{"a//b", 0, true},
+	}
+
+	for _, tt := range tests {
+		if got := safeLinkTarget(tt.target, tt.depth); got != tt.want {
+			t.Errorf("safeLinkTarget(%q, %d) = %v, want %v", tt.target, tt.depth, got, tt.want)
+		}
+	}
+}
+
+func TestDownloadTreePreservedLinks(t *testing.T) {
+	c := newPipeClient(t, 0)
+	dir := t.TempDir()
+	remote := filepath.Join(dir, "remote")
+	writeTree(t, remote, map[string]string{"a": "1", "sub/": "", "sub/b": "2"})
+	links := map[string]string{
+		"to-a":        "a",
+		"sub/to-a":    "../a",
+		"sub/to-b":    "b",
+		"absolute":    "/etc/passwd",
+		"up":          "../outside",
+		"sub/up":      "../../outside",
+		"sub/through": "to-b/../../..",
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(remote, filepath.FromSlash(name))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	local := filepath.Join(dir, "local")
+	result, err := c.DownloadTree(context.Background(), filepath.ToSlash(remote), local, TransferOptions{Symlinks: SymlinkPreserve}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var skipped []string
+	for _, s := range result.Skipped {
+		rel, _ := filepath.Rel(remote, filepath.FromSlash(s.Path))
+		skipped = append(skipped, filepath.ToSlash(rel))
+	}
+	sort.Strings(skipped)
+	if want := []string{"absolute", "sub/through", "sub/up", "up"}; !reflect.DeepEqual(skipped, want) {
+		t.Errorf("skipped %q, want %q", skipped, want)
+	}
+	if result.Links != 3 {
+		t.Errorf("created %d links, want 3", result.Links)
+	}
+	for _, name := range []string{"to-a", "sub/to-a", "sub/to-b"} {
+		if target, err := os.Readlink(filepath.Join(local, filepath.FromSlash(name))); err != nil || target != links[name] {
+			t.Errorf("%s -> %q (%v), want %q", name, target, err, links[name])
+		}
+	}
+	if _, err := os.Lstat(filepath.Join(local, "absolute")); !os.IsNotExist(err) {
+		t.Errorf("link to an absolute path was created: %v", err)
+	}
+}
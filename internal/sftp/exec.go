@@ -0,0 +1,135 @@
+package sftp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// RunCommand runs a shell command on the server over the SSH connection
+// and returns its standard output
+func (c *Client) RunCommand(cmd string) ([]byte, error) {
+	if c.sshClient == nil {
+		return nil, fmt.Errorf("not connected")
+	}
+
+	session, err := c.sshClient.NewSession()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+	defer session.Close()
+
+	var stderr bytes.Buffer
+	session.Stderr = &stderr
+	out, err := session.Output(cmd)
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return out, fmt.Errorf("%w: %s", err, msg)
+		}
+		return out, err
+	}
+	return out, nil
+}
+
+// shellQuote quotes s as a single POSIX shell word
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// streamRecordMax is how much of an output record streamCommand keeps. The
+// rest of a longer one, like a match in minified code, is read past and dropped.
+const streamRecordMax = 64 << 10
+
+// streamCommand runs cmd on the server and hands its output to record one
+// sep-terminated record at a time as it arrives, until record returns false
+// or ctx is cancelled. It returns the exit status and standard error of the
+// command; err is only set when it could not run to the end.
+func (c *Client) streamCommand(ctx context.Context, cmd string, sep byte, record func([]byte) bool) (int, string, error) {
+	if c.sshClient == nil {
+		return 0, "", fmt.Errorf("not connected")
+	}
+
+	session, err := c.sshClient.NewSession()
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to create session: %w", err)
+	}
+	defer session.Close()
+
+	var stderr bytes.Buffer
+	session.Stderr = &stderr
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to run command: %w", err)
+	}
+	if err := session.Start(cmd); err != nil {
+		return 0, "", fmt.Errorf("failed to run command: %w", err)
+	}
+
+	// Closing the session stops the command when the caller gives up on it
+	stop := context.AfterFunc(ctx, func() { session.Close() })
+	defer stop()
+
+	stopped := false
+	reader := bufio.NewReaderSize(stdout, streamRecordMax)
+	for {
+		line, err := readRecord(reader, sep)
+		if len(line) > 0 && !record(bytes.TrimSuffix(line, []byte{sep})) {
+			stopped = true
+			session.Close()
+			break
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	err = session.Wait()
+	switch {
+	case ctx.Err() != nil:
+		return 0, "", ctx.Err()
+	case stopped:
+		return 0, stderr.String(), nil
+	}
+	var exitErr *ssh.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitStatus(), stderr.String(), nil
+	}
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to run command: %w", err)
+	}
+	return 0, stderr.String(), nil
+}
+
+// readRecord reads up to and including the next sep, like ReadBytes, but
+// keeps only the start of a record that does not fit in the buffer of r
+func readRecord(r *bufio.Reader, sep byte) ([]byte, error) {
+	line, err := r.ReadSlice(sep)
+	if err != bufio.ErrBufferFull {
+		return bytes.Clone(line), err
+	}
+
+	line = bytes.Clone(line)
+	for err == bufio.ErrBufferFull {
+		_, err = r.ReadSlice(sep)
+	}
+	if err == nil {
+		line = append(line, sep)
+	}
+	return line, err
+}
+
+// searchProblem returns the first complaint of a search command other than
+// unreadable entries, which are skipped like in an SFTP walk
+func searchProblem(stderr string) string {
+	for _, line := range strings.Split(strings.TrimSpace(stderr), "\n") {
+		if line != "" && !strings.Contains(line, "Permission denied") {
+			return line
+		}
+	}
+	return ""
+}
@@ -0,0 +1,47 @@
+package sftp
+
+import (
+	"bufio"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadRecord(t *testing.T) {
+	// 16 bytes is the smallest buffer bufio allows
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{"short records", "a\nbb\n", []string{"a\n", "bb\n"}},
+		{"last record without separator", "a\nb", []string{"a\n", "b"}},
+		{"record filling the buffer", strings.Repeat("x", 15) + "\ny\n", []string{strings.Repeat("x", 15) + "\n", "y\n"}},
+		{"long record is cut", strings.Repeat("x", 40) + "\ny\n", []string{strings.Repeat("x", 16) + "\n", "y\n"}},
+		{"long last record", strings.Repeat("x", 40), []string{strings.Repeat("x", 16)}},
+		{"empty", "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := bufio.NewReaderSize(strings.NewReader(tt.input), 16)
+			var got []string
+			for {
+				record, err := readRecord(r, '\n')
+				if len(record) > 0 {
+					got = append(got, string(record))
+				}
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,316 @@
+package sftp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/sftp"
+)
+
+// FindOptions narrow down a recursive search. Zero values match everything.
+type FindOptions struct {
+	Name           string // glob matched against entry names, like *.log
+	MinSize        int64  // size limits in bytes; with either set only regular files match
+	MaxSize        int64
+	ModifiedWithin time.Duration
+}
+
+// FindHit is an entry matching a search
+type FindHit struct {
+	Path string
+	Info FileInfo
+}
+
+// FindMethod is how a search went through the tree
+type FindMethod string
+
+const (
+	FindCommand FindMethod = "find on the server"
+	FindWalk    FindMethod = "SFTP walk"
+)
+
+// matches reports whether an entry, from lstat, passes the options
+func (o FindOptions) matches(info os.FileInfo, now time.Time) bool {
+	if o.Name != "" {
+		if ok, _ := path.Match(o.Name, info.Name()); !ok {
+			return false
+		}
+	}
+	if o.MinSize > 0 || o.MaxSize > 0 {
+		if !info.Mode().IsRegular() || info.Size() < o.MinSize {
+			return false
+		}
+		if o.MaxSize > 0 && info.Size() > o.MaxSize {
+			return false
+		}
+	}
+	if o.ModifiedWithin > 0 && now.Sub(info.ModTime()) > o.ModifiedWithin {
+		return false
+	}
+	return true
+}
+
+// Find looks for entries below root matching opts, without following
+// symlinks, and calls found for each one as it turns up. It runs find on
+// the server when it can, which is much faster on large trees, and walks
+// the tree over SFTP otherwise.
+func (c *Client) Find(ctx context.Context, root string, opts FindOptions, found func(FindHit)) (FindMethod, error) {
+	if c.sftpClient == nil {
+		return "", fmt.Errorf("not connected")
+	}
+	if opts.Name != "" {
+		if _, err := path.Match(opts.Name, ""); err != nil {
+			return "", fmt.Errorf("invalid pattern: %w", err)
+		}
+	}
+	if opts.MaxSize > 0 && opts.MinSize > opts.MaxSize {
+		return "", fmt.Errorf("the minimum size is above the maximum")
+	}
+
+	c.findOnce.Do(c.detectFind)
+	if c.hasFind {
+		hits, err := c.findWithCommand(ctx, root, opts, c.findPrintf, found)
+		if err == nil || hits > 0 || ctx.Err() != nil {
+			return FindCommand, err
+		}
+		// find failed before printing anything, like a busybox build
+		// without some option, so search again the slow way
+	}
+	return FindWalk, c.findWithWalk(ctx, root, opts, found)
+}
+
+// detectFind checks for find on the server, and whether it can print the
+// metadata of its hits like GNU find, once per connection
+func (c *Client) detectFind() {
+	out, err := c.RunCommand("command -v find")
+	c.hasFind = err == nil && len(bytes.TrimSpace(out)) > 0
+	if c.hasFind {
+		out, err = c.RunCommand("find / -maxdepth 0 -printf x")
+		c.findPrintf = err == nil && string(out) == "x"
+	}
+}
+
+// findFormat makes GNU find print the metadata of each hit and then where
+// it points, empty for anything but symlinks, as two NUL-terminated records
+const findFormat = `'%y %Y %s %T@ %m %U %G %p\0%l\0'`
+
+// findArgs builds the find command for opts, printing metadata with printf
+// and only paths otherwise. The entries it prints are checked against opts
+// again, so rounding here only has to be generous.
+func findArgs(root string, opts FindOptions, printf bool) string {
+	args := []string{"find", shellQuote(root), "-mindepth", "1"}
+	if opts.Name != "" {
+		args = append(args, "-name", shellQuote(opts.Name))
+	}
+	if opts.MinSize > 0 || opts.MaxSize > 0 {
+		args = append(args, "-type", "f")
+	}
+	if opts.MinSize > 0 {
+		args = append(args, "-size", fmt.Sprintf("+%dc", opts.MinSize-1))
+	}
+	if opts.MaxSize > 0 {
+		args = append(args, "-size", fmt.Sprintf("-%dc", opts.MaxSize+1))
+	}
+	if opts.ModifiedWithin > 0 {
+		args = append(args, "-mmin", fmt.Sprintf("-%d", int(math.Ceil(opts.ModifiedWithin.Minutes()))+1))
+	}
+	if printf {
+		return strings.Join(append(args, "-printf", findFormat), " ")
+	}
+	return strings.Join(append(args, "-print0"), " ")
+}
+
+// findWithCommand runs find over exec and returns how many hits it reported.
+// Without printf each hit is looked up over SFTP.
+func (c *Client) findWithCommand(ctx context.Context, root string, opts FindOptions, printf bool, found func(FindHit)) (int, error) {
+	accounts, err := c.Accounts()
+	if err != nil {
+		return 0, err
+	}
+
+	hits := 0
+	now := time.Now()
+	var head []byte // metadata record waiting for its link target
+	status, stderr, err := c.streamCommand(ctx, findArgs(root, opts, printf), 0, func(record []byte) bool {
+		if printf {
+			if head == nil {
+				head = record
+				return true
+			}
+			hit, info, ok := parseFindRecord(head, record, accounts)
+			head = nil
+			if ok && opts.matches(info, now) {
+				found(hit)
+				hits++
+			}
+			return true
+		}
+
+		p := string(record)
+		info, err := c.sftpClient.Lstat(p)
+		if err != nil || !opts.matches(info, now) {
+			return true // gone since, or outside a rounded limit
+		}
+		found(FindHit{Path: p, Info: c.toFileInfo(p, info, accounts)})
+		hits++
+		return true
+	})
+	if err != nil {
+		return hits, err
+	}
+	if problem := searchProblem(stderr); status != 0 && problem != "" {
+		return hits, fmt.Errorf("find failed: %s", problem)
+	}
+	return hits, nil
+}
+
+// findTypes maps the file types printed by find to mode bits
+var findTypes = map[byte]fs.FileMode{
+	'f': 0,
+	'd': fs.ModeDir,
+	'l': fs.ModeSymlink,
+	'p': fs.ModeNamedPipe,
+	's': fs.ModeSocket,
+	'c': fs.ModeDevice | fs.ModeCharDevice,
+	'b': fs.ModeDevice,
+}
+
+// parseFindRecord reads a hit printed with findFormat, from its metadata and
+// link target records. The returned os.FileInfo is for checking the options.
+func parseFindRecord(head, target []byte, accounts *Accounts) (FindHit, os.FileInfo, bool) {
+	fields := strings.SplitN(string(head), " ", 8)
+	if len(fields) != 8 || len(fields[0]) != 1 || len(fields[1]) != 1 || fields[7] == "" {
+		return FindHit{}, nil, false
+	}
+	kind, ok := findTypes[fields[0][0]]
+	size, err1 := strconv.ParseInt(fields[2], 10, 64)
+	mtime, err2 := strconv.ParseFloat(fields[3], 64)
+	perm, err3 := strconv.ParseUint(fields[4], 8, 32)
+	uid, err4 := strconv.ParseUint(fields[5], 10, 32)
+	gid, err5 := strconv.ParseUint(fields[6], 10, 32)
+	if !ok || errors.Join(err1, err2, err3, err4, err5) != nil {
+		return FindHit{}, nil, false
+	}
+
+	sec, frac := math.Modf(mtime)
+	stat := findStat{
+		name:    path.Base(fields[7]),
+		size:    size,
+		mode:    kind | fromUnixMode(uint32(perm)),
+		modTime: time.Unix(int64(sec), int64(frac*1e9)),
+		sys:     &sftp.FileStat{UID: uint32(uid), GID: uint32(gid)},
+	}
+	info := newFileInfo(stat, accounts)
+	if kind == fs.ModeSymlink {
+		info.IsLink = true
+		info.LinkTarget = string(target)
+		// %Y is the type of the target: N when missing, L in a loop
+		switch fields[1][0] {
+		case 'd':
+			info.LinksToDir = true
+		case 'N', 'L', '?':
+			info.LinkBroken = true
+		}
+	}
+	return FindHit{Path: fields[7], Info: info}, stat, true
+}
+
+// findStat is an os.FileInfo for a hit printed by find
+type findStat struct {
+	name    string
+	size    int64
+	mode    fs.FileMode
+	modTime time.Time
+	sys     *sftp.FileStat
+}
+
+func (s findStat) Name() string       { return s.name }
+func (s findStat) Size() int64        { return s.size }
+func (s findStat) Mode() fs.FileMode  { return s.mode }
+func (s findStat) ModTime() time.Time { return s.modTime }
+func (s findStat) IsDir() bool        { return s.mode.IsDir() }
+func (s findStat) Sys() any           { return s.sys }
+
+// findWithWalk goes through the tree over SFTP, skipping unreadable directories
+func (c *Client) findWithWalk(ctx context.Context, root string, opts FindOptions, found func(FindHit)) error {
+	accounts, err := c.Accounts()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	walker := c.sftpClient.Walk(root)
+	for walker.Step() {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := walker.Err(); err != nil {
+			if walker.Path() == root {
+				return wrapSFTPError(err, "failed to read directory")
+			}
+			continue
+		}
+		if walker.Path() == root || !opts.matches(walker.Stat(), now) {
+			continue
+		}
+		found(FindHit{Path: walker.Path(), Info: c.toFileInfo(walker.Path(), walker.Stat(), accounts)})
+	}
+	return nil
+}
+
+// ParseSize reads a size like 512, 10k, 1.5M or 2G, in powers of 1024
+func ParseSize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	value := strings.TrimRight(strings.ToUpper(s), "IB")
+	multiplier := 1.0
+	if value != "" {
+		switch value[len(value)-1] {
+		case 'K':
+			multiplier = 1 << 10
+		case 'M':
+			multiplier = 1 << 20
+		case 'G':
+			multiplier = 1 << 30
+		case 'T':
+			multiplier = 1 << 40
+		}
+		if multiplier > 1 {
+			value = value[:len(value)-1]
+		}
+	}
+	n, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q, use a number of bytes or a unit like 10k, 5M, 1G", s)
+	}
+	return int64(n * multiplier), nil
+}
+
+// ParseAge reads an age like 30m, 12h, 7d or 2w
+func ParseAge(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	unit := time.Duration(0)
+	switch {
+	case strings.HasSuffix(s, "d"):
+		unit = 24 * time.Hour
+	case strings.HasSuffix(s, "w"):
+		unit = 7 * 24 * time.Hour
+	}
+	if unit > 0 {
+		n, err := strconv.ParseFloat(s[:len(s)-1], 64)
+		if err == nil && n > 0 {
+			return time.Duration(n * float64(unit)), nil
+		}
+	} else if d, err := time.ParseDuration(s); err == nil && d > 0 {
+		return d, nil
+	}
+	return 0, fmt.Errorf("invalid age %q, use a duration like 30m, 12h, 7d or 2w", s)
+}
@@ -0,0 +1,271 @@
+package sftp
+
+import (
+	"bytes"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int64
+		wantErr bool
+	}{
+		{"512", 512, false},
+		{"0", 0, false},
+		{"10k", 10 << 10, false},
+		{"10K", 10 << 10, false},
+		{"10KB", 10 << 10, false},
+		{"10kib", 10 << 10, false},
+		{"1.5M", 3 << 19, false},
+		{" 2G ", 2 << 30, false},
+		{"1T", 1 << 40, false},
+		{"", 0, true},
+		{"k", 0, true},
+		{"-1", 0, true},
+		{"10x", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseSize(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseSize(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseSize(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"30m", 30 * time.Minute, false},
+		{"12h", 12 * time.Hour, false},
+		{"1h30m", 90 * time.Minute, false},
+		{"7d", 7 * 24 * time.Hour, false},
+		{"1.5d", 36 * time.Hour, false},
+		{"2w", 14 * 24 * time.Hour, false},
+		{"0d", 0, true},
+		{"-1h", 0, true},
+		{"d", 0, true},
+		{"7", 0, true},
+		{"", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseAge(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseAge(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseAge(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFindArgs(t *testing.T) {
+	tests := []struct {
+		name   string
+		root   string
+		opts   FindOptions
+		printf bool
+		want   string
+	}{
+		{"everything", "/srv", FindOptions{}, false,
+			`find '/srv' -mindepth 1 -print0`},
+		{"name", "/srv", FindOptions{Name: "*.log"}, false,
+			`find '/srv' -mindepth 1 -name '*.log' -print0`},
+		{"quoted root", "/it's", FindOptions{}, false,
+			`find '/it'\''s' -mindepth 1 -print0`},
+		{"minimum size includes the limit", "/", FindOptions{MinSize: 1024}, false,
+			`find '/' -mindepth 1 -type f -size +1023c -print0`},
+		{"maximum size includes the limit", "/", FindOptions{MaxSize: 10}, false,
+			`find '/' -mindepth 1 -type f -size -11c -print0`},
+		{"size range", "/", FindOptions{MinSize: 1, MaxSize: 1}, false,
+			`find '/' -mindepth 1 -type f -size +0c -size -2c -print0`},
+		{"whole minutes", "/", FindOptions{ModifiedWithin: 30 * time.Minute}, false,
+			`find '/' -mindepth 1 -mmin -31 -print0`},
+		{"partial minutes round up", "/", FindOptions{ModifiedWithin: 90 * time.Second}, false,
+			`find '/' -mindepth 1 -mmin -3 -print0`},
+		{"printf", "/srv", FindOptions{Name: "a b"}, true,
+			`find '/srv' -mindepth 1 -name 'a b' -printf '%y %Y %s %T@ %m %U %G %p\0%l\0'`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := findArgs(tt.root, tt.opts, tt.printf); got != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindOptionsMatches(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	file := func(name string, size int64, age time.Duration) os.FileInfo {
+		return findStat{name: name, size: size, mode: 0644, modTime: now.Add(-age)}
+	}
+	dir := findStat{name: "logs", mode: fs.ModeDir | 0755, modTime: now}
+
+	tests := []struct {
+		name string
+		opts FindOptions
+		info os.FileInfo
+		want bool
+	}{
+		{"no options", FindOptions{}, dir, true},
+		{"name matches", FindOptions{Name: "*.log"}, file("app.log", 1, 0), true},
+		{"name does not match", FindOptions{Name: "*.log"}, file("app.txt", 1, 0), false},
+		{"name is not a path", FindOptions{Name: "a*"}, file("b", 1, 0), false},
+		{"at the minimum size", FindOptions{MinSize: 10}, file("f", 10, 0), true},
+		{"below the minimum size", FindOptions{MinSize: 10}, file("f", 9, 0), false},
+		{"at the maximum size", FindOptions{MaxSize: 10}, file("f", 10, 0), true},
+		{"above the maximum size", FindOptions{MaxSize: 10}, file("f", 11, 0), false},
+		{"size limits skip directories", FindOptions{MaxSize: 1 << 20}, dir, false},
+		{"recently modified", FindOptions{ModifiedWithin: time.Hour}, file("f", 1, time.Hour), true},
+		{"modified too long ago", FindOptions{ModifiedWithin: time.Hour}, file("f", 1, time.Hour+time.Second), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.opts.matches(tt.info, now); got != tt.want {
+				t.Errorf("matches = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFindRecord(t *testing.T) {
+	accounts := &Accounts{users: map[int]string{0: "root"}, groups: map[int]string{100: "users"}}
+	tests := []struct {
+		name   string
+		head   string
+		target string
+		ok     bool
+		want   FileInfo
+		path   string
+	}{
+		{
+			name: "file with spaces and setuid",
+			head: "f f 3 1700000000.5000000000 4755 0 100 /srv/a b",
+			ok:   true,
+			path: "/srv/a b",
+			want: FileInfo{Name: "a b", Size: 3, Mode: fs.ModeSetuid | 0755, ModTime: time.Unix(1700000000, 5e8),
+				Permissions: (fs.ModeSetuid | 0755).String(), UID: 0, GID: 100, Owner: "root", Group: "users"},
+		},
+		{
+			name: "directory",
+			head: "d d 4096 1700000000.0000000000 755 1000 1000 /srv/d",
+			ok:   true,
+			path: "/srv/d",
+			want: FileInfo{Name: "d", Size: 4096, Mode: fs.ModeDir | 0755, ModTime: time.Unix(1700000000, 0), IsDir: true,
+				Permissions: (fs.ModeDir | 0755).String(), UID: 1000, GID: 1000, Owner: "1000", Group: "1000"},
+		},
+		{
+			name:   "link to a directory",
+			head:   "l d 1 1700000000.0000000000 777 0 100 /srv/ld",
+			target: "d",
+			ok:     true,
+			path:   "/srv/ld",
+			want: FileInfo{Name: "ld", Size: 1, Mode: fs.ModeSymlink | 0777, ModTime: time.Unix(1700000000, 0),
+				Permissions: (fs.ModeSymlink | 0777).String(), GID: 100, Owner: "root", Group: "users",
+				IsLink: true, LinkTarget: "d", LinksToDir: true},
+		},
+		{
+			name:   "broken link",
+			head:   "l N 4 1700000000.0000000000 777 0 100 /srv/lb",
+			target: "nope",
+			ok:     true,
+			path:   "/srv/lb",
+			want: FileInfo{Name: "lb", Size: 4, Mode: fs.ModeSymlink | 0777, ModTime: time.Unix(1700000000, 0),
+				Permissions: (fs.ModeSymlink | 0777).String(), GID: 100, Owner: "root", Group: "users",
+				IsLink: true, LinkTarget: "nope", LinkBroken: true},
+		},
+		{name: "unknown type", head: "D D 0 1700000000.0 644 0 0 /door"},
+		{name: "missing path", head: "f f 3 1700000000.0 644 0 0 "},
+		{name: "too few fields", head: "f f 3 1700000000.0 644 /x"},
+		{name: "bad mode", head: "f f 3 1700000000.0 999 0 0 /x"},
+		{name: "bad size", head: "f f x 1700000000.0 644 0 0 /x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hit, _, ok := parseFindRecord([]byte(tt.head), []byte(tt.target), accounts)
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v", ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			if hit.Path != tt.path {
+				t.Errorf("path = %q, want %q", hit.Path, tt.path)
+			}
+			if !hit.Info.ModTime.Equal(tt.want.ModTime) {
+				t.Errorf("modified %v, want %v", hit.Info.ModTime, tt.want.ModTime)
+			}
+			hit.Info.ModTime = tt.want.ModTime
+			if hit.Info != tt.want {
+				t.Errorf("info\n got %+v\nwant %+v", hit.Info, tt.want)
+			}
+		})
+	}
+}
+
+// TestFindFormat runs the printf command with the local find, when it is
+// GNU find, and reads back what it prints
+func TestFindFormat(t *testing.T) {
+	if out, err := exec.Command("find", "/", "-maxdepth", "0", "-printf", "x").Output(); err != nil || string(out) != "x" {
+		t.Skip("find without -printf")
+	}
+
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{"a b": "abc", "sub/": "", "sub/x.log": "1"})
+	os.Symlink("sub", filepath.Join(root, "to sub"))
+	os.Symlink("missing", filepath.Join(root, "dangling"))
+
+	out, err := exec.Command("sh", "-c", findArgs(root, FindOptions{}, true)).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := bytes.Split(bytes.TrimSuffix(out, []byte{0}), []byte{0})
+	if len(records)%2 != 0 {
+		t.Fatalf("odd number of records: %q", records)
+	}
+
+	got := map[string]FileInfo{}
+	for i := 0; i < len(records); i += 2 {
+		hit, _, ok := parseFindRecord(records[i], records[i+1], &Accounts{})
+		if !ok {
+			t.Fatalf("could not parse %q", records[i])
+		}
+		rel, _ := filepath.Rel(root, hit.Path)
+		got[filepath.ToSlash(rel)] = hit.Info
+	}
+
+	if len(got) != 5 {
+		t.Errorf("got %d entries, want 5: %v", len(got), got)
+	}
+	if f := got["a b"]; f.Size != 3 || !f.Mode.IsRegular() || !f.ModTime.Equal(syncTime) {
+		t.Errorf("a b = %+v", f)
+	}
+	if d := got["sub"]; !d.IsDir {
+		t.Errorf("sub = %+v", d)
+	}
+	if l := got["to sub"]; !l.IsLink || l.LinkTarget != "sub" || !l.LinksToDir || l.LinkBroken {
+		t.Errorf("to sub = %+v", l)
+	}
+	if l := got["dangling"]; !l.IsLink || l.LinkTarget != "missing" || !l.LinkBroken {
+		t.Errorf("dangling = %+v", l)
+	}
+}
@@ -0,0 +1,155 @@
+package sftp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// grepMaxText is how much of a matching line is kept for its preview
+const grepMaxText = 1024
+
+// GrepOptions describe a search of file contents
+type GrepOptions struct {
+	Pattern    string // extended regular expression, or plain text with Fixed
+	Fixed      bool
+	IgnoreCase bool
+	MaxMatches int // stop after this many matching lines, 0 for no limit
+}
+
+// GrepMatch is a matching line of a remote file
+type GrepMatch struct {
+	Path string
+	Line int    // 1-based
+	Text string // the line, cut to a preview for long ones
+}
+
+// GrepResult sums up a finished content search
+type GrepResult struct {
+	Tool      string // rg or grep
+	Matches   int
+	Truncated bool // stopped at MaxMatches
+}
+
+// Grep searches the contents of the files below root on the server, with
+// rg when it is installed and grep otherwise, calling found for each
+// matching line as it arrives. Binary files are skipped, and rg also skips
+// what .gitignore files exclude.
+func (c *Client) Grep(ctx context.Context, root string, opts GrepOptions, found func(GrepMatch)) (GrepResult, error) {
+	if c.sshClient == nil {
+		return GrepResult{}, fmt.Errorf("not connected")
+	}
+	if opts.Pattern == "" {
+		return GrepResult{}, fmt.Errorf("nothing to search for")
+	}
+
+	c.grepOnce.Do(c.detectGrep)
+	result := GrepResult{Tool: c.grepTool}
+	status, stderr, err := c.streamCommand(ctx, grepArgs(c.grepTool, c.grepNull, root, opts), '\n', func(record []byte) bool {
+		match, ok := parseGrepLine(root, record)
+		if !ok {
+			return true
+		}
+		found(match)
+		result.Matches++
+		if opts.MaxMatches > 0 && result.Matches >= opts.MaxMatches {
+			result.Truncated = true
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return result, err
+	}
+
+	// Both tools exit with 1 when nothing matched and 2 on errors
+	if problem := searchProblem(stderr); status > 1 && problem != "" {
+		return result, fmt.Errorf("%s failed: %s", result.Tool, problem)
+	}
+	return result, nil
+}
+
+// detectGrep prefers rg on the server, once per connection, and checks
+// whether grep can end paths with a NUL, which busybox grep cannot
+func (c *Client) detectGrep() {
+	if out, err := c.RunCommand("command -v rg"); err == nil && len(bytes.TrimSpace(out)) > 0 {
+		c.grepTool, c.grepNull = "rg", true
+		return
+	}
+	c.grepTool = "grep"
+	out, _ := c.RunCommand("echo x | grep --null -q x && echo ok")
+	c.grepNull = strings.TrimSpace(string(out)) == "ok"
+}
+
+// grepArgs builds the search command, printing path:line:text for every
+// match, with a NUL instead of the first colon when null is set. rg cuts
+// long lines itself; with grep streamCommand drops what does not fit.
+func grepArgs(tool string, null bool, root string, opts GrepOptions) string {
+	var args []string
+	if tool == "rg" {
+		args = []string{"rg", "--line-number", "--with-filename", "--no-heading", "--color", "never", "--hidden",
+			"--max-columns", strconv.Itoa(grepMaxText), "--max-columns-preview"}
+	} else {
+		args = []string{"grep", "-rnI"}
+	}
+	switch {
+	case opts.Fixed:
+		args = append(args, "-F")
+	case tool == "grep":
+		args = append(args, "-E")
+	}
+	if null {
+		args = append(args, "--null")
+	}
+	if opts.IgnoreCase {
+		args = append(args, "-i")
+	}
+	args = append(args, "-e", shellQuote(opts.Pattern), "--", shellQuote(root))
+	return strings.Join(args, " ")
+}
+
+// grepLineNumber finds the line number between the path and the text of an
+// output line without a NUL after the path
+var grepLineNumber = regexp.MustCompile(`:(\d+):`)
+
+// parseGrepLine splits an output line into its match. Without a NUL after
+// the path, the first :number: past root is taken as the line number, which
+// a name like "a:1:b" below root can fool.
+func parseGrepLine(root string, line []byte) (GrepMatch, bool) {
+	if !bytes.HasPrefix(line, []byte(root)) {
+		return GrepMatch{}, false
+	}
+
+	var filePath, number, text []byte
+	if i := bytes.IndexByte(line, 0); i >= 0 {
+		var ok bool
+		filePath = line[:i]
+		if number, text, ok = bytes.Cut(line[i+1:], []byte(":")); !ok {
+			return GrepMatch{}, false
+		}
+	} else {
+		loc := grepLineNumber.FindSubmatchIndex(line[len(root):])
+		if loc == nil {
+			return GrepMatch{}, false
+		}
+		filePath = line[:len(root)+loc[0]]
+		number = line[len(root)+loc[2] : len(root)+loc[3]]
+		text = line[len(root)+loc[1]:]
+	}
+
+	n, err := strconv.Atoi(string(number))
+	if err != nil {
+		return GrepMatch{}, false
+	}
+	if len(text) > grepMaxText {
+		text = text[:grepMaxText]
+	}
+	return GrepMatch{
+		Path: string(filePath),
+		Line: n,
+		Text: strings.ToValidUTF8(strings.TrimRight(string(text), "\r"), "?"),
+	}, true
+}
@@ -0,0 +1,90 @@
+package sftp
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGrepArgs(t *testing.T) {
+	rg := "rg --line-number --with-filename --no-heading --color never --hidden --max-columns 1024 --max-columns-preview"
+	tests := []struct {
+		name string
+		tool string
+		null bool
+		opts GrepOptions
+		want string
+	}{
+		{"rg", "rg", true, GrepOptions{Pattern: "TODO"},
+			rg + ` --null -e 'TODO' -- '/srv'`},
+		{"rg fixed ignoring case", "rg", true, GrepOptions{Pattern: "a.b", Fixed: true, IgnoreCase: true},
+			rg + ` -F --null -i -e 'a.b' -- '/srv'`},
+		{"grep uses extended expressions", "grep", true, GrepOptions{Pattern: "a|b"},
+			`grep -rnI -E --null -e 'a|b' -- '/srv'`},
+		{"grep fixed", "grep", true, GrepOptions{Pattern: "a|b", Fixed: true},
+			`grep -rnI -F --null -e 'a|b' -- '/srv'`},
+		{"grep without null", "grep", false, GrepOptions{Pattern: "x"},
+			`grep -rnI -E -e 'x' -- '/srv'`},
+		{"pattern starting with a dash and quotes", "grep", false, GrepOptions{Pattern: "-it's"},
+			`grep -rnI -E -e '-it'\''s' -- '/srv'`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := grepArgs(tt.tool, tt.null, "/srv", tt.opts); got != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseGrepLine(t *testing.T) {
+	long := strings.Repeat("x", grepMaxText+10)
+	tests := []struct {
+		name string
+		root string // /srv when empty
+		line string
+		ok   bool
+		want GrepMatch
+	}{
+		{"null", "", "/srv/a.go\x0012:func main() {", true,
+			GrepMatch{Path: "/srv/a.go", Line: 12, Text: "func main() {"}},
+		{"null keeps colons in the path", "", "/srv/a:1:b\x003:text", true,
+			GrepMatch{Path: "/srv/a:1:b", Line: 3, Text: "text"}},
+		{"null with colons in the text", "", "/srv/a\x001:x:2:y", true,
+			GrepMatch{Path: "/srv/a", Line: 1, Text: "x:2:y"}},
+		{"null without a line number", "", "/srv/a\x00text", false, GrepMatch{}},
+		{"plain", "", "/srv/a.go:12:func main() {", true,
+			GrepMatch{Path: "/srv/a.go", Line: 12, Text: "func main() {"}},
+		{"plain with colons in the text", "", "/srv/a:7:x:2:y", true,
+			GrepMatch{Path: "/srv/a", Line: 7, Text: "x:2:y"}},
+		{"plain colon in the root is skipped", "/s:1:v", "/s:1:v/a:4:x", true,
+			GrepMatch{Path: "/s:1:v/a", Line: 4, Text: "x"}},
+		// Without a NUL a name like a:1:b is read as a line number
+		{"plain is fooled by a:1:b", "", "/srv/a:1:b:3:text", true,
+			GrepMatch{Path: "/srv/a", Line: 1, Text: "b:3:text"}},
+		{"plain without a line number", "", "/srv/a:text", false, GrepMatch{}},
+		{"outside root", "", "/other/a:1:x", false, GrepMatch{}},
+		{"windows line ending", "", "/srv/a:1:x\r", true,
+			GrepMatch{Path: "/srv/a", Line: 1, Text: "x"}},
+		{"invalid UTF-8", "", "/srv/a:1:\xffok", true,
+			GrepMatch{Path: "/srv/a", Line: 1, Text: "?ok"}},
+		{"long line is cut", "", "/srv/a:1:" + long, true,
+			GrepMatch{Path: "/srv/a", Line: 1, Text: long[:grepMaxText]}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := tt.root
+			if root == "" {
+				root = "/srv"
+			}
+			got, ok := parseGrepLine(root, []byte(tt.line))
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v", ok, tt.ok)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
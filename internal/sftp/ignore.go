@@ -0,0 +1,172 @@
+package sftp
+
+import (
+	"bufio"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ignoreRule is one pattern of a .gitignore file
+type ignoreRule struct {
+	base     string // directory holding the .gitignore, relative to the root
+	pattern  string
+	negate   bool // "!pattern" re-includes a path
+	dirOnly  bool // "pattern/" only matches directories
+	anchored bool // the pattern contains a slash and matches from base
+}
+
+// ignoreMatcher decides which paths of a local tree are ignored, following
+// the .gitignore files in the tree. Later rules win over earlier ones.
+type ignoreMatcher struct {
+	rules []ignoreRule
+}
+
+// loadIgnore reads every .gitignore below root that is not itself in an
+// ignored directory. extra patterns apply as if listed in a .gitignore at the
+// root, and .git is always ignored.
+func loadIgnore(root string, extra []string) *ignoreMatcher {
+	m := &ignoreMatcher{}
+	m.rules = append(m.rules, parseIgnoreLines("", append([]string{".git/"}, extra...))...)
+
+	filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		rel := relSlash(root, p)
+		if rel != "" && m.Ignored(rel, true) {
+			return filepath.SkipDir
+		}
+		if lines, err := readLines(filepath.Join(p, ".gitignore")); err == nil {
+			m.rules = append(m.rules, parseIgnoreLines(rel, lines)...)
+		}
+		return nil
+	})
+	return m
+}
+
+// parseIgnoreLines turns .gitignore lines into rules relative to base
+func parseIgnoreLines(base string, lines []string) []ignoreRule {
+	var rules []ignoreRule
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		rule := ignoreRule{base: base}
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\`) {
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		if strings.Contains(line, "/") {
+			rule.anchored = true
+			line = strings.TrimPrefix(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		rule.pattern = line
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// Ignored reports whether the slash-separated path rel, or any directory
+// above it, is ignored
+func (m *ignoreMatcher) Ignored(rel string, isDir bool) bool {
+	for i := 0; i < len(rel); i++ {
+		if rel[i] == '/' && m.match(rel[:i], true) {
+			return true
+		}
+	}
+	return m.match(rel, isDir)
+}
+
+// match applies the rules to a single path, the last matching rule decides
+func (m *ignoreMatcher) match(rel string, isDir bool) bool {
+	ignored := false
+	for _, r := range m.rules {
+		if r.dirOnly && !isDir {
+			continue
+		}
+		sub := rel
+		if r.base != "" {
+			if !strings.HasPrefix(rel, r.base+"/") {
+				continue
+			}
+			sub = rel[len(r.base)+1:]
+		}
+
+		var ok bool
+		if r.anchored {
+			ok = globMatch(r.pattern, sub)
+		} else {
+			ok = globMatch(r.pattern, path.Base(sub))
+		}
+		if ok {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
+
+// globMatch matches a slash-separated path against a pattern in which "**"
+// stands for any number of directories
+func globMatch(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// readLines returns the lines of a small text file
+func readLines(p string) ([]string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines, scanner.Err()
+}
+
+// relSlash returns p relative to root with forward slashes, "" for root itself
+func relSlash(root, p string) string {
+	rel, err := filepath.Rel(root, p)
+	if err != nil || rel == "." {
+		return ""
+	}
+	return filepath.ToSlash(rel)
+}
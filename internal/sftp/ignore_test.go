@@ -0,0 +1,93 @@
+package sftp
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGlobMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"*.log", "a.log", true},
+		{"*.log", "a.txt", false},
+		{"*.go", "dir/a.go", false},
+		{"a/*/c", "a/b/c", true},
+		{"a/*/c", "a/b/x/c", false},
+		{"a/**/b", "a/b", true},
+		{"a/**/b", "a/x/y/b", true},
+		{"a/**/b", "x/a/b", false},
+		{"**/b", "b", true},
+		{"**/b", "x/y/b", true},
+		{"a/**", "a/x/y", true},
+		{"a/b?", "a/bc", true},
+		{"a/[bc]", "a/d", false},
+	}
+
+	for _, tt := range tests {
+		if got := globMatch(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("globMatch(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIgnoreMatcher(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		".gitignore":     "# comment\n\n*.log\nbuild/\n/top.txt\n!keep.log\ndocs/**/*.tmp\n\\#hash\n",
+		"sub/.gitignore": "local.txt\n!important.log\nnested/deep.txt\n",
+		// Rules in an ignored directory are never read
+		"build/.gitignore": "!out.o\n",
+	}
+	for name, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m := loadIgnore(root, []string{"*.bak"})
+
+	tests := []struct {
+		rel   string
+		isDir bool
+		want  bool
+	}{
+		{"main.go", false, false},
+		{"a.log", false, true},
+		{"x/y/a.log", false, true},
+		{"keep.log", false, false},
+		{"x/keep.log", false, false},
+		{"build", true, true},
+		{"build", false, false}, // dir/ patterns only match directories
+		{"build/out.o", false, true},
+		{"x/build/y", false, true},
+		{"top.txt", false, true},
+		{"x/top.txt", false, false},
+		{"docs/c.tmp", false, true},
+		{"docs/a/b/c.tmp", false, true},
+		{"other/c.tmp", false, false},
+		{"#hash", false, true},
+		{"sub/local.txt", false, true},
+		{"sub/x/local.txt", false, true},
+		{"local.txt", false, false},
+		{"sub/important.log", false, false},
+		{"sub/other.log", false, true},
+		{"sub/nested/deep.txt", false, true},
+		{"nested/deep.txt", false, false},
+		{".git", true, true},
+		{".git/config", false, true},
+		{"old.bak", false, true},
+	}
+
+	for _, tt := range tests {
+		if got := m.Ignored(tt.rel, tt.isDir); got != tt.want {
+			t.Errorf("Ignored(%q, %v) = %v, want %v", tt.rel, tt.isDir, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,379 @@
+package sftp
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/sftp"
+)
+
+// specialBits are the setuid, setgid and sticky bits of a mode
+const specialBits = fs.ModeSetuid | fs.ModeSetgid | fs.ModeSticky
+
+// ModeChange is a chmod mode: an absolute octal mode like 0755, or symbolic
+// clauses like u+x,go-w applied to the current mode of each file
+type ModeChange struct {
+	absolute bool
+	mode     fs.FileMode
+	clauses  []modeClause
+}
+
+// modeClause is one symbolic operation, like the +x of u+x
+type modeClause struct {
+	who   fs.FileMode // permission bits of the users it applies to
+	op    byte        // '+', '-' or '='
+	perms string      // letters out of rwxXst
+}
+
+// ParseMode parses an octal or symbolic chmod mode. Without users, a symbolic
+// clause applies to all of them; no umask is involved.
+func ParseMode(spec string) (ModeChange, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return ModeChange{}, fmt.Errorf("mode is empty")
+	}
+
+	if spec[0] >= '0' && spec[0] <= '7' {
+		n, err := strconv.ParseUint(spec, 8, 32)
+		if err != nil || n > 07777 {
+			return ModeChange{}, fmt.Errorf("invalid octal mode %q", spec)
+		}
+		return ModeChange{absolute: true, mode: fromUnixMode(uint32(n))}, nil
+	}
+
+	var change ModeChange
+	for _, part := range strings.Split(spec, ",") {
+		i := 0
+		var who fs.FileMode
+		for ; i < len(part) && strings.IndexByte("ugoa", part[i]) >= 0; i++ {
+			switch part[i] {
+			case 'u':
+				who |= 0700
+			case 'g':
+				who |= 0070
+			case 'o':
+				who |= 0007
+			case 'a':
+				who |= 0777
+			}
+		}
+		if who == 0 {
+			who = 0777
+		}
+		if i == len(part) {
+			return ModeChange{}, fmt.Errorf("invalid mode %q: missing +, - or =", spec)
+		}
+
+		// Several operations may follow the users, as in u+x-w
+		for i < len(part) {
+			op := part[i]
+			if op != '+' && op != '-' && op != '=' {
+				return ModeChange{}, fmt.Errorf("invalid mode %q: unexpected %q", spec, op)
+			}
+			i++
+			start := i
+			for ; i < len(part) && strings.IndexByte("rwxXst", part[i]) >= 0; i++ {
+			}
+			change.clauses = append(change.clauses, modeClause{who: who, op: op, perms: part[start:i]})
+		}
+	}
+	return change, nil
+}
+
+// Apply returns the mode a file with the given mode ends up with
+func (m ModeChange) Apply(mode fs.FileMode, isDir bool) fs.FileMode {
+	kind := mode.Type()
+	if m.absolute {
+		return kind | m.mode
+	}
+
+	mode &= fs.ModePerm | specialBits
+	for _, c := range m.clauses {
+		var bits fs.FileMode
+		for _, p := range c.perms {
+			switch p {
+			case 'r':
+				bits |= c.who & 0444
+			case 'w':
+				bits |= c.who & 0222
+			case 'x':
+				bits |= c.who & 0111
+			case 'X':
+				// Execute only for directories and files someone can already execute
+				if isDir || mode&0111 != 0 {
+					bits |= c.who & 0111
+				}
+			case 's':
+				if c.who&0700 != 0 {
+					bits |= fs.ModeSetuid
+				}
+				if c.who&0070 != 0 {
+					bits |= fs.ModeSetgid
+				}
+			case 't':
+				if c.who&0007 != 0 {
+					bits |= fs.ModeSticky
+				}
+			}
+		}
+
+		switch c.op {
+		case '+':
+			mode |= bits
+		case '-':
+			mode &^= bits
+		case '=':
+			clear := c.who
+			if c.who&0700 != 0 {
+				clear |= fs.ModeSetuid
+			}
+			if c.who&0070 != 0 {
+				clear |= fs.ModeSetgid
+			}
+			mode = mode&^clear | bits
+		}
+	}
+	return kind | mode
+}
+
+// OctalMode formats the permission and special bits of mode the way chmod takes them
+func OctalMode(mode fs.FileMode) string {
+	n := uint32(mode.Perm())
+	if mode&fs.ModeSetuid != 0 {
+		n |= 04000
+	}
+	if mode&fs.ModeSetgid != 0 {
+		n |= 02000
+	}
+	if mode&fs.ModeSticky != 0 {
+		n |= 01000
+	}
+	return fmt.Sprintf("%04o", n)
+}
+
+// fromUnixMode converts unix permission bits to a FileMode
+func fromUnixMode(n uint32) fs.FileMode {
+	mode := fs.FileMode(n & 0777)
+	if n&04000 != 0 {
+		mode |= fs.ModeSetuid
+	}
+	if n&02000 != 0 {
+		mode |= fs.ModeSetgid
+	}
+	if n&01000 != 0 {
+		mode |= fs.ModeSticky
+	}
+	return mode
+}
+
+// fileOwner returns the owner and group ids the server reported for a file,
+// or -1 when it did not
+func fileOwner(info os.FileInfo) (int, int) {
+	if st, ok := info.Sys().(*sftp.FileStat); ok {
+		return int(st.UID), int(st.GID)
+	}
+	return -1, -1
+}
+
+// Chmod changes the mode of a remote file, and with recursive set of
+// everything below a directory. Symlinks are skipped, as changing their
+// mode would change their target. Entries that cannot be changed are
+// returned instead of aborting the whole operation.
+func (c *Client) Chmod(remotePath string, change ModeChange, recursive bool) ([]FailedEntry, error) {
+	if c.sftpClient == nil {
+		return nil, fmt.Errorf("not connected")
+	}
+
+	return c.walkAttrs(remotePath, recursive, func(p string, info os.FileInfo) error {
+		mode := change.Apply(info.Mode(), info.IsDir())
+		if mode == info.Mode() {
+			return nil
+		}
+		return wrapSFTPError(c.sftpClient.Chmod(p, mode), "failed to change mode")
+	})
+}
+
+// Chown changes the owner and group of a remote file, and with recursive set
+// of everything below a directory. An id of -1 keeps the current one.
+// Symlinks are skipped like in Chmod.
+func (c *Client) Chown(remotePath string, uid, gid int, recursive bool) ([]FailedEntry, error) {
+	if c.sftpClient == nil {
+		return nil, fmt.Errorf("not connected")
+	}
+
+	return c.walkAttrs(remotePath, recursive, func(p string, info os.FileInfo) error {
+		curUID, curGID := fileOwner(info)
+		newUID, newGID := uid, gid
+		if newUID < 0 {
+			newUID = curUID
+		}
+		if newGID < 0 {
+			newGID = curGID
+		}
+		if newUID == curUID && newGID == curGID {
+			return nil
+		}
+		if newUID < 0 || newGID < 0 {
+			return fmt.Errorf("server did not report the current owner")
+		}
+		return wrapSFTPError(c.sftpClient.Chown(p, newUID, newGID), "failed to change owner")
+	})
+}
+
+// walkAttrs calls fn for root, and with recursive set for every entry below
+// it except symlinks. The error for root itself is returned as is.
+func (c *Client) walkAttrs(root string, recursive bool, fn func(p string, info os.FileInfo) error) ([]FailedEntry, error) {
+	root = path.Clean(root)
+	if !recursive {
+		info, err := c.sftpClient.Stat(root)
+		if err != nil {
+			return nil, wrapSFTPError(err, "failed to stat item")
+		}
+		return nil, fn(root, info)
+	}
+
+	var failures []FailedEntry
+	walker := c.sftpClient.Walk(root)
+	for walker.Step() {
+		if err := walker.Err(); err != nil {
+			if walker.Path() == root && walker.Stat() == nil {
+				return nil, wrapSFTPError(err, "failed to stat item")
+			}
+			failures = append(failures, FailedEntry{Path: walker.Path(), Err: wrapSFTPError(err, "failed to read directory")})
+			continue
+		}
+		info := walker.Stat()
+		if info.Mode()&fs.ModeSymlink != 0 {
+			continue
+		}
+		if err := fn(walker.Path(), info); err != nil {
+			failures = append(failures, FailedEntry{Path: walker.Path(), Err: err})
+		}
+	}
+	return failures, nil
+}
+
+// Accounts maps the user and group ids of the server to names
+type Accounts struct {
+	users  map[int]string
+	groups map[int]string
+}
+
+// Accounts reads the user and group names of the server from /etc/passwd and
+// /etc/group. Servers without them, like Windows ones, get empty maps.
+func (c *Client) Accounts() (*Accounts, error) {
+	if c.sftpClient == nil {
+		return nil, fmt.Errorf("not connected")
+	}
+
+	c.accountsOnce.Do(func() {
+		c.accounts = &Accounts{
+			users:  c.readIDNames("/etc/passwd"),
+			groups: c.readIDNames("/etc/group"),
+		}
+	})
+	return c.accounts, nil
+}
+
+// readIDNames reads the name and id fields of a passwd or group file
+func (c *Client) readIDNames(file string) map[int]string {
+	names := make(map[int]string)
+	f, err := c.sftpClient.Open(file)
+	if err != nil {
+		return names
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), ":")
+		if len(fields) < 3 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if id, err := strconv.Atoi(fields[2]); err == nil {
+			if _, seen := names[id]; !seen {
+				names[id] = fields[0]
+			}
+		}
+	}
+	return names
+}
+
+// UserName returns the name of a user id, or the id itself when it has none
+func (a *Accounts) UserName(uid int) string {
+	return idName(a.users, uid)
+}
+
+// GroupName returns the name of a group id, or the id itself when it has none
+func (a *Accounts) GroupName(gid int) string {
+	return idName(a.groups, gid)
+}
+
+func idName(names map[int]string, id int) string {
+	if id < 0 {
+		return "?"
+	}
+	if name, ok := names[id]; ok {
+		return name
+	}
+	return strconv.Itoa(id)
+}
+
+// LookupUser resolves a numeric id or a user name to a user id. Names
+// missing from /etc/passwd, like directory service users, are asked of the
+// server with id(1).
+func (c *Client) LookupUser(spec string) (int, error) {
+	accounts, err := c.Accounts()
+	if err != nil {
+		return 0, err
+	}
+	if id, ok := lookupID(accounts.users, spec); ok {
+		return id, nil
+	}
+
+	out, err := c.RunCommand("id -u " + shellQuote(spec))
+	if id, convErr := strconv.Atoi(string(bytes.TrimSpace(out))); err == nil && convErr == nil {
+		return id, nil
+	}
+	return 0, fmt.Errorf("unknown user %q", spec)
+}
+
+// LookupGroup resolves a numeric id or a group name to a group id, asking
+// the server with getent(1) for names missing from /etc/group
+func (c *Client) LookupGroup(spec string) (int, error) {
+	accounts, err := c.Accounts()
+	if err != nil {
+		return 0, err
+	}
+	if id, ok := lookupID(accounts.groups, spec); ok {
+		return id, nil
+	}
+
+	out, err := c.RunCommand("getent group " + shellQuote(spec))
+	if fields := strings.Split(string(bytes.TrimSpace(out)), ":"); err == nil && len(fields) >= 3 {
+		if id, err := strconv.Atoi(fields[2]); err == nil {
+			return id, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown group %q", spec)
+}
+
+// lookupID finds the id of a name, or parses a numeric id
+func lookupID(names map[int]string, spec string) (int, bool) {
+	spec = strings.TrimSpace(spec)
+	if id, err := strconv.Atoi(spec); err == nil && id >= 0 {
+		return id, true
+	}
+	for id, name := range names {
+		if name == spec {
+			return id, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,91 @@
+package sftp
+
+import (
+	"io/fs"
+	"testing"
+)
+
+func TestParseModeApply(t *testing.T) {
+	tests := []struct {
+		spec  string
+		mode  string // octal mode before the change
+		isDir bool
+		want  string
+	}{
+		{"755", "0600", false, "0755"},
+		{"0644", "4755", false, "0644"},
+		{"4755", "0644", false, "4755"},
+		{"1777", "0755", true, "1777"},
+		{"u+x", "0644", false, "0744"},
+		{"u+x-w", "0644", false, "0544"},
+		{"go-w", "0666", false, "0644"},
+		{"a=r", "0755", false, "0444"},
+		{"=r", "0755", false, "0444"},
+		{"+x", "0644", false, "0755"},
+		{"u=rw,g=r,o=", "0777", false, "0640"},
+		{"ug+rw,o-rwx", "0604", false, "0660"},
+		{"g+s", "0755", true, "2755"},
+		{"u+s", "0755", false, "4755"},
+		{"+t", "0777", true, "1777"},
+		{"u-s,g-s", "6755", false, "0755"},
+		{"u=rwx", "4755", false, "0755"}, // = clears setuid of the user
+		{"g=rx", "2775", true, "0755"},
+		{"o=rx", "2775", true, "2775"},
+		{"a+X", "0644", false, "0644"},
+		{"a+X", "0744", false, "0755"},
+		{"a+X", "0644", true, "0755"},
+		{"u+r", "0", false, "0400"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec+" on "+tt.mode, func(t *testing.T) {
+			change, err := ParseMode(tt.spec)
+			if err != nil {
+				t.Fatalf("ParseMode(%q) failed: %v", tt.spec, err)
+			}
+			before, err := ParseMode(tt.mode)
+			if err != nil {
+				t.Fatal(err)
+			}
+			mode := before.Apply(0, tt.isDir)
+			if tt.isDir {
+				mode |= fs.ModeDir
+			}
+
+			got := change.Apply(mode, tt.isDir)
+			if OctalMode(got) != tt.want {
+				t.Errorf("got %s, want %s", OctalMode(got), tt.want)
+			}
+			if got.IsDir() != tt.isDir {
+				t.Errorf("the file type changed: %v", got)
+			}
+		})
+	}
+}
+
+func TestParseModeErrors(t *testing.T) {
+	for _, spec := range []string{"", "  ", "8", "0758", "17777", "u", "ug", "u+y", "u+x,", "u*x"} {
+		if _, err := ParseMode(spec); err == nil {
+			t.Errorf("ParseMode(%q) succeeded, want an error", spec)
+		}
+	}
+}
+
+func TestOctalMode(t *testing.T) {
+	tests := []struct {
+		mode fs.FileMode
+		want string
+	}{
+		{0644, "0644"},
+		{fs.ModeDir | 0755, "0755"},
+		{fs.ModeSetuid | 0755, "4755"},
+		{fs.ModeSetgid | fs.ModeDir | 0775, "2775"},
+		{fs.ModeSticky | fs.ModeDir | 0777, "1777"},
+	}
+
+	for _, tt := range tests {
+		if got := OctalMode(tt.mode); got != tt.want {
+			t.Errorf("OctalMode(%v) = %s, want %s", tt.mode, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package sftp
+
+import (
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/sftp"
+)
+
+// delayedWriter delivers every write to w after a fixed delay without
+// limiting how much is in flight, like a link with latency but ample bandwidth
+type delayedWriter struct {
+	w      io.WriteCloser
+	delay  time.Duration
+	chunks chan delayedChunk
+
+	mu     sync.Mutex
+	closed bool
+}
+
+type delayedChunk struct {
+	data []byte
+	at   time.Time
+}
+
+func newDelayedWriter(w io.WriteCloser, delay time.Duration) *delayedWriter {
+	d := &delayedWriter{w: w, delay: delay, chunks: make(chan delayedChunk, 4096)}
+	go func() {
+		var err error
+		for c := range d.chunks {
+			if err == nil {
+				time.Sleep(time.Until(c.at))
+				_, err = d.w.Write(c.data)
+			}
+		}
+		d.w.Close()
+	}()
+	return d
+}
+
+func (d *delayedWriter) Write(p []byte) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return 0, io.ErrClosedPipe
+	}
+	d.chunks <- delayedChunk{data: append([]byte(nil), p...), at: time.Now().Add(d.delay)}
+	return len(p), nil
+}
+
+func (d *delayedWriter) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.closed {
+		d.closed = true
+		close(d.chunks)
+	}
+	return nil
+}
+
+// serverConn is the server end of the benchmark link
+type serverConn struct {
+	io.Reader
+	io.Writer
+	io.Closer
+}
+
+// newPipeClient connects a Client to an in-process SFTP server over a pipe,
+// with latency added in both directions. The server sees the local disk, and
+// the client has no SSH connection to run commands over.
+func newPipeClient(tb testing.TB, latency time.Duration) *Client {
+	clientEnd, serverEnd := net.Pipe()
+
+	server, err := sftp.NewServer(serverConn{serverEnd, newDelayedWriter(serverEnd, latency), serverEnd})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	go server.Serve()
+
+	sftpClient, err := sftp.NewClientPipe(clientEnd, newDelayedWriter(clientEnd, latency), Tuning{}.clientOptions()...)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() {
+		sftpClient.Close()
+		server.Close()
+	})
+	return &Client{sftpClient: sftpClient, currentDir: "/"}
+}
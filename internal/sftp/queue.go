@@ -0,0 +1,413 @@
+package sftp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DefaultTransferWorkers is the number of transfers run at once when not configured
+const DefaultTransferWorkers = 2
+
+// progressInterval limits how often progress of a running transfer is announced
+const progressInterval = 100 * time.Millisecond
+
+// TransferState is the lifecycle state of a queued transfer
+type TransferState int
+
+const (
+	TransferQueued TransferState = iota
+	TransferRunning
+	TransferDone
+	TransferFailed
+	TransferCancelled
+)
+
+// String returns a short label for the state
+func (s TransferState) String() string {
+	switch s {
+	case TransferRunning:
+		return "running"
+	case TransferDone:
+		return "done"
+	case TransferFailed:
+		return "failed"
+	case TransferCancelled:
+		return "cancelled"
+	default:
+		return "queued"
+	}
+}
+
+// TransferRequest describes an upload or download to queue
+type TransferRequest struct {
+	TransferOptions
+	Upload bool // local to remote, otherwise remote to local
+	Move   bool // remove the source once it transferred without problems
+	Source string
+	Dest   string
+	Sync   *SyncPlan // apply this plan instead of copying Source to Dest
+}
+
+// Transfer is a snapshot of a queued transfer and its progress
+type Transfer struct {
+	TransferRequest
+	ID       int
+	State    TransferState
+	Total    int64 // bytes to transfer, known once the transfer starts
+	Progress TransferProgress
+	Result   *TreeResult
+	Err      error
+	Started  time.Time
+	Finished time.Time
+}
+
+// Ended reports whether the transfer will not change anymore
+func (t Transfer) Ended() bool {
+	return t.State == TransferDone || t.State == TransferFailed || t.State == TransferCancelled
+}
+
+// Rate returns the average speed in bytes per second
+func (t Transfer) Rate() float64 {
+	if t.Started.IsZero() {
+		return 0
+	}
+	end := t.Finished
+	if end.IsZero() {
+		end = time.Now()
+	}
+	elapsed := end.Sub(t.Started).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(t.Progress.Bytes) / elapsed
+}
+
+// ETA estimates the time left at the current average rate, zero when unknown
+func (t Transfer) ETA() time.Duration {
+	rate := t.Rate()
+	if t.State != TransferRunning || rate <= 0 || t.Total <= t.Progress.Bytes {
+		return 0
+	}
+	return time.Duration(float64(t.Total-t.Progress.Bytes) / rate * float64(time.Second))
+}
+
+// QueueOptions configures a TransferManager
+type QueueOptions struct {
+	Workers       int  // transfers run at once, DefaultTransferWorkers if unset
+	Verify        bool // compare SHA-256 of every transferred file
+	VerifyRetries int  // times a file is copied again after a failed check
+}
+
+// TransferManager runs queued transfers on a fixed number of workers.
+// Changes are announced on Updates so a UI can refresh its snapshot.
+type TransferManager struct {
+	client *Client
+	opts   QueueOptions
+
+	mu         sync.Mutex
+	transfers  []*Transfer
+	cancels    map[int]context.CancelFunc
+	nextID     int
+	lastNotify time.Time
+
+	wake    chan struct{}
+	updates chan struct{}
+	done    chan struct{}
+	closed  bool
+}
+
+// NewTransferManager starts opts.Workers transfers at a time over client
+func NewTransferManager(client *Client, opts QueueOptions) *TransferManager {
+	if opts.Workers < 1 {
+		opts.Workers = DefaultTransferWorkers
+	}
+	workers := opts.Workers
+
+	tm := &TransferManager{
+		client:  client,
+		opts:    opts,
+		cancels: make(map[int]context.CancelFunc),
+		nextID:  1,
+		wake:    make(chan struct{}, workers),
+		updates: make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	for range workers {
+		go tm.worker()
+	}
+	return tm
+}
+
+// Enqueue adds a transfer to the queue and returns its ID. The queue's
+// verification settings apply to every transfer.
+func (tm *TransferManager) Enqueue(req TransferRequest) int {
+	req.Verify = tm.opts.Verify
+	req.VerifyRetries = tm.opts.VerifyRetries
+
+	tm.mu.Lock()
+	t := &Transfer{TransferRequest: req, ID: tm.nextID}
+	tm.nextID++
+	tm.transfers = append(tm.transfers, t)
+	tm.mu.Unlock()
+
+	select {
+	case tm.wake <- struct{}{}:
+	default:
+	}
+	tm.notify()
+	return t.ID
+}
+
+// Transfers returns a snapshot of every transfer in queue order
+func (tm *TransferManager) Transfers() []Transfer {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	list := make([]Transfer, len(tm.transfers))
+	for i, t := range tm.transfers {
+		list[i] = *t
+	}
+	return list
+}
+
+// Updates signals that transfers changed. Signals are coalesced, so a
+// receiver should read the full state with Transfers afterwards.
+func (tm *TransferManager) Updates() <-chan struct{} {
+	return tm.updates
+}
+
+// Cancel stops a running transfer or removes a queued one from the queue
+func (tm *TransferManager) Cancel(id int) {
+	tm.mu.Lock()
+	for _, t := range tm.transfers {
+		if t.ID != id {
+			continue
+		}
+		switch t.State {
+		case TransferQueued:
+			t.State = TransferCancelled
+			t.Finished = time.Now()
+		case TransferRunning:
+			if cancel, ok := tm.cancels[id]; ok {
+				cancel()
+			}
+		}
+	}
+	tm.mu.Unlock()
+	tm.notify()
+}
+
+// CancelAll cancels every queued and running transfer
+func (tm *TransferManager) CancelAll() {
+	for _, t := range tm.Transfers() {
+		if !t.Ended() {
+			tm.Cancel(t.ID)
+		}
+	}
+}
+
+// ClearEnded removes finished, failed and cancelled transfers from the list
+func (tm *TransferManager) ClearEnded() {
+	tm.mu.Lock()
+	kept := tm.transfers[:0]
+	for _, t := range tm.transfers {
+		if !t.Ended() {
+			kept = append(kept, t)
+		}
+	}
+	tm.transfers = kept
+	tm.mu.Unlock()
+	tm.notify()
+}
+
+// Active returns the number of queued and running transfers
+func (tm *TransferManager) Active() int {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	n := 0
+	for _, t := range tm.transfers {
+		if !t.Ended() {
+			n++
+		}
+	}
+	return n
+}
+
+// Close cancels all transfers and stops the workers
+func (tm *TransferManager) Close() {
+	tm.CancelAll()
+
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	if !tm.closed {
+		tm.closed = true
+		close(tm.done)
+	}
+}
+
+// notify signals a change without blocking when nobody is listening
+func (tm *TransferManager) notify() {
+	select {
+	case tm.updates <- struct{}{}:
+	default:
+	}
+}
+
+// worker runs queued transfers until the manager is closed
+func (tm *TransferManager) worker() {
+	for {
+		t, ctx := tm.next()
+		if t == nil {
+			select {
+			case <-tm.wake:
+				continue
+			case <-tm.done:
+				return
+			}
+		}
+		tm.run(ctx, t)
+	}
+}
+
+// next claims the oldest queued transfer, or returns nil if there is none
+func (tm *TransferManager) next() (*Transfer, context.Context) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	if tm.closed {
+		return nil, nil
+	}
+	for _, t := range tm.transfers {
+		if t.State == TransferQueued {
+			ctx, cancel := context.WithCancel(context.Background())
+			tm.cancels[t.ID] = cancel
+			t.State = TransferRunning
+			t.Started = time.Now()
+			return t, ctx
+		}
+	}
+	return nil, nil
+}
+
+// run performs a claimed transfer and records its outcome
+func (tm *TransferManager) run(ctx context.Context, t *Transfer) {
+	tm.notify()
+
+	var total int64
+	switch {
+	case t.Sync != nil:
+		total = t.Sync.Bytes()
+	case t.Upload:
+		total = localTreeSize(t.Source)
+	default:
+		total = tm.client.remoteTreeSize(t.Source)
+	}
+	tm.mu.Lock()
+	t.Total = total
+	tm.mu.Unlock()
+
+	progress := func(p TransferProgress) {
+		tm.mu.Lock()
+		t.Progress = p
+		announce := time.Since(tm.lastNotify) >= progressInterval
+		if announce {
+			tm.lastNotify = time.Now()
+		}
+		tm.mu.Unlock()
+		if announce {
+			tm.notify()
+		}
+	}
+
+	var result *TreeResult
+	var err error
+	switch {
+	case t.Sync != nil:
+		result, err = tm.client.ApplySync(ctx, t.Sync, t.TransferOptions, progress)
+	case t.Upload:
+		result, err = tm.client.UploadTree(ctx, t.Source, t.Dest, t.TransferOptions, progress)
+	default:
+		result, err = tm.client.DownloadTree(ctx, t.Source, t.Dest, t.TransferOptions, progress)
+	}
+
+	// Only remove the source of a move if all of it arrived
+	if t.Move && err == nil && len(result.Failed) == 0 && len(result.Skipped) == 0 {
+		err = tm.removeSource(t.TransferRequest)
+	}
+
+	tm.mu.Lock()
+	tm.cancels[t.ID]()
+	delete(tm.cancels, t.ID)
+	t.Result = result
+	t.Err = err
+	t.Finished = time.Now()
+	switch {
+	case errors.Is(err, context.Canceled):
+		t.State = TransferCancelled
+	case err != nil || (result != nil && len(result.Failed) > 0):
+		t.State = TransferFailed
+	default:
+		t.State = TransferDone
+	}
+	tm.mu.Unlock()
+	tm.notify()
+}
+
+// removeSource deletes the source of a completed move
+func (tm *TransferManager) removeSource(req TransferRequest) error {
+	if req.Upload {
+		if err := os.RemoveAll(req.Source); err != nil {
+			return fmt.Errorf("failed to remove after move: %w", err)
+		}
+		return nil
+	}
+
+	failures, err := tm.client.DeleteRecursive(req.Source, nil)
+	if err != nil {
+		return err
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("failed to remove after move: %w", failures[0].Err)
+	}
+	return nil
+}
+
+// localTreeSize returns the total size of the regular files below root
+func localTreeSize(root string) int64 {
+	var total int64
+	filepath.WalkDir(root, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				total += info.Size()
+			}
+		}
+		return nil
+	})
+	return total
+}
+
+// remoteTreeSize returns the total size of the regular files below a remote path
+func (c *Client) remoteTreeSize(p string) int64 {
+	info, err := c.Stat(p)
+	if err != nil {
+		return 0
+	}
+	if !info.IsDir {
+		return info.Size
+	}
+	summary, err := c.SummarizeTree(p)
+	if err != nil {
+		return 0
+	}
+	return summary.TotalSize
+}
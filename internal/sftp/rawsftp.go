@@ -0,0 +1,260 @@
+package sftp
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// SFTP packet types and open flags used by rawSFTP, from
+// draft-ietf-secsh-filexfer-02
+const (
+	fxpInit     = 1
+	fxpVersion  = 2
+	fxpOpen     = 3
+	fxpClose    = 4
+	fxpStatus   = 101
+	fxpHandle   = 102
+	fxpExtended = 200
+
+	fxfRead  = 0x01
+	fxfWrite = 0x02
+	fxfCreat = 0x08
+	fxfExcl  = 0x20
+
+	fxAttrPermissions = 0x04
+
+	rawMaxPacket = 256 * 1024
+)
+
+// rawSFTP is a second SFTP session on the connection, for the requests
+// pkg/sftp cannot send, like the copy-data extension. Requests are sent one
+// at a time.
+type rawSFTP struct {
+	mu      sync.Mutex
+	w       io.Writer
+	r       io.Reader
+	session io.Closer
+	nextID  uint32
+	closed  bool // shut down after a failed read or write, or with the client
+}
+
+// rawSession opens the raw session on first use, and again after the last
+// one failed
+func (c *Client) rawSession() (*rawSFTP, error) {
+	c.rawMu.Lock()
+	defer c.rawMu.Unlock()
+	if c.raw != nil {
+		if c.raw.usable() {
+			return c.raw, nil
+		}
+		c.raw = nil
+	}
+	if c.sshClient == nil {
+		return nil, fmt.Errorf("not connected")
+	}
+
+	session, err := c.sshClient.NewSession()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+	w, err := session.StdinPipe()
+	if err != nil {
+		session.Close()
+		return nil, fmt.Errorf("failed to open sftp session: %w", err)
+	}
+	r, err := session.StdoutPipe()
+	if err != nil {
+		session.Close()
+		return nil, fmt.Errorf("failed to open sftp session: %w", err)
+	}
+	if err := session.RequestSubsystem("sftp"); err != nil {
+		session.Close()
+		return nil, fmt.Errorf("failed to open sftp session: %w", err)
+	}
+
+	raw := &rawSFTP{w: w, r: r, session: session}
+	init := binary.BigEndian.AppendUint32([]byte{fxpInit}, 3)
+	if err := raw.write(init); err != nil {
+		session.Close()
+		return nil, err
+	}
+	if typ, _, err := raw.read(); err != nil || typ != fxpVersion {
+		session.Close()
+		return nil, fmt.Errorf("failed to open sftp session: unexpected reply")
+	}
+	c.raw = raw
+	return raw, nil
+}
+
+// closeRawSession shuts the raw session down when the client closes
+func (c *Client) closeRawSession() {
+	c.rawMu.Lock()
+	defer c.rawMu.Unlock()
+	if c.raw != nil {
+		c.raw.shutdown()
+		c.raw = nil
+	}
+}
+
+// usable reports whether the session can still take requests
+func (s *rawSFTP) usable() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return !s.closed
+}
+
+// shutdown closes the session, failing the requests that follow
+func (s *rawSFTP) shutdown() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.shutdownLocked()
+}
+
+func (s *rawSFTP) shutdownLocked() {
+	if !s.closed {
+		s.closed = true
+		s.session.Close()
+	}
+}
+
+// write sends one packet, adding its length
+func (s *rawSFTP) write(packet []byte) error {
+	buf := binary.BigEndian.AppendUint32(nil, uint32(len(packet)))
+	if _, err := s.w.Write(append(buf, packet...)); err != nil {
+		return fmt.Errorf("failed to send sftp request: %w", err)
+	}
+	return nil
+}
+
+// read receives one packet and returns its type and the rest of it
+func (s *rawSFTP) read() (byte, []byte, error) {
+	var length [4]byte
+	if _, err := io.ReadFull(s.r, length[:]); err != nil {
+		return 0, nil, fmt.Errorf("failed to read sftp reply: %w", err)
+	}
+	n := binary.BigEndian.Uint32(length[:])
+	if n == 0 || n > rawMaxPacket {
+		return 0, nil, fmt.Errorf("failed to read sftp reply: bad length %d", n)
+	}
+	body := make([]byte, n)
+	if _, err := io.ReadFull(s.r, body); err != nil {
+		return 0, nil, fmt.Errorf("failed to read sftp reply: %w", err)
+	}
+	return body[0], body[1:], nil
+}
+
+// request sends a request of the given type and waits for its reply,
+// returning the reply type and the data after the request id
+func (s *rawSFTP) request(typ byte, payload []byte) (byte, []byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return 0, nil, fmt.Errorf("sftp session closed")
+	}
+
+	// After a failed read or write the stream may be out of step, so the
+	// session is closed and the next request opens a new one
+	s.nextID++
+	id := s.nextID
+	packet := binary.BigEndian.AppendUint32([]byte{typ}, id)
+	if err := s.write(append(packet, payload...)); err != nil {
+		s.shutdownLocked()
+		return 0, nil, err
+	}
+
+	replyType, body, err := s.read()
+	if err != nil {
+		s.shutdownLocked()
+		return 0, nil, err
+	}
+	if len(body) < 4 || binary.BigEndian.Uint32(body) != id {
+		s.shutdownLocked()
+		return 0, nil, fmt.Errorf("failed to read sftp reply: unexpected id")
+	}
+	return replyType, body[4:], nil
+}
+
+// status turns a STATUS reply into an error, nil for success
+func status(typ byte, body []byte) error {
+	if typ != fxpStatus || len(body) < 4 {
+		return fmt.Errorf("unexpected sftp reply %d", typ)
+	}
+	code := binary.BigEndian.Uint32(body)
+	if code == 0 {
+		return nil
+	}
+	msg, _ := readString(body[4:])
+	if msg == "" {
+		msg = fmt.Sprintf("status %d", code)
+	}
+	return fmt.Errorf("%s", msg)
+}
+
+// open opens a file, creating it with perm when pflags ask for it, and
+// returns its handle
+func (s *rawSFTP) open(p string, pflags uint32, perm uint32) (string, error) {
+	payload := appendString(nil, p)
+	payload = binary.BigEndian.AppendUint32(payload, pflags)
+	if pflags&fxfCreat != 0 {
+		payload = binary.BigEndian.AppendUint32(payload, fxAttrPermissions)
+		payload = binary.BigEndian.AppendUint32(payload, perm)
+	} else {
+		payload = binary.BigEndian.AppendUint32(payload, 0)
+	}
+
+	typ, body, err := s.request(fxpOpen, payload)
+	if err != nil {
+		return "", err
+	}
+	if typ != fxpHandle {
+		return "", status(typ, body)
+	}
+	handle, ok := readString(body)
+	if !ok {
+		return "", fmt.Errorf("malformed sftp handle")
+	}
+	return handle, nil
+}
+
+// close closes a handle
+func (s *rawSFTP) close(handle string) error {
+	typ, body, err := s.request(fxpClose, appendString(nil, handle))
+	if err != nil {
+		return err
+	}
+	return status(typ, body)
+}
+
+// copyData copies the whole file behind src to the start of dst on the server
+func (s *rawSFTP) copyData(src, dst string) error {
+	payload := appendString(nil, "copy-data")
+	payload = appendString(payload, src)
+	payload = binary.BigEndian.AppendUint64(payload, 0) // read offset
+	payload = binary.BigEndian.AppendUint64(payload, 0) // length, 0 is up to the end
+	payload = appendString(payload, dst)
+	payload = binary.BigEndian.AppendUint64(payload, 0) // write offset
+
+	typ, body, err := s.request(fxpExtended, payload)
+	if err != nil {
+		return err
+	}
+	return status(typ, body)
+}
+
+func appendString(b []byte, s string) []byte {
+	b = binary.BigEndian.AppendUint32(b, uint32(len(s)))
+	return append(b, s...)
+}
+
+func readString(b []byte) (string, bool) {
+	if len(b) < 4 {
+		return "", false
+	}
+	n := binary.BigEndian.Uint32(b)
+	if uint64(len(b)-4) < uint64(n) {
+		return "", false
+	}
+	return string(b[4 : 4+n]), true
+}
@@ -0,0 +1,84 @@
+package sftp
+
+import (
+	"encoding/binary"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/sftp"
+)
+
+// newRawPipe opens a raw session to an in-process SFTP server, returning the
+// server end of the pipe to break the connection with
+func newRawPipe(t *testing.T) (*rawSFTP, net.Conn) {
+	clientEnd, serverEnd := net.Pipe()
+	server, err := sftp.NewServer(serverEnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go server.Serve()
+	t.Cleanup(func() { server.Close() })
+
+	raw := &rawSFTP{w: clientEnd, r: clientEnd, session: clientEnd}
+	if err := raw.write(binary.BigEndian.AppendUint32([]byte{fxpInit}, 3)); err != nil {
+		t.Fatal(err)
+	}
+	if typ, _, err := raw.read(); err != nil || typ != fxpVersion {
+		t.Fatalf("no version reply: %d, %v", typ, err)
+	}
+	return raw, serverEnd
+}
+
+func TestRawSFTPShutsDownAfterIOError(t *testing.T) {
+	raw, serverEnd := newRawPipe(t)
+	file := filepath.Join(t.TempDir(), "file")
+	os.WriteFile(file, []byte("x"), 0644)
+
+	handle, err := raw.open(filepath.ToSlash(file), fxfRead, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := raw.close(handle); err != nil {
+		t.Fatal(err)
+	}
+
+	// An error reply leaves the session usable
+	if _, err := raw.open(filepath.ToSlash(file)+".missing", fxfRead, 0); err == nil {
+		t.Fatal("opening a missing file succeeded")
+	}
+	if !raw.usable() {
+		t.Fatal("session closed after an error reply")
+	}
+
+	serverEnd.Close()
+	if _, err := raw.open(filepath.ToSlash(file), fxfRead, 0); err == nil {
+		t.Fatal("request over a broken pipe succeeded")
+	}
+	if raw.usable() {
+		t.Fatal("session still usable after a failed request")
+	}
+}
+
+func TestRawSessionReplacesClosedSession(t *testing.T) {
+	raw, _ := newRawPipe(t)
+	raw.shutdown()
+
+	c := &Client{raw: raw}
+	if _, err := c.rawSession(); err == nil {
+		t.Fatal("got a session without a connection")
+	}
+	if c.raw != nil {
+		t.Error("the closed session was kept")
+	}
+}
+
+func TestCloseShutsDownRawSession(t *testing.T) {
+	raw, _ := newRawPipe(t)
+	c := &Client{raw: raw}
+	c.Close()
+	if raw.usable() || c.raw != nil {
+		t.Error("Close left the raw session open")
+	}
+}
@@ -0,0 +1,98 @@
+package sftp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/pkg/sftp"
+)
+
+// ReadHead returns up to n bytes from the start of a remote file, without
+// transferring the rest of it
+func (c *Client) ReadHead(remotePath string, n int64) ([]byte, error) {
+	if c.sftpClient == nil {
+		return nil, fmt.Errorf("not connected")
+	}
+
+	f, err := c.sftpClient.Open(remotePath)
+	if err != nil {
+		return nil, wrapSFTPError(err, "failed to open remote file")
+	}
+	defer f.Close()
+
+	buf := make([]byte, n)
+	read, err := io.ReadFull(f, buf)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, fmt.Errorf("failed to read remote file: %w", err)
+	}
+	return buf[:read], nil
+}
+
+// RemoteFile is a remote file opened for reads at arbitrary offsets
+type RemoteFile struct {
+	file *sftp.File
+}
+
+// OpenRemote opens a remote file for ranged reads, so parts of a large file
+// can be read without transferring the rest
+func (c *Client) OpenRemote(remotePath string) (*RemoteFile, error) {
+	if c.sftpClient == nil {
+		return nil, fmt.Errorf("not connected")
+	}
+
+	f, err := c.sftpClient.Open(remotePath)
+	if err != nil {
+		return nil, wrapSFTPError(err, "failed to open remote file")
+	}
+	return &RemoteFile{file: f}, nil
+}
+
+// ReadAt reads len(p) bytes at off. It is safe to call from several goroutines.
+func (r *RemoteFile) ReadAt(p []byte, off int64) (int, error) {
+	return r.file.ReadAt(p, off)
+}
+
+// Size returns the current size of the open file
+func (r *RemoteFile) Size() (int64, error) {
+	info, err := r.file.Stat()
+	if err != nil {
+		return 0, wrapSFTPError(err, "failed to stat remote file")
+	}
+	return info.Size(), nil
+}
+
+// Close closes the remote file
+func (r *RemoteFile) Close() error {
+	return r.file.Close()
+}
+
+// FileChange is what PollRemote found out about a followed remote file
+type FileChange struct {
+	Size     int64 // current size of the file at the path
+	Replaced bool  // truncated or rotated, the path should be opened again
+}
+
+// PollRemote checks a followed file, of which the first known bytes have
+// been read through f, for growth, truncation and rotation
+func (c *Client) PollRemote(remotePath string, f *RemoteFile, known int64) (FileChange, error) {
+	if c.sftpClient == nil {
+		return FileChange{}, fmt.Errorf("not connected")
+	}
+
+	info, err := c.sftpClient.Stat(remotePath)
+	if err != nil {
+		return FileChange{}, wrapSFTPError(err, "failed to stat remote file")
+	}
+	if info.Size() < known {
+		return FileChange{Size: info.Size(), Replaced: true}, nil
+	}
+
+	// The open file is checked after the path, so if the path still names
+	// it, it can only have grown in between. A smaller one was rotated away.
+	size, err := f.Size()
+	if err != nil {
+		return FileChange{}, err
+	}
+	return FileChange{Size: info.Size(), Replaced: size < info.Size()}, nil
+}
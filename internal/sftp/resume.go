@@ -0,0 +1,142 @@
+package sftp
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+)
+
+// resumeCheckSize is how much of the end of a partial file is compared with
+// the source before continuing it. A mismatch means the file changed since
+// the interrupted transfer and has to be rewritten.
+const resumeCheckSize = 64 * 1024
+
+// PartialFile describes a destination that already holds part of its source
+type PartialFile struct {
+	Have int64 // bytes already at the destination
+	Want int64 // size of the source
+}
+
+// Complete reports whether the destination already has the whole source
+func (p PartialFile) Complete() bool {
+	return p.Have == p.Want
+}
+
+// DetectPartial checks whether dest holds the beginning of source, comparing
+// the overlapping tail of both files. upload selects the direction: a local
+// source and remote destination, or the other way around. It returns nil when
+// the destination is missing, larger than the source or has different content.
+func (c *Client) DetectPartial(source, dest string, upload bool) (*PartialFile, error) {
+	if c.sftpClient == nil {
+		return nil, fmt.Errorf("not connected")
+	}
+
+	var offset, size int64
+	if upload {
+		localFile, err := os.Open(source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open local file: %w", err)
+		}
+		defer localFile.Close()
+
+		info, err := localFile.Stat()
+		if err != nil || !info.Mode().IsRegular() {
+			return nil, nil
+		}
+		size = info.Size()
+		offset = c.remotePartialOffset(localFile, size, dest)
+	} else {
+		remoteFile, err := c.sftpClient.Open(source)
+		if err != nil {
+			return nil, wrapSFTPError(err, "failed to open remote file")
+		}
+		defer remoteFile.Close()
+
+		info, err := remoteFile.Stat()
+		if err != nil || !info.Mode().IsRegular() {
+			return nil, nil
+		}
+		size = info.Size()
+		offset = localPartialOffset(remoteFile, size, dest)
+	}
+
+	if offset == 0 {
+		return nil, nil
+	}
+	return &PartialFile{Have: offset, Want: size}, nil
+}
+
+// localPartialOffset returns how much of a local partial download can be kept
+func localPartialOffset(source io.ReaderAt, sourceSize int64, localPath string) int64 {
+	dest, err := os.Open(localPath)
+	if err != nil {
+		return 0
+	}
+	defer dest.Close()
+
+	info, err := dest.Stat()
+	if err != nil || !info.Mode().IsRegular() {
+		return 0
+	}
+	return partialOffset(source, dest, sourceSize, info.Size())
+}
+
+// remotePartialOffset returns how much of a remote partial upload can be kept
+func (c *Client) remotePartialOffset(source io.ReaderAt, sourceSize int64, remotePath string) int64 {
+	dest, err := c.sftpClient.Open(remotePath)
+	if err != nil {
+		return 0
+	}
+	defer dest.Close()
+
+	info, err := dest.Stat()
+	if err != nil || !info.Mode().IsRegular() {
+		return 0
+	}
+	return partialOffset(source, dest, sourceSize, info.Size())
+}
+
+// partialOffset returns destSize if dest holds the first destSize bytes of
+// source, judged by hashing the last resumeCheckSize bytes of both, or 0.
+func partialOffset(source, dest io.ReaderAt, sourceSize, destSize int64) int64 {
+	if destSize <= 0 || destSize > sourceSize {
+		return 0
+	}
+
+	n := min(resumeCheckSize, destSize)
+	want, err := hashRange(source, destSize-n, n)
+	if err != nil {
+		return 0
+	}
+	have, err := hashRange(dest, destSize-n, n)
+	if err != nil || !bytes.Equal(want, have) {
+		return 0
+	}
+	return destSize
+}
+
+// hashRange returns the SHA-256 of n bytes of r starting at off
+func hashRange(r io.ReaderAt, off, n int64) ([]byte, error) {
+	h := sha256.New()
+	copied, err := io.Copy(h, io.NewSectionReader(r, off, n))
+	if err != nil {
+		return nil, err
+	}
+	if copied != n {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return h.Sum(nil), nil
+}
+
+// seekBoth positions the destination and source of a resumed copy at offset
+func seekBoth(dest, source io.Seeker, offset int64) error {
+	if _, err := dest.Seek(offset, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek destination: %w", err)
+	}
+	if _, err := source.Seek(offset, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek source: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,505 @@
+package sftp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SyncDirection selects which side of a sync is the source
+type SyncDirection int
+
+const (
+	SyncUpload   SyncDirection = iota // mirror the local directory to the server
+	SyncDownload                      // mirror the remote directory locally
+)
+
+// String returns a short label for the direction
+func (d SyncDirection) String() string {
+	if d == SyncDownload {
+		return "download"
+	}
+	return "upload"
+}
+
+// SyncOptions controls how a sync plan is made. Globs without a slash match
+// the base name of an entry, globs with one match its path relative to the
+// synced directory. Excluded directories are not descended into.
+type SyncOptions struct {
+	Direction SyncDirection
+	Checksum  bool     // compare same-size files by SHA-256 instead of mtime
+	Delete    bool     // remove destination entries that are missing from the source
+	Include   []string // only sync files matching one of these globs, all if empty
+	Exclude   []string // never sync or delete entries matching these globs
+}
+
+// SyncActionKind is what a sync does to one entry
+type SyncActionKind int
+
+const (
+	SyncMkdir SyncActionKind = iota
+	SyncCopy
+	SyncUpdate
+	SyncDelete
+)
+
+// String returns a short label for the action
+func (k SyncActionKind) String() string {
+	switch k {
+	case SyncMkdir:
+		return "mkdir"
+	case SyncCopy:
+		return "copy"
+	case SyncUpdate:
+		return "update"
+	default:
+		return "delete"
+	}
+}
+
+// SyncAction is one step of a sync plan
+type SyncAction struct {
+	Kind    SyncActionKind
+	Path    string // relative to the synced directories, slash separated
+	IsDir   bool
+	Size    int64
+	Reason  string
+	Replace bool // the destination has a different type and is removed first
+}
+
+// SyncPlan lists the changes that make the destination mirror the source
+type SyncPlan struct {
+	LocalRoot  string
+	RemoteRoot string
+	Options    SyncOptions
+	Actions    []SyncAction
+	Conflicts  []SyncConflict // source entries left out to keep destination entries safe
+	Unchanged  int            // files that are already up to date
+}
+
+// SyncConflict is a source entry a plan leaves out, and why
+type SyncConflict struct {
+	Path   string
+	Reason string
+}
+
+// Count returns the number of actions of the given kind
+func (p *SyncPlan) Count(kind SyncActionKind) int {
+	n := 0
+	for _, a := range p.Actions {
+		if a.Kind == kind {
+			n++
+		}
+	}
+	return n
+}
+
+// Bytes returns the amount of data the plan transfers
+func (p *SyncPlan) Bytes() int64 {
+	var total int64
+	for _, a := range p.Actions {
+		if (a.Kind == SyncCopy || a.Kind == SyncUpdate) && !a.IsDir {
+			total += a.Size
+		}
+	}
+	return total
+}
+
+// syncEntry is a file or directory found while scanning one side of a sync
+type syncEntry struct {
+	size      int64
+	modTime   time.Time
+	isDir     bool
+	protected bool   // a directory holding excluded entries, which removing it would take along
+	special   string // what the entry is when not a file or directory, like a symlink; only kept for destinations
+}
+
+// PlanSync compares localRoot and remoteRoot and returns the actions that
+// make the destination a mirror of the source. Nothing is changed. The
+// destination directory does not need to exist yet. Symbolic links are
+// left out on both sides, except that a destination link in the way of a
+// source entry is replaced rather than written through. A destination
+// directory holding excluded entries is never replaced.
+func (c *Client) PlanSync(localRoot, remoteRoot string, opts SyncOptions) (*SyncPlan, error) {
+	if c.sftpClient == nil {
+		return nil, fmt.Errorf("not connected")
+	}
+
+	local, err := scanLocalTree(localRoot, opts, opts.Direction == SyncUpload)
+	if err != nil {
+		return nil, err
+	}
+	remote, err := c.scanRemoteTree(remoteRoot, opts, opts.Direction == SyncDownload)
+	if err != nil {
+		return nil, err
+	}
+
+	src, dst := local, remote
+	if opts.Direction == SyncDownload {
+		src, dst = remote, local
+	}
+
+	plan := &SyncPlan{LocalRoot: localRoot, RemoteRoot: remoteRoot, Options: opts}
+	removed := make(map[string]bool) // destination directories the plan removes as a whole
+
+	for _, rel := range sortedKeys(src) {
+		s := src[rel]
+		d, exists := dst[rel]
+
+		switch {
+		case !exists && s.isDir:
+			plan.Actions = append(plan.Actions, SyncAction{Kind: SyncMkdir, Path: rel, IsDir: true, Reason: "new directory"})
+		case !exists:
+			plan.Actions = append(plan.Actions, SyncAction{Kind: SyncCopy, Path: rel, Size: s.size, Reason: "new file"})
+		case d.special != "":
+			plan.Actions = append(plan.Actions, SyncAction{Kind: SyncUpdate, Path: rel, IsDir: s.isDir, Size: s.size, Reason: "replaces a " + d.special, Replace: true})
+		case s.isDir != d.isDir && d.protected:
+			plan.Conflicts = append(plan.Conflicts, SyncConflict{Path: rel, Reason: "a directory holding excluded entries is in the way"})
+		case s.isDir != d.isDir:
+			plan.Actions = append(plan.Actions, SyncAction{Kind: SyncUpdate, Path: rel, IsDir: s.isDir, Size: s.size, Reason: "type changed", Replace: true})
+			if d.isDir {
+				removed[rel] = true
+			}
+		case s.isDir:
+			// Directory on both sides, its contents are compared one by one
+		default:
+			if reason := c.syncDifference(plan, rel, s, d); reason != "" {
+				plan.Actions = append(plan.Actions, SyncAction{Kind: SyncUpdate, Path: rel, Size: s.size, Reason: reason})
+			} else {
+				plan.Unchanged++
+			}
+		}
+	}
+
+	if opts.Delete {
+		for _, rel := range sortedKeys(dst) {
+			// Everything below a removed directory goes with it
+			if _, ok := src[rel]; ok || underRemoved(removed, rel) {
+				continue
+			}
+			entry := dst[rel]
+			if entry.protected {
+				continue // its other entries are deleted one by one
+			}
+			if entry.special != "" {
+				continue // links are left out
+			}
+			plan.Actions = append(plan.Actions, SyncAction{Kind: SyncDelete, Path: rel, IsDir: entry.isDir, Size: entry.size, Reason: "not in source"})
+			if entry.isDir {
+				removed[rel] = true
+			}
+		}
+	}
+
+	return plan, nil
+}
+
+// syncDifference explains why a destination file differs from its source, or returns ""
+func (c *Client) syncDifference(plan *SyncPlan, rel string, src, dst syncEntry) string {
+	if src.size != dst.size {
+		return "size differs"
+	}
+
+	if plan.Options.Checksum {
+		localPath := filepath.Join(plan.LocalRoot, filepath.FromSlash(rel))
+		remotePath := path.Join(plan.RemoteRoot, rel)
+		hashed, err := c.VerifyFile(localPath, remotePath, true)
+		switch {
+		case errors.Is(err, ErrVerifyFailed):
+			return "content differs"
+		case hashed && err == nil:
+			return ""
+		}
+		// No checksum available on the server, fall back to the mtime
+	}
+
+	// SFTP keeps whole seconds
+	if !src.modTime.Truncate(time.Second).Equal(dst.modTime.Truncate(time.Second)) {
+		return "modified time differs"
+	}
+	return ""
+}
+
+// underRemoved reports whether rel lies below a directory the plan removes
+func underRemoved(removed map[string]bool, rel string) bool {
+	for dir := path.Dir(rel); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		if removed[dir] {
+			return true
+		}
+	}
+	return false
+}
+
+// scanLocalTree lists the files and directories below root by relative path
+func scanLocalTree(root string, opts SyncOptions, isSource bool) (map[string]syncEntry, error) {
+	entries := make(map[string]syncEntry)
+
+	if _, err := os.Stat(root); err != nil {
+		if os.IsNotExist(err) && !isSource {
+			return entries, nil
+		}
+		return nil, fmt.Errorf("failed to read local directory: %w", err)
+	}
+
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if p == root {
+				return err
+			}
+			return nil
+		}
+		if p == root {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+
+		if !syncIncluded(opts, rel, d.IsDir()) {
+			protectParents(entries, rel)
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.IsDir() && !d.Type().IsRegular() {
+			if !isSource {
+				entries[rel] = syncEntry{special: specialKind(d.Type())}
+			}
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		entries[rel] = syncEntry{size: info.Size(), modTime: info.ModTime(), isDir: d.IsDir()}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read local directory: %w", err)
+	}
+	return entries, nil
+}
+
+// scanRemoteTree lists the files and directories below a remote root by relative path
+func (c *Client) scanRemoteTree(root string, opts SyncOptions, isSource bool) (map[string]syncEntry, error) {
+	entries := make(map[string]syncEntry)
+	root = path.Clean(root)
+
+	if _, err := c.sftpClient.Stat(root); err != nil {
+		if errors.Is(err, fs.ErrNotExist) && !isSource {
+			return entries, nil
+		}
+		return nil, wrapSFTPError(err, "failed to read remote directory")
+	}
+
+	walker := c.sftpClient.Walk(root)
+	for walker.Step() {
+		if err := walker.Err(); err != nil {
+			if walker.Path() == root {
+				return nil, wrapSFTPError(err, "failed to read remote directory")
+			}
+			continue
+		}
+		if walker.Path() == root {
+			continue
+		}
+
+		rel := strings.TrimPrefix(strings.TrimPrefix(walker.Path(), root), "/")
+		info := walker.Stat()
+
+		// A name that leaves the directory would be written outside it locally
+		if !safeEntryName(info.Name()) {
+			if info.IsDir() {
+				walker.SkipDir()
+			}
+			continue
+		}
+
+		if !syncIncluded(opts, rel, info.IsDir()) {
+			protectParents(entries, rel)
+			if info.IsDir() {
+				walker.SkipDir()
+			}
+			continue
+		}
+		if !info.IsDir() && !info.Mode().IsRegular() {
+			if !isSource {
+				entries[rel] = syncEntry{special: specialKind(info.Mode())}
+			}
+			continue
+		}
+		entries[rel] = syncEntry{size: info.Size(), modTime: info.ModTime(), isDir: info.IsDir()}
+	}
+	return entries, nil
+}
+
+// specialKind names a destination entry that is neither a file nor a directory
+func specialKind(mode fs.FileMode) string {
+	if mode&fs.ModeSymlink != 0 {
+		return "symlink"
+	}
+	return "special file"
+}
+
+// protectParents marks the directories above an entry left out of a scan, so
+// a sync with delete does not remove them along with it
+func protectParents(entries map[string]syncEntry, rel string) {
+	for dir := path.Dir(rel); dir != "."; dir = path.Dir(dir) {
+		if e, ok := entries[dir]; ok {
+			e.protected = true
+			entries[dir] = e
+		}
+	}
+}
+
+// syncIncluded applies the include and exclude globs to an entry
+func syncIncluded(opts SyncOptions, rel string, isDir bool) bool {
+	if matchesAny(opts.Exclude, rel) {
+		return false
+	}
+	// Includes select files, directories are always searched
+	return isDir || len(opts.Include) == 0 || matchesAny(opts.Include, rel)
+}
+
+// matchesAny reports whether one of the globs matches rel or its base name
+func matchesAny(patterns []string, rel string) bool {
+	for _, p := range patterns {
+		target := rel
+		if !strings.Contains(p, "/") {
+			target = path.Base(rel)
+		}
+		if ok, _ := path.Match(strings.TrimPrefix(p, "/"), target); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedKeys returns the paths of a scan in order, parents before their children
+func sortedKeys(entries map[string]syncEntry) []string {
+	keys := make([]string, 0, len(entries))
+	for k := range entries {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ApplySync carries out a plan. Files are copied with the tree transfer
+// code, so opts apply to each of them. Entries that fail are collected in
+// the result and the rest of the plan still runs.
+func (c *Client) ApplySync(ctx context.Context, plan *SyncPlan, opts TransferOptions, progress func(TransferProgress)) (*TreeResult, error) {
+	if c.sftpClient == nil {
+		return nil, fmt.Errorf("not connected")
+	}
+
+	upload := plan.Options.Direction == SyncUpload
+	result := &TreeResult{}
+
+	// The destination root may not exist yet
+	if err := c.syncMkdir(upload, plan.LocalRoot, plan.RemoteRoot); err != nil {
+		return result, err
+	}
+
+	for _, a := range plan.Actions {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		localPath := filepath.Join(plan.LocalRoot, filepath.FromSlash(a.Path))
+		remotePath := path.Join(plan.RemoteRoot, a.Path)
+		source, dest := localPath, remotePath
+		if !upload {
+			source, dest = remotePath, localPath
+		}
+
+		if a.Kind == SyncDelete || a.Replace {
+			if err := c.syncRemove(upload, localPath, remotePath); err != nil {
+				result.Failed = append(result.Failed, FailedEntry{Path: dest, Err: err})
+				continue
+			}
+			if a.Kind == SyncDelete {
+				result.Deleted++
+				continue
+			}
+		}
+
+		if a.IsDir {
+			if err := c.syncMkdir(upload, localPath, remotePath); err != nil {
+				result.Failed = append(result.Failed, FailedEntry{Path: dest, Err: err})
+				continue
+			}
+			result.Dirs++
+			continue
+		}
+
+		// Offset progress by what the previous files transferred
+		done := *result
+		fileProgress := func(p TransferProgress) {
+			if progress != nil {
+				progress(TransferProgress{Files: done.Files + p.Files, Bytes: done.Bytes + p.Bytes, Current: p.Current})
+			}
+		}
+
+		var r *TreeResult
+		var err error
+		if upload {
+			r, err = c.UploadTree(ctx, source, dest, opts, fileProgress)
+		} else {
+			r, err = c.DownloadTree(ctx, source, dest, opts, fileProgress)
+		}
+		if r != nil {
+			result.merge(r)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return result, ctx.Err()
+			}
+			result.Failed = append(result.Failed, FailedEntry{Path: source, Err: err})
+		}
+	}
+
+	return result, nil
+}
+
+// syncMkdir creates a destination directory of a sync
+func (c *Client) syncMkdir(upload bool, localPath, remotePath string) error {
+	if upload {
+		return wrapSFTPError(c.sftpClient.MkdirAll(remotePath), "failed to create remote directory")
+	}
+	if err := os.MkdirAll(localPath, 0755); err != nil {
+		return fmt.Errorf("failed to create local directory: %w", err)
+	}
+	return nil
+}
+
+// syncRemove deletes a destination entry of a sync, directories recursively
+func (c *Client) syncRemove(upload bool, localPath, remotePath string) error {
+	if !upload {
+		if err := os.RemoveAll(localPath); err != nil {
+			return fmt.Errorf("failed to delete: %w", err)
+		}
+		return nil
+	}
+
+	failures, err := c.DeleteRecursive(remotePath, nil)
+	if err != nil {
+		return err
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("failed to delete %s: %w", failures[0].Path, failures[0].Err)
+	}
+	return nil
+}
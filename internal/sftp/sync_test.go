@@ -0,0 +1,265 @@
+package sftp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// syncTime is the modification time of every entry written by writeTree
+var syncTime = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+// writeTree creates the entries of tree below root. Names ending in a slash
+// are directories, the others files holding their value.
+func writeTree(t *testing.T, root string, tree map[string]string) {
+	t.Helper()
+	if err := os.MkdirAll(root, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range tree {
+		p := filepath.Join(root, filepath.FromSlash(strings.TrimSuffix(name, "/")))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if strings.HasSuffix(name, "/") {
+			if err := os.MkdirAll(p, 0755); err != nil {
+				t.Fatal(err)
+			}
+		} else if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name := range tree {
+		os.Chtimes(filepath.Join(root, filepath.FromSlash(strings.TrimSuffix(name, "/"))), syncTime, syncTime)
+	}
+}
+
+func TestPlanSync(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      SyncOptions
+		local     map[string]string
+		remote    map[string]string // nil leaves the remote directory missing
+		setup     func(local, remote string)
+		want      []string
+		conflicts []string
+		unchanged int
+	}{
+		{
+			name:  "new files and directories",
+			local: map[string]string{"a/": "", "a/x": "1", "b": "22"},
+			want:  []string{"mkdir a: new directory", "copy a/x: new file", "copy b: new file"},
+		},
+		{
+			name:      "unchanged file",
+			local:     map[string]string{"f": "same"},
+			remote:    map[string]string{"f": "same"},
+			unchanged: 1,
+		},
+		{
+			name:   "size differs",
+			local:  map[string]string{"f": "newer"},
+			remote: map[string]string{"f": "old"},
+			want:   []string{"update f: size differs"},
+		},
+		{
+			name:   "modified time differs",
+			local:  map[string]string{"f": "same"},
+			remote: map[string]string{"f": "same"},
+			setup: func(local, remote string) {
+				os.Chtimes(filepath.Join(remote, "f"), syncTime, syncTime.Add(time.Hour))
+			},
+			want: []string{"update f: modified time differs"},
+		},
+		{
+			name:   "sub-second time difference is ignored",
+			local:  map[string]string{"f": "same"},
+			remote: map[string]string{"f": "same"},
+			setup: func(local, remote string) {
+				os.Chtimes(filepath.Join(local, "f"), syncTime, syncTime.Add(500*time.Millisecond))
+			},
+			unchanged: 1,
+		},
+		{
+			name:   "file replaces a directory with its contents",
+			opts:   SyncOptions{Delete: true},
+			local:  map[string]string{"d": "file"},
+			remote: map[string]string{"d/": "", "d/x": "1"},
+			want:   []string{"update d: type changed (replace)"},
+		},
+		{
+			name:      "delete removes a directory as a whole",
+			opts:      SyncOptions{Delete: true},
+			local:     map[string]string{"keep": "1"},
+			remote:    map[string]string{"keep": "1", "old/": "", "old/x": "1", "old/y/": "", "stale": "22"},
+			want:      []string{"delete old: not in source", "delete stale: not in source"},
+			unchanged: 1,
+		},
+		{
+			name:      "nothing is deleted without delete",
+			local:     map[string]string{"keep": "1"},
+			remote:    map[string]string{"keep": "1", "stale": "22"},
+			unchanged: 1,
+		},
+		{
+			name:      "excluded destination entries are kept",
+			opts:      SyncOptions{Delete: true, Exclude: []string{"*.log"}},
+			local:     map[string]string{"a": "1"},
+			remote:    map[string]string{"a": "1", "app.log": "x", "logs/": "", "logs/b.log": "x", "logs/c": "x"},
+			want:      []string{"delete logs/c: not in source"},
+			unchanged: 1,
+		},
+		{
+			name:      "directories holding files left out by include are kept",
+			opts:      SyncOptions{Delete: true, Include: []string{"*.go"}},
+			local:     map[string]string{"main.go": "1"},
+			remote:    map[string]string{"main.go": "1", "docs/": "", "docs/a.txt": "x", "old/": "", "old/x.go": "1"},
+			want:      []string{"delete old: not in source"},
+			unchanged: 1,
+		},
+		{
+			name:  "excluded source directory is not descended into",
+			opts:  SyncOptions{Exclude: []string{"build"}},
+			local: map[string]string{"build/": "", "build/out": "1", "src": "1"},
+			want:  []string{"copy src: new file"},
+		},
+		{
+			name:  "exclude with a slash matches the relative path",
+			opts:  SyncOptions{Exclude: []string{"/a/tmp"}},
+			local: map[string]string{"a/": "", "a/tmp": "1", "b/": "", "b/tmp": "1"},
+			want:  []string{"mkdir a: new directory", "mkdir b: new directory", "copy b/tmp: new file"},
+		},
+		{
+			name:  "include selects files but searches every directory",
+			opts:  SyncOptions{Include: []string{"*.go"}},
+			local: map[string]string{"main.go": "1", "README": "1", "pkg/": "", "pkg/x.go": "1", "pkg/x.txt": "1"},
+			want:  []string{"copy main.go: new file", "mkdir pkg: new directory", "copy pkg/x.go: new file"},
+		},
+		{
+			name:   "destination link to a file is replaced, not written through",
+			local:  map[string]string{"f": "new"},
+			remote: map[string]string{"target": "old"},
+			setup: func(local, remote string) {
+				os.Symlink("target", filepath.Join(remote, "f"))
+			},
+			want: []string{"update f: replaces a symlink (replace)"},
+		},
+		{
+			name:   "destination link to a directory is replaced",
+			local:  map[string]string{"d/": "", "d/x": "1"},
+			remote: map[string]string{"elsewhere/": ""},
+			setup: func(local, remote string) {
+				os.Symlink("elsewhere", filepath.Join(remote, "d"))
+			},
+			want: []string{"update d: replaces a symlink (replace)", "copy d/x: new file"},
+		},
+		{
+			name:      "destination links missing from the source are left alone",
+			opts:      SyncOptions{Delete: true},
+			local:     map[string]string{"a": "1"},
+			remote:    map[string]string{"a": "1"},
+			setup:     func(local, remote string) { os.Symlink("a", filepath.Join(remote, "link")) },
+			unchanged: 1,
+		},
+		{
+			name:      "directory holding excluded entries is not replaced",
+			opts:      SyncOptions{Delete: true, Exclude: []string{"*.log"}},
+			local:     map[string]string{"d": "file", "b": "2"},
+			remote:    map[string]string{"d/": "", "d/app.log": "x", "d/c": "x"},
+			want:      []string{"copy b: new file", "delete d/c: not in source"},
+			conflicts: []string{"d: a directory holding excluded entries is in the way"},
+		},
+		{
+			name:   "download mirrors the remote directory",
+			opts:   SyncOptions{Direction: SyncDownload, Delete: true},
+			local:  map[string]string{"old": "1"},
+			remote: map[string]string{"new": "1"},
+			want:   []string{"copy new: new file", "delete old: not in source"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newPipeClient(t, 0)
+			dir := t.TempDir()
+			local := filepath.Join(dir, "local")
+			remote := filepath.Join(dir, "remote")
+			writeTree(t, local, tt.local)
+			if tt.remote != nil {
+				writeTree(t, remote, tt.remote)
+			}
+			if tt.setup != nil {
+				tt.setup(local, remote)
+			}
+
+			plan, err := c.PlanSync(local, filepath.ToSlash(remote), tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, a := range plan.Actions {
+				action := fmt.Sprintf("%s %s: %s", a.Kind, a.Path, a.Reason)
+				if a.Replace {
+					action += " (replace)"
+				}
+				got = append(got, action)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("actions\n got %q\nwant %q", got, tt.want)
+			}
+			var conflicts []string
+			for _, c := range plan.Conflicts {
+				conflicts = append(conflicts, c.Path+": "+c.Reason)
+			}
+			if !reflect.DeepEqual(conflicts, tt.conflicts) {
+				t.Errorf("conflicts\n got %q\nwant %q", conflicts, tt.conflicts)
+			}
+			if plan.Unchanged != tt.unchanged {
+				t.Errorf("unchanged = %d, want %d", plan.Unchanged, tt.unchanged)
+			}
+		})
+	}
+}
+
+func TestPlanSyncMissingSource(t *testing.T) {
+	c := newPipeClient(t, 0)
+	dir := t.TempDir()
+	if _, err := c.PlanSync(filepath.Join(dir, "missing"), filepath.ToSlash(dir), SyncOptions{}); err == nil {
+		t.Error("expected an error for a missing source directory")
+	}
+}
+
+func TestApplySyncReplacesLinks(t *testing.T) {
+	c := newPipeClient(t, 0)
+	dir := t.TempDir()
+	local := filepath.Join(dir, "local")
+	remote := filepath.Join(dir, "remote")
+	writeTree(t, local, map[string]string{"f": "new"})
+	writeTree(t, remote, map[string]string{"target": "old"})
+	os.Symlink("target", filepath.Join(remote, "f"))
+
+	plan, err := c.PlanSync(local, filepath.ToSlash(remote), SyncOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := c.ApplySync(context.Background(), plan, TransferOptions{}, nil)
+	if err != nil || len(result.Failed) > 0 {
+		t.Fatalf("apply: %v %v", err, result.Failed)
+	}
+
+	if data, _ := os.ReadFile(filepath.Join(remote, "target")); string(data) != "old" {
+		t.Errorf("link target was written through: %q", data)
+	}
+	info, err := os.Lstat(filepath.Join(remote, "f"))
+	if err != nil || !info.Mode().IsRegular() {
+		t.Fatalf("f is not a regular file: %v %v", info, err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(remote, "f")); string(data) != "new" {
+		t.Errorf("f = %q, want new", data)
+	}
+}
@@ -0,0 +1,102 @@
+package sftp
+
+import (
+	"crypto/rand"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// benchLatency is the delay of each direction of the benchmark link, for a
+// round trip close to a server in another region
+const benchLatency = 5 * time.Millisecond
+
+const benchFileSize = 8 << 20
+
+// writeBenchFile creates a file of random data in dir
+func writeBenchFile(b *testing.B, dir string) string {
+	data := make([]byte, benchFileSize)
+	rand.Read(data)
+	p := filepath.Join(dir, "source")
+	if err := os.WriteFile(p, data, 0644); err != nil {
+		b.Fatal(err)
+	}
+	return p
+}
+
+// BenchmarkDownload compares copying one chunk at a time, as a plain
+// io.Copy over the remote file does, with DownloadFile
+func BenchmarkDownload(b *testing.B) {
+	c := newPipeClient(b, benchLatency)
+	dir := b.TempDir()
+	source := writeBenchFile(b, dir)
+	dest := filepath.Join(dir, "dest")
+
+	b.Run("sequential", func(b *testing.B) {
+		b.SetBytes(benchFileSize)
+		for i := 0; i < b.N; i++ {
+			remote, err := c.sftpClient.Open(source)
+			if err != nil {
+				b.Fatal(err)
+			}
+			local, err := os.Create(dest)
+			if err != nil {
+				b.Fatal(err)
+			}
+			// Hiding WriteTo leaves a single read in flight
+			if _, err := io.Copy(local, struct{ io.Reader }{remote}); err != nil {
+				b.Fatal(err)
+			}
+			local.Close()
+			remote.Close()
+		}
+	})
+
+	b.Run("pipelined", func(b *testing.B) {
+		b.SetBytes(benchFileSize)
+		for i := 0; i < b.N; i++ {
+			if err := c.DownloadFile(source, dest); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// BenchmarkUpload compares writing one chunk at a time with UploadFile
+func BenchmarkUpload(b *testing.B) {
+	c := newPipeClient(b, benchLatency)
+	dir := b.TempDir()
+	source := writeBenchFile(b, dir)
+	dest := filepath.Join(dir, "dest")
+
+	b.Run("sequential", func(b *testing.B) {
+		b.SetBytes(benchFileSize)
+		for i := 0; i < b.N; i++ {
+			local, err := os.Open(source)
+			if err != nil {
+				b.Fatal(err)
+			}
+			remote, err := c.sftpClient.Create(dest)
+			if err != nil {
+				b.Fatal(err)
+			}
+			// Hiding ReadFrom leaves a single write in flight
+			if _, err := io.Copy(struct{ io.Writer }{remote}, local); err != nil {
+				b.Fatal(err)
+			}
+			remote.Close()
+			local.Close()
+		}
+	})
+
+	b.Run("pipelined", func(b *testing.B) {
+		b.SetBytes(benchFileSize)
+		for i := 0; i < b.N; i++ {
+			if err := c.UploadFile(source, dest); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
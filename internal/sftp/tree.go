@@ -0,0 +1,57 @@
+package sftp
+
+import (
+	"fmt"
+	"io/fs"
+)
+
+// TreeSummary describes the contents of a directory tree
+type TreeSummary struct {
+	Files     int
+	Dirs      int
+	Symlinks  int
+	TotalSize int64
+}
+
+// FailedEntry records a path that could not be processed during a recursive operation
+type FailedEntry struct {
+	Path string
+	Err  error
+}
+
+// SummarizeTree counts the entries below root (not including root itself)
+// without following symlinks
+func (c *Client) SummarizeTree(root string) (*TreeSummary, error) {
+	if c.sftpClient == nil {
+		return nil, fmt.Errorf("not connected")
+	}
+
+	summary := &TreeSummary{}
+	walker := c.sftpClient.Walk(root)
+	for walker.Step() {
+		if err := walker.Err(); err != nil {
+			if walker.Path() == root {
+				return nil, wrapSFTPError(err, "failed to read directory")
+			}
+			// Unreadable subdirectory, count what we can
+			continue
+		}
+
+		if walker.Path() == root {
+			continue
+		}
+
+		info := walker.Stat()
+		switch {
+		case info.Mode()&fs.ModeSymlink != 0:
+			summary.Symlinks++
+		case info.IsDir():
+			summary.Dirs++
+		default:
+			summary.Files++
+			summary.TotalSize += info.Size()
+		}
+	}
+
+	return summary, nil
+}
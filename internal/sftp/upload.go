@@ -0,0 +1,270 @@
+package sftp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// treeUpload holds the state of a single UploadTree call
+type treeUpload struct {
+	ctx      context.Context
+	client   *Client
+	opts     TransferOptions
+	progress func(TransferProgress)
+	result   *TreeResult
+	visited  map[string]bool // resolved local directories, to break link loops
+}
+
+// UploadTree uploads a local file or directory to remotePath. Directories are
+// uploaded recursively, merging into an existing remote directory, and
+// permissions and modification times are preserved. Entries that fail are
+// collected in the result and the rest of the tree is still uploaded.
+// Cancelling ctx stops the upload and returns the context error. With
+// opts.Resume, partial remote files are continued where they stopped.
+func (c *Client) UploadTree(ctx context.Context, localPath, remotePath string, opts TransferOptions, progress func(TransferProgress)) (*TreeResult, error) {
+	if c.sftpClient == nil {
+		return nil, fmt.Errorf("not connected")
+	}
+
+	info, err := os.Lstat(localPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat local file: %w", err)
+	}
+
+	u := &treeUpload{
+		ctx:      ctx,
+		client:   c,
+		opts:     opts,
+		progress: progress,
+		result:   &TreeResult{},
+		visited:  make(map[string]bool),
+	}
+
+	switch {
+	case info.Mode()&fs.ModeSymlink != 0:
+		u.uploadLink(localPath, remotePath)
+	case info.IsDir():
+		if err := u.uploadDir(localPath, remotePath, info); err != nil {
+			return u.result, err
+		}
+	default:
+		u.uploadFile(localPath, remotePath, info)
+	}
+
+	return u.result, ctx.Err()
+}
+
+// uploadDir creates remoteDir if needed and uploads the contents of localDir into it
+func (u *treeUpload) uploadDir(localDir, remoteDir string, info fs.FileInfo) error {
+	if err := u.client.sftpClient.MkdirAll(remoteDir); err != nil {
+		return wrapSFTPError(err, "failed to create remote directory")
+	}
+	if real, err := filepath.EvalSymlinks(localDir); err == nil {
+		u.visited[real] = true
+	}
+	u.result.Dirs++
+
+	entries, err := os.ReadDir(localDir)
+	if err != nil {
+		u.fail(localDir, fmt.Errorf("failed to read directory: %w", err))
+	}
+
+	for _, entry := range entries {
+		if err := u.ctx.Err(); err != nil {
+			return err
+		}
+		localPath := filepath.Join(localDir, entry.Name())
+		remotePath := path.Join(remoteDir, entry.Name())
+
+		entryInfo, err := entry.Info()
+		if err != nil {
+			u.fail(localPath, err)
+			continue
+		}
+
+		switch {
+		case entryInfo.Mode()&fs.ModeSymlink != 0:
+			u.uploadLink(localPath, remotePath)
+		case entryInfo.IsDir():
+			if err := u.uploadDir(localPath, remotePath, entryInfo); err != nil {
+				u.fail(localPath, err)
+			}
+		case entryInfo.Mode().IsRegular():
+			u.uploadFile(localPath, remotePath, entryInfo)
+		default:
+			u.skip(localPath, fmt.Errorf("not a regular file"))
+		}
+	}
+
+	// Apply directory metadata last, writing the contents changes the mtime
+	u.client.sftpClient.Chmod(remoteDir, info.Mode().Perm()|0700)
+	u.client.sftpClient.Chtimes(remoteDir, info.ModTime(), info.ModTime())
+
+	return nil
+}
+
+// uploadLink handles a local symlink according to the selected mode
+func (u *treeUpload) uploadLink(localPath, remotePath string) {
+	switch u.opts.Symlinks {
+	case SymlinkPreserve:
+		target, err := os.Readlink(localPath)
+		if err != nil {
+			u.fail(localPath, fmt.Errorf("failed to read link: %w", err))
+			return
+		}
+		if err := u.client.sftpClient.Symlink(target, remotePath); err != nil {
+			u.fail(localPath, wrapSFTPError(err, "failed to create link"))
+			return
+		}
+		u.result.Links++
+
+	case SymlinkFollow:
+		info, err := os.Stat(localPath)
+		if err != nil {
+			u.skip(localPath, fmt.Errorf("broken link"))
+			return
+		}
+		if info.IsDir() {
+			real, err := filepath.EvalSymlinks(localPath)
+			if err != nil || u.visited[real] {
+				u.skip(localPath, fmt.Errorf("link loop or directory already uploaded"))
+				return
+			}
+			if err := u.uploadDir(localPath, remotePath, info); err != nil {
+				u.fail(localPath, err)
+			}
+			return
+		}
+		if !info.Mode().IsRegular() {
+			u.skip(localPath, fmt.Errorf("not a regular file"))
+			return
+		}
+		u.uploadFile(localPath, remotePath, info)
+
+	default:
+		u.skip(localPath, fmt.Errorf("symbolic link"))
+	}
+}
+
+// uploadFile copies a single local file, checks the copy and applies its mode and mtime
+func (u *treeUpload) uploadFile(localPath, remotePath string, info fs.FileInfo) {
+	if u.progress != nil {
+		u.progress(TransferProgress{Files: u.result.Files, Bytes: u.result.Bytes, Current: localPath})
+	}
+
+	bytesBefore := u.result.Bytes
+	for attempt := 0; ; attempt++ {
+		// A retry starts over, the partial data is what failed the check
+		err := u.copyFile(localPath, remotePath, info, u.opts.Resume && attempt == 0)
+		if err == nil {
+			err = u.verify(localPath, remotePath)
+		}
+		if err == nil {
+			break
+		}
+		if errors.Is(err, ErrVerifyFailed) && attempt < u.opts.VerifyRetries && u.ctx.Err() == nil {
+			u.result.Bytes = bytesBefore
+			continue
+		}
+		u.fail(localPath, err)
+		return
+	}
+
+	u.client.sftpClient.Chmod(remotePath, info.Mode().Perm())
+	u.client.sftpClient.Chtimes(remotePath, info.ModTime(), info.ModTime())
+	u.result.Files++
+
+	if u.progress != nil {
+		u.progress(TransferProgress{Files: u.result.Files, Bytes: u.result.Bytes, Current: localPath})
+	}
+}
+
+// copyFile writes the contents of a local file to remotePath
+func (u *treeUpload) copyFile(localPath, remotePath string, info fs.FileInfo, resume bool) error {
+	localFile, err := os.Open(localPath)
+	if err != nil {
+		return fmt.Errorf("failed to open local file: %w", err)
+	}
+	defer localFile.Close()
+
+	var offset int64
+	if resume {
+		offset = u.client.remotePartialOffset(localFile, info.Size(), remotePath)
+	}
+
+	flags := os.O_RDWR | os.O_CREATE | os.O_TRUNC
+	if offset > 0 {
+		flags = os.O_RDWR
+	}
+	remoteFile, err := u.client.sftpClient.OpenFile(remotePath, flags)
+	if err != nil {
+		return wrapSFTPError(err, "failed to create remote file")
+	}
+
+	if offset > 0 {
+		if err := seekBoth(remoteFile, localFile, offset); err != nil {
+			remoteFile.Close()
+			return err
+		}
+		u.result.Bytes += offset
+		u.result.Resumed++
+	}
+
+	// Report progress as data is sent so large files don't look stalled
+	reader := &progressReader{r: contextReader{ctx: u.ctx, r: localFile}, onRead: func(n int64) {
+		u.result.Bytes += n
+		if u.progress != nil {
+			u.progress(TransferProgress{Files: u.result.Files, Bytes: u.result.Bytes, Current: localPath})
+		}
+	}}
+
+	// Keep several writes in flight instead of waiting for each one
+	_, err = remoteFile.ReadFromWithConcurrency(reader, 0)
+	if err != nil {
+		truncateAtOffset(remoteFile)
+		remoteFile.Close()
+		return wrapSFTPError(err, "failed to write to remote file")
+	}
+	if err := remoteFile.Close(); err != nil {
+		return wrapSFTPError(err, "failed to close remote file")
+	}
+	return nil
+}
+
+// verify compares an uploaded file with its source
+func (u *treeUpload) verify(localPath, remotePath string) error {
+	hashed, err := u.client.VerifyFile(localPath, remotePath, u.opts.Verify)
+	if hashed && err == nil {
+		u.result.Verified++
+	}
+	return err
+}
+
+// progressReader calls onRead with the size of every read that passes through it
+type progressReader struct {
+	r      io.Reader
+	onRead func(n int64)
+}
+
+func (pr *progressReader) Read(p []byte) (int, error) {
+	n, err := pr.r.Read(p)
+	pr.onRead(int64(n))
+	return n, err
+}
+
+func (u *treeUpload) fail(p string, err error) {
+	if u.ctx.Err() != nil {
+		return
+	}
+	u.result.Failed = append(u.result.Failed, FailedEntry{Path: p, Err: err})
+}
+
+func (u *treeUpload) skip(p string, reason error) {
+	u.result.Skipped = append(u.result.Skipped, FailedEntry{Path: p, Err: reason})
+}
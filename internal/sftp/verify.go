@@ -0,0 +1,109 @@
+package sftp
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ErrVerifyFailed is wrapped by errors for a copy that differs from its source
+var ErrVerifyFailed = errors.New("verification failed")
+
+// VerifyFile checks that a local and a remote file match. Sizes are always
+// compared; with hash set, the contents are compared by SHA-256 when the
+// server can compute it. It reports whether the contents were compared.
+func (c *Client) VerifyFile(localPath, remotePath string, hash bool) (bool, error) {
+	if c.sftpClient == nil {
+		return false, fmt.Errorf("not connected")
+	}
+
+	localInfo, err := os.Stat(localPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to stat local file: %w", err)
+	}
+	remoteInfo, err := c.sftpClient.Stat(remotePath)
+	if err != nil {
+		return false, wrapSFTPError(err, "failed to stat remote file")
+	}
+	if localInfo.Size() != remoteInfo.Size() {
+		return false, fmt.Errorf("%w: %d bytes locally, %d on the server", ErrVerifyFailed, localInfo.Size(), remoteInfo.Size())
+	}
+
+	if !hash {
+		return false, nil
+	}
+	remoteSum, ok, err := c.remoteSHA256(remotePath)
+	if err != nil {
+		return false, err
+	}
+	if !ok {
+		// No way to hash on the server, the size check is all we have
+		return false, nil
+	}
+
+	localSum, err := localSHA256(localPath)
+	if err != nil {
+		return false, err
+	}
+	if localSum != remoteSum {
+		return true, fmt.Errorf("%w: sha256 differs", ErrVerifyFailed)
+	}
+	return true, nil
+}
+
+// remoteSHA256 hashes a remote file by running sha256sum over SSH. ok is
+// false when the server has no hashing command or does not allow exec.
+func (c *Client) remoteSHA256(remotePath string) (sum string, ok bool, err error) {
+	c.hashOnce.Do(c.detectHashCommand)
+	if c.hashCommand == "" {
+		return "", false, nil
+	}
+
+	out, err := c.RunCommand(c.hashCommand + " " + shellQuote(remotePath))
+	if err != nil {
+		return "", false, fmt.Errorf("failed to hash remote file: %w", err)
+	}
+	fields := strings.Fields(string(out))
+	if len(fields) == 0 {
+		return "", false, fmt.Errorf("failed to hash remote file: empty output")
+	}
+	return strings.ToLower(fields[0]), true, nil
+}
+
+// detectHashCommand looks for a SHA-256 tool on the server, once per connection
+func (c *Client) detectHashCommand() {
+	out, err := c.RunCommand("command -v sha256sum || command -v shasum")
+	if err != nil && len(out) == 0 {
+		return
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		switch {
+		case strings.HasSuffix(line, "sha256sum"):
+			c.hashCommand = "sha256sum"
+			return
+		case strings.HasSuffix(line, "shasum"):
+			c.hashCommand = "shasum -a 256"
+			return
+		}
+	}
+}
+
+// localSHA256 returns the hex SHA-256 of a local file
+func localSHA256(localPath string) (string, error) {
+	f, err := os.Open(localPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open local file: %w", err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("failed to hash local file: %w", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,233 @@
+package sftp
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultWatchDebounce is how long a file has to stay unchanged before it is pushed
+const DefaultWatchDebounce = 300 * time.Millisecond
+
+// WatchOptions configures WatchPush
+type WatchOptions struct {
+	Debounce time.Duration // quiet period before a changed file is uploaded
+	Ignore   []string      // extra .gitignore-style patterns
+}
+
+// PushEvent reports a file uploaded by WatchPush, or a problem while watching
+type PushEvent struct {
+	Time  time.Time
+	Path  string // relative to the watched directory, slash-separated
+	Bytes int64
+	Err   error
+}
+
+// pushWatcher holds the state of a single WatchPush call
+type pushWatcher struct {
+	ctx        context.Context
+	client     *Client
+	localRoot  string
+	remoteRoot string
+	opts       WatchOptions
+	push       func(PushEvent)
+	watcher    *fsnotify.Watcher
+	ignore     *ignoreMatcher
+	pending    map[string]time.Time // changed files by time of their last change
+}
+
+// WatchPush watches localRoot and uploads each file that changes below it to
+// the same place under remoteRoot, once it has been left alone for
+// opts.Debounce. Paths ignored by .gitignore files in the tree or by
+// opts.Ignore are not uploaded. Every upload is reported to push. It runs
+// until ctx is cancelled and then returns nil.
+func (c *Client) WatchPush(ctx context.Context, localRoot, remoteRoot string, opts WatchOptions, push func(PushEvent)) error {
+	if c.sftpClient == nil {
+		return fmt.Errorf("not connected")
+	}
+	if opts.Debounce <= 0 {
+		opts.Debounce = DefaultWatchDebounce
+	}
+
+	root, err := filepath.Abs(localRoot)
+	if err != nil {
+		return fmt.Errorf("failed to resolve local directory: %w", err)
+	}
+	info, err := os.Stat(root)
+	if err != nil {
+		return fmt.Errorf("failed to stat local directory: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", localRoot)
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	w := &pushWatcher{
+		ctx:        ctx,
+		client:     c,
+		localRoot:  root,
+		remoteRoot: remoteRoot,
+		opts:       opts,
+		push:       push,
+		watcher:    watcher,
+		ignore:     loadIgnore(root, opts.Ignore),
+		pending:    make(map[string]time.Time),
+	}
+	if err := w.addTree(root, false); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(max(opts.Debounce/2, 50*time.Millisecond))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			w.handle(event)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			w.push(PushEvent{Time: time.Now(), Err: fmt.Errorf("watch error: %w", err)})
+		case now := <-ticker.C:
+			w.flush(now)
+		}
+	}
+}
+
+// addTree watches dir and the directories below it that are not ignored.
+// With queue set, the files found are scheduled for upload too, for
+// directories that appeared after watching started.
+func (w *pushWatcher) addTree(dir string, queue bool) error {
+	return filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		rel := relSlash(w.localRoot, p)
+		if rel != "" && w.ignore.Ignored(rel, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		switch {
+		case d.IsDir():
+			if err := w.watcher.Add(p); err != nil {
+				return fmt.Errorf("failed to watch %s: %w", p, err)
+			}
+		case queue && d.Type().IsRegular():
+			w.pending[rel] = time.Now()
+		}
+		return nil
+	})
+}
+
+// handle records a file system event
+func (w *pushWatcher) handle(event fsnotify.Event) {
+	rel := relSlash(w.localRoot, event.Name)
+	if rel == "" {
+		return
+	}
+
+	if event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename) {
+		// Removed or renamed away, an editor's atomic save shows up as a create
+		delete(w.pending, rel)
+		return
+	}
+	if !event.Has(fsnotify.Create) && !event.Has(fsnotify.Write) {
+		// A chmod alone leaves the contents, and any pending upload, as they are
+		return
+	}
+
+	if path.Base(rel) == ".gitignore" {
+		w.ignore = loadIgnore(w.localRoot, w.opts.Ignore)
+	}
+
+	info, err := os.Lstat(event.Name)
+	if err != nil {
+		return
+	}
+	if w.ignore.Ignored(rel, info.IsDir()) {
+		return
+	}
+
+	switch {
+	case info.IsDir():
+		if event.Has(fsnotify.Create) {
+			if err := w.addTree(event.Name, true); err != nil {
+				w.push(PushEvent{Time: time.Now(), Path: rel, Err: err})
+			}
+		}
+	case info.Mode().IsRegular():
+		w.pending[rel] = time.Now()
+	}
+}
+
+// flush uploads the files that have not changed for the debounce period
+func (w *pushWatcher) flush(now time.Time) {
+	var ready []string
+	for rel, changed := range w.pending {
+		if now.Sub(changed) >= w.opts.Debounce {
+			ready = append(ready, rel)
+		}
+	}
+	sort.Strings(ready)
+
+	for _, rel := range ready {
+		if w.ctx.Err() != nil {
+			return
+		}
+		delete(w.pending, rel)
+		w.upload(rel)
+	}
+}
+
+// upload pushes one file, creating its remote directory when needed
+func (w *pushWatcher) upload(rel string) {
+	localPath := filepath.Join(w.localRoot, filepath.FromSlash(rel))
+	info, err := os.Lstat(localPath)
+	if err != nil || !info.Mode().IsRegular() {
+		// Gone again, like an editor's temporary file
+		return
+	}
+
+	remotePath := path.Join(w.remoteRoot, rel)
+	event := PushEvent{Time: time.Now(), Path: rel}
+	if err := w.client.sftpClient.MkdirAll(path.Dir(remotePath)); err != nil {
+		event.Err = wrapSFTPError(err, "failed to create remote directory")
+		w.push(event)
+		return
+	}
+
+	result, err := w.client.UploadTree(w.ctx, localPath, remotePath, TransferOptions{}, nil)
+	switch {
+	case err != nil:
+		if w.ctx.Err() != nil {
+			return
+		}
+		event.Err = err
+	case len(result.Failed) > 0:
+		event.Err = result.Failed[0].Err
+	default:
+		event.Bytes = result.Bytes
+	}
+	w.push(event)
+}
@@ -0,0 +1,51 @@
+package sftp
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+func TestPushWatcherHandle(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"a.txt", "b.log"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		events  []fsnotify.Op
+		file    string
+		pending bool
+	}{
+		{"write", []fsnotify.Op{fsnotify.Write}, "a.txt", true},
+		{"create", []fsnotify.Op{fsnotify.Create}, "a.txt", true},
+		{"chmod after write keeps the upload", []fsnotify.Op{fsnotify.Write, fsnotify.Chmod}, "a.txt", true},
+		{"chmod alone", []fsnotify.Op{fsnotify.Chmod}, "a.txt", false},
+		{"remove drops the upload", []fsnotify.Op{fsnotify.Write, fsnotify.Remove}, "a.txt", false},
+		{"rename drops the upload", []fsnotify.Op{fsnotify.Write, fsnotify.Rename}, "a.txt", false},
+		{"ignored file", []fsnotify.Op{fsnotify.Write}, "b.log", false},
+		{"missing file", []fsnotify.Op{fsnotify.Create}, "gone.txt", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &pushWatcher{
+				localRoot: root,
+				ignore:    loadIgnore(root, []string{"*.log"}),
+				pending:   make(map[string]time.Time),
+			}
+			for _, op := range tt.events {
+				w.handle(fsnotify.Event{Name: filepath.Join(root, tt.file), Op: op})
+			}
+			if _, ok := w.pending[tt.file]; ok != tt.pending {
+				t.Errorf("pending = %v, want %v", ok, tt.pending)
+			}
+		})
+	}
+}
@@ -1,11 +1,14 @@
 package ssh
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	"github.com/muesli/cancelreader"
 	"golang.org/x/crypto/ssh"
 	"golang.org/x/term"
 )
@@ -16,6 +19,8 @@ type Client struct {
 	client *ssh.Client
 	host   string
 	port   int
+
+	forwards forwards
 }
 
 // NewClient() creates a new SSH client
@@ -47,16 +52,17 @@ func (c *Client) Connect() error {
 	return nil
 }
 
-// StartInteractiveSession starts an interactive shell session
-func (c *Client) StartInteractiveSession() error {
+// StartInteractiveSession starts an interactive shell session and reports how it ended.
+// A non-zero exit status is not an error; it is recorded in the returned result.
+func (c *Client) StartInteractiveSession() (*SessionResult, error) {
 	if c.client == nil {
-		return fmt.Errorf("not connected")
+		return nil, fmt.Errorf("not connected")
 	}
 
 	// Create a session
 	session, err := c.client.NewSession()
 	if err != nil {
-		return fmt.Errorf("failed to create session: %w", err)
+		return nil, fmt.Errorf("failed to create session: %w", err)
 	}
 	defer session.Close()
 
@@ -76,39 +82,79 @@ func (c *Client) StartInteractiveSession() error {
 
 	// Request pseudo terminal
 	if err := session.RequestPty("xterm-256color", height, width, modes); err != nil {
-		return fmt.Errorf("request for pseudo terminal failed: %w", err)
+		return nil, fmt.Errorf("request for pseudo terminal failed: %w", err)
 	}
 
 	// Set up terminal for raw mode
 	oldState, err := term.MakeRaw(int(os.Stdin.Fd()))
 	if err != nil {
-		return fmt.Errorf("failed to set terminal to raw mode: %w", err)
+		return nil, fmt.Errorf("failed to set terminal to raw mode: %w", err)
 	}
 	defer term.Restore(int(os.Stdin.Fd()), oldState)
 
-	// Connect input/output
-	session.Stdin = os.Stdin
-	session.Stdout = os.Stdout
-	session.Stderr = os.Stderr
+	// Connect input/output. Stdin goes through the escape filter so that
+	// ~. and friends are handled locally instead of being sent to the server.
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open session stdin: %w", err)
+	}
+	stdout := &countingWriter{w: os.Stdout}
+	stderr := &countingWriter{w: os.Stderr}
+	session.Stdout = stdout
+	session.Stderr = stderr
+
+	input, err := cancelreader.NewReader(os.Stdin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read terminal input: %w", err)
+	}
+	defer func() {
+		// Stop reading stdin so the next program gets the keyboard back
+		input.Cancel()
+		input.Close()
+	}()
+
+	sent := &countingWriter{w: stdin}
+	escapes := newEscapeFilter(c, sent, os.Stdout, func() {
+		session.Close()
+	})
+	go escapes.run(input)
 
 	// Handle window resize
 	go c.handleResize(session)
 
 	// Start remote shell
 	if err := session.Shell(); err != nil {
-		return fmt.Errorf("failed to start shell: %w", err)
+		return nil, fmt.Errorf("failed to start shell: %w", err)
 	}
+	started := time.Now()
 
 	// Wait for session to finish
-	if err := session.Wait(); err != nil {
-		if exitErr, ok := err.(*ssh.ExitError); ok {
-			// Session exited with non-zero status
-			return fmt.Errorf("remote command exited with status %d", exitErr.ExitStatus())
+	err = session.Wait()
+
+	result := &SessionResult{
+		Duration:      time.Since(started),
+		BytesSent:     sent.Count(),
+		BytesReceived: stdout.Count() + stderr.Count(),
+	}
+
+	if err != nil {
+		var exitErr *ssh.ExitError
+		var missingErr *ssh.ExitMissingError
+		switch {
+		case escapes.disconnected.Load():
+			// User hung up with ~., not an error
+			result.Disconnected = true
+		case errors.As(err, &exitErr):
+			result.ExitStatus = exitErr.ExitStatus()
+			result.Signal = exitErr.Signal()
+		case errors.As(err, &missingErr):
+			result.ExitMissing = true
+		default:
+			return result, fmt.Errorf("session wait failed: %w", err)
 		}
-		return fmt.Errorf("session wait failed: %w", err)
 	}
 
-	return nil
+	return result, nil
 }
 
 // handleResize handles terminal window resize events
@@ -152,6 +198,7 @@ func (c *Client) RunCommand(command string) (string, error) {
 
 // Close closes the SSH connection
 func (c *Client) Close() error {
+	c.forwards.closeAll()
 	if c.client != nil {
 		return c.client.Close()
 	}
@@ -0,0 +1,225 @@
+package ssh
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+)
+
+// escapeChar starts an escape sequence when typed at the beginning of a line
+const escapeChar = '~'
+
+const escapeHelp = `Supported escape sequences:
+ ~.   - terminate connection
+ ~C   - open a command line
+ ~#   - list forwarded connections
+ ~?   - this message
+ ~~   - send the escape character by typing it twice
+(Note that escapes are only recognized immediately after newline.)
+`
+
+const commandHelp = `Commands:
+      -L[bind_address:]port:host:hostport    Request local forward
+      -R[bind_address:]port:host:hostport    Request remote forward
+      -KL[bind_address:]port                 Cancel local forward
+      -KR[bind_address:]port                 Cancel remote forward
+`
+
+type escapeState int
+
+const (
+	escapeNormal  escapeState = iota
+	escapePending             // escape char seen at line start
+	escapeCommand             // reading a ~C command line
+)
+
+// escapeFilter sits between the local terminal and the session's stdin and
+// intercepts OpenSSH-style escape sequences
+type escapeFilter struct {
+	client     *Client
+	remote     io.Writer // session stdin
+	term       io.Writer // local terminal, in raw mode
+	disconnect func()
+
+	state     escapeState
+	lineStart bool
+	command   []byte
+
+	// disconnected is set by the input goroutine and read once the session ends
+	disconnected atomic.Bool
+}
+
+func newEscapeFilter(client *Client, remote, term io.Writer, disconnect func()) *escapeFilter {
+	return &escapeFilter{
+		client:     client,
+		remote:     remote,
+		term:       term,
+		disconnect: disconnect,
+		lineStart:  true, // the session starts on a fresh line
+	}
+}
+
+// run copies input to the remote side until input fails or the user disconnects
+func (f *escapeFilter) run(input io.Reader) {
+	buf := make([]byte, 1024)
+	for {
+		n, err := input.Read(buf)
+		if n > 0 {
+			out := f.process(buf[:n])
+			if len(out) > 0 {
+				if _, werr := f.remote.Write(out); werr != nil {
+					return
+				}
+			}
+			if f.disconnected.Load() {
+				f.disconnect()
+				return
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// process consumes typed bytes and returns those that should be sent to the server
+func (f *escapeFilter) process(in []byte) []byte {
+	out := make([]byte, 0, len(in))
+
+	for _, b := range in {
+		switch f.state {
+		case escapePending:
+			f.state = escapeNormal
+			switch b {
+			case '.':
+				f.printf("%c.\r\n", escapeChar)
+				f.disconnected.Store(true)
+				return out
+			case '?':
+				f.printf("%c?\r\n", escapeChar)
+				f.print(escapeHelp)
+				f.lineStart = true
+			case '#':
+				f.printf("%c#\r\n", escapeChar)
+				f.listForwards()
+				f.lineStart = true
+			case 'C':
+				f.print("\r\nssh> ")
+				f.command = f.command[:0]
+				f.state = escapeCommand
+			case escapeChar:
+				out = append(out, escapeChar)
+				f.lineStart = false
+			default:
+				out = append(out, escapeChar, b)
+				f.lineStart = b == '\r' || b == '\n'
+			}
+
+		case escapeCommand:
+			switch b {
+			case '\r', '\n':
+				f.print("\r\n")
+				f.runCommand(strings.TrimSpace(string(f.command)))
+				f.state = escapeNormal
+				f.lineStart = true
+			case 0x7f, 0x08: // backspace
+				if len(f.command) > 0 {
+					f.command = f.command[:len(f.command)-1]
+					f.print("\b \b")
+				}
+			case 0x03, 0x1b: // ctrl+c, esc
+				f.print("\r\n")
+				f.state = escapeNormal
+				f.lineStart = true
+			default:
+				if b >= 0x20 {
+					f.command = append(f.command, b)
+					f.term.Write([]byte{b})
+				}
+			}
+
+		default:
+			if b == escapeChar && f.lineStart {
+				f.state = escapePending
+				continue
+			}
+			out = append(out, b)
+			f.lineStart = b == '\r' || b == '\n'
+		}
+	}
+
+	return out
+}
+
+// runCommand executes a line typed at the ~C prompt
+func (f *escapeFilter) runCommand(line string) {
+	if line == "" {
+		return
+	}
+
+	switch {
+	case line == "?" || line == "-h":
+		f.print(commandHelp)
+
+	case strings.HasPrefix(line, "-KL"), strings.HasPrefix(line, "-KR"):
+		fwdType := LocalForward
+		if line[2] == 'R' {
+			fwdType = RemoteForward
+		}
+		bindAddr, err := parseCancelSpec(strings.TrimSpace(line[3:]))
+		if err != nil {
+			f.printf("%v\r\n", err)
+			return
+		}
+		if err := f.client.CancelForward(fwdType, bindAddr); err != nil {
+			f.printf("%v\r\n", err)
+			return
+		}
+		f.printf("Canceled forwarding %s.\r\n", bindAddr)
+
+	case strings.HasPrefix(line, "-L"), strings.HasPrefix(line, "-R"):
+		bindAddr, destAddr, err := parseForwardSpec(strings.TrimSpace(line[2:]))
+		if err != nil {
+			f.printf("%v\r\n", err)
+			return
+		}
+		if line[1] == 'R' {
+			_, err = f.client.AddRemoteForward(bindAddr, destAddr)
+		} else {
+			_, err = f.client.AddLocalForward(bindAddr, destAddr)
+		}
+		if err != nil {
+			f.printf("Port forwarding failed: %v\r\n", err)
+			return
+		}
+		f.print("Forwarding port.\r\n")
+
+	default:
+		f.print("Invalid command.\r\n")
+		f.print(commandHelp)
+	}
+}
+
+// listForwards prints the open port forwards
+func (f *escapeFilter) listForwards() {
+	fwds := f.client.Forwards()
+	f.print("The following connections are open:\r\n")
+	if len(fwds) == 0 {
+		f.print("  (none)\r\n")
+		return
+	}
+	for i, fwd := range fwds {
+		f.printf("  #%d %s\r\n", i, fwd)
+	}
+}
+
+// print writes text to the terminal, translating newlines for raw mode
+func (f *escapeFilter) print(text string) {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	io.WriteString(f.term, strings.ReplaceAll(text, "\n", "\r\n"))
+}
+
+func (f *escapeFilter) printf(format string, args ...any) {
+	f.print(fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,117 @@
+package ssh
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEscapeFilterProcess(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        []string // separate reads, to cover sequences split across them
+		wantSent     string
+		wantTerm     string
+		disconnected bool
+	}{
+		{"plain text", []string{"ls -l\r"}, "ls -l\r", "", false},
+		{"tilde mid-line is sent", []string{"echo ~.\r"}, "echo ~.\r", "", false},
+		{"disconnect at start", []string{"~."}, "", "~.\r\n", true},
+		{"disconnect after newline", []string{"exit\r~.ignored"}, "exit\r", "~.\r\n", true},
+		{"disconnect split across reads", []string{"\r~", "."}, "\r", "~.\r\n", true},
+		{"double tilde sends one", []string{"~~x"}, "~x", "", false},
+		{"double tilde ends the line start", []string{"~~~."}, "~~.", "", false},
+		{"unknown escape is sent as is", []string{"~x"}, "~x", "", false},
+		{"tilde then newline keeps line start", []string{"~\r~."}, "~\r", "~.\r\n", true},
+		{"help", []string{"~?"}, "", "~?\r\n" + escapeHelpRaw(), false},
+		{"command prompt cancelled", []string{"~C", "ab\x1b"}, "", "\r\nssh> ab\r\n", false},
+		{"command backspace", []string{"~Cab\x7f\x03"}, "", "\r\nssh> ab\b \b\r\n", false},
+		{"invalid command", []string{"~Cfoo\r"}, "", "\r\nssh> foo\r\nInvalid command.\r\n" + commandHelpRaw(), false},
+		{"bad forward spec", []string{"~C-L 80\r"}, "", "\r\nssh> -L 80\r\nbad forwarding specification '80'\r\n", false},
+		{"bad cancel spec", []string{"~C-KL a:b:c\r"}, "", "\r\nssh> -KL a:b:c\r\nbad forwarding close specification 'a:b:c'\r\n", false},
+		{"line start after command", []string{"~C\r~."}, "", "\r\nssh> \r\n~.\r\n", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var term bytes.Buffer
+			f := newEscapeFilter(nil, nil, &term, nil)
+			var sent []byte
+			for _, in := range tt.input {
+				sent = append(sent, f.process([]byte(in))...)
+				if f.disconnected.Load() {
+					break
+				}
+			}
+			if string(sent) != tt.wantSent {
+				t.Errorf("sent %q, want %q", sent, tt.wantSent)
+			}
+			if term.String() != tt.wantTerm {
+				t.Errorf("terminal got %q, want %q", term.String(), tt.wantTerm)
+			}
+			if f.disconnected.Load() != tt.disconnected {
+				t.Errorf("disconnected = %v, want %v", f.disconnected.Load(), tt.disconnected)
+			}
+		})
+	}
+}
+
+func TestParseForwardSpec(t *testing.T) {
+	tests := []struct {
+		spec     string
+		wantBind string
+		wantDest string
+		wantErr  bool
+	}{
+		{"8080:localhost:80", "localhost:8080", "localhost:80", false},
+		{"0.0.0.0:8080:db:5432", "0.0.0.0:8080", "db:5432", false},
+		{":8080:db:5432", ":8080", "db:5432", false},
+		{"8080", "", "", true},
+		{"8080:db", "", "", true},
+		{"a:b:c:d:e", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			bind, dest, err := parseForwardSpec(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if bind != tt.wantBind || dest != tt.wantDest {
+				t.Errorf("got %q, %q, want %q, %q", bind, dest, tt.wantBind, tt.wantDest)
+			}
+		})
+	}
+}
+
+func TestParseCancelSpec(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    string
+		wantErr bool
+	}{
+		{"8080", "localhost:8080", false},
+		{"127.0.0.1:8080", "127.0.0.1:8080", false},
+		{"a:b:c", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := parseCancelSpec(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// escapeHelpRaw is escapeHelp as printed in raw mode
+func escapeHelpRaw() string {
+	return string(bytes.ReplaceAll([]byte(escapeHelp), []byte("\n"), []byte("\r\n")))
+}
+
+func commandHelpRaw() string {
+	return string(bytes.ReplaceAll([]byte(commandHelp), []byte("\n"), []byte("\r\n")))
+}
@@ -0,0 +1,199 @@
+package ssh
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// ForwardType identifies the direction of a port forward
+type ForwardType int
+
+const (
+	LocalForward  ForwardType = iota // -L: local listener, remote destination
+	RemoteForward                    // -R: remote listener, local destination
+)
+
+// String returns the OpenSSH flag for the forward type
+func (t ForwardType) String() string {
+	if t == RemoteForward {
+		return "-R"
+	}
+	return "-L"
+}
+
+// Forward represents an active port forward on the connection
+type Forward struct {
+	Type     ForwardType
+	BindAddr string
+	DestAddr string
+
+	listener net.Listener
+	conns    int32 // number of open forwarded connections
+}
+
+// ActiveConnections returns the number of connections currently forwarded
+func (f *Forward) ActiveConnections() int {
+	return int(atomic.LoadInt32(&f.conns))
+}
+
+// String formats the forward the way OpenSSH lists it
+func (f *Forward) String() string {
+	return fmt.Sprintf("%s %s -> %s (%d open)", f.Type, f.BindAddr, f.DestAddr, f.ActiveConnections())
+}
+
+// forwards tracks the port forwards opened on a client
+type forwards struct {
+	mu   sync.Mutex
+	list []*Forward
+}
+
+// AddLocalForward listens on a local address and forwards connections to dest through the server
+func (c *Client) AddLocalForward(bindAddr, destAddr string) (*Forward, error) {
+	if c.client == nil {
+		return nil, fmt.Errorf("not connected")
+	}
+
+	listener, err := net.Listen("tcp", bindAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", bindAddr, err)
+	}
+
+	fwd := &Forward{
+		Type:     LocalForward,
+		BindAddr: bindAddr,
+		DestAddr: destAddr,
+		listener: listener,
+	}
+	go fwd.serve(func() (net.Conn, error) {
+		return c.client.Dial("tcp", destAddr)
+	})
+
+	c.forwards.add(fwd)
+	return fwd, nil
+}
+
+// AddRemoteForward asks the server to listen on bindAddr and forwards connections to a local dest
+func (c *Client) AddRemoteForward(bindAddr, destAddr string) (*Forward, error) {
+	if c.client == nil {
+		return nil, fmt.Errorf("not connected")
+	}
+
+	listener, err := c.client.Listen("tcp", bindAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on remote %s: %w", bindAddr, err)
+	}
+
+	fwd := &Forward{
+		Type:     RemoteForward,
+		BindAddr: bindAddr,
+		DestAddr: destAddr,
+		listener: listener,
+	}
+	go fwd.serve(func() (net.Conn, error) {
+		return net.Dial("tcp", destAddr)
+	})
+
+	c.forwards.add(fwd)
+	return fwd, nil
+}
+
+// CancelForward closes the forward of the given type listening on bindAddr
+func (c *Client) CancelForward(fwdType ForwardType, bindAddr string) error {
+	c.forwards.mu.Lock()
+	defer c.forwards.mu.Unlock()
+
+	for i, fwd := range c.forwards.list {
+		if fwd.Type == fwdType && fwd.BindAddr == bindAddr {
+			c.forwards.list = append(c.forwards.list[:i], c.forwards.list[i+1:]...)
+			return fwd.listener.Close()
+		}
+	}
+	return fmt.Errorf("no %s forward on %s", fwdType, bindAddr)
+}
+
+// Forwards returns the port forwards currently open on the connection
+func (c *Client) Forwards() []*Forward {
+	c.forwards.mu.Lock()
+	defer c.forwards.mu.Unlock()
+
+	return append([]*Forward(nil), c.forwards.list...)
+}
+
+func (f *forwards) add(fwd *Forward) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.list = append(f.list, fwd)
+}
+
+// closeAll closes every listener, ending all forwards
+func (f *forwards) closeAll() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, fwd := range f.list {
+		fwd.listener.Close()
+	}
+	f.list = nil
+}
+
+// serve accepts connections until the listener is closed and pipes each one to dial()
+func (f *Forward) serve(dial func() (net.Conn, error)) {
+	for {
+		conn, err := f.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go func() {
+			defer conn.Close()
+
+			dest, err := dial()
+			if err != nil {
+				return
+			}
+			defer dest.Close()
+
+			atomic.AddInt32(&f.conns, 1)
+			defer atomic.AddInt32(&f.conns, -1)
+
+			done := make(chan struct{}, 2)
+			go func() {
+				io.Copy(dest, conn)
+				done <- struct{}{}
+			}()
+			go func() {
+				io.Copy(conn, dest)
+				done <- struct{}{}
+			}()
+			<-done
+		}()
+	}
+}
+
+// parseForwardSpec parses "[bind_address:]port:host:hostport" into bind and destination addresses
+func parseForwardSpec(spec string) (bindAddr, destAddr string, err error) {
+	parts := strings.Split(spec, ":")
+	switch len(parts) {
+	case 3:
+		return net.JoinHostPort("localhost", parts[0]), net.JoinHostPort(parts[1], parts[2]), nil
+	case 4:
+		return net.JoinHostPort(parts[0], parts[1]), net.JoinHostPort(parts[2], parts[3]), nil
+	}
+	return "", "", fmt.Errorf("bad forwarding specification '%s'", spec)
+}
+
+// parseCancelSpec parses "[bind_address:]port" into a bind address
+func parseCancelSpec(spec string) (string, error) {
+	parts := strings.Split(spec, ":")
+	switch len(parts) {
+	case 1:
+		return net.JoinHostPort("localhost", parts[0]), nil
+	case 2:
+		return net.JoinHostPort(parts[0], parts[1]), nil
+	}
+	return "", fmt.Errorf("bad forwarding close specification '%s'", spec)
+}
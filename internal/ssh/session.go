@@ -0,0 +1,52 @@
+package ssh
+
+import (
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// SessionResult describes how an interactive session ended
+type SessionResult struct {
+	ExitStatus    int           // exit status reported by the remote shell
+	Signal        string        // signal that terminated the shell, if any
+	ExitMissing   bool          // the server closed the channel without an exit status
+	Disconnected  bool          // the user hung up with ~.
+	Duration      time.Duration // time between shell start and session end
+	BytesSent     int64         // bytes typed and sent to the server
+	BytesReceived int64         // bytes written to stdout and stderr by the server
+}
+
+// Status returns a human readable description of how the session ended
+func (r *SessionResult) Status() string {
+	switch {
+	case r.Disconnected:
+		return "Disconnected by user (~.)"
+	case r.Signal != "":
+		return fmt.Sprintf("Terminated by signal %s", r.Signal)
+	case r.ExitMissing:
+		return "Connection closed without exit status"
+	case r.ExitStatus == 0:
+		return "Exited normally"
+	default:
+		return fmt.Sprintf("Exited with status %d", r.ExitStatus)
+	}
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	w     io.Writer
+	count int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	atomic.AddInt64(&cw.count, int64(n))
+	return n, err
+}
+
+// Count returns the number of bytes written so far
+func (cw *countingWriter) Count() int64 {
+	return atomic.LoadInt64(&cw.count)
+}
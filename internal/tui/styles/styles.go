@@ -58,6 +58,12 @@ var SelectedStyle = lipgloss.NewStyle().
 	Bold(true).
 	Padding(0, 1)
 
+// Selected item style in a pane that doesn't have focus
+var InactiveSelectedStyle = lipgloss.NewStyle().
+	Foreground(Highlight).
+	Background(Dim).
+	Padding(0, 1)
+
 // Normal item style
 var ItemStyle = lipgloss.NewStyle().
 	Foreground(Foreground).
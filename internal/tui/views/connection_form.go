@@ -42,6 +42,10 @@ type ConnectionFormModel struct {
 	mode   FormMode
 	connID string // Only set when editing
 
+	// Settings without form fields, kept when editing
+	maxPacket           int
+	transferConcurrency int
+
 	// Text inputs
 	labelInput    textinput.Model
 	hostInput     textinput.Model
@@ -128,6 +132,8 @@ func NewConnectionFormWithCredentials(mode FormMode, conn *config.Connection, cr
 	// If editing, populate with existing values
 	if mode == FormModeEdit && conn != nil {
 		m.connID = conn.ID
+		m.maxPacket = conn.MaxPacket
+		m.transferConcurrency = conn.TransferConcurrency
 		m.inputs[0].SetValue(conn.Label)
 		m.inputs[1].SetValue(conn.Host)
 		m.inputs[2].SetValue(strconv.Itoa(conn.Port))
@@ -599,6 +605,9 @@ func (m ConnectionFormModel) GetConnection() config.Connection {
 		Host:  m.inputs[1].Value(),
 		Port:  port,
 		Icon:  m.icons[m.iconIndex],
+
+		MaxPacket:           m.maxPacket,
+		TransferConcurrency: m.transferConcurrency,
 	}
 
 	if m.authTypeIndex == 1 && len(m.credentials) > 0 {
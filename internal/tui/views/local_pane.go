@@ -0,0 +1,266 @@
+package views
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/steevenmentech/bifrost/internal/tui/styles"
+)
+
+// localEntry is a file or directory in a local pane
+type localEntry struct {
+	name  string
+	size  int64
+	isDir bool
+}
+
+// LocalPaneModel is a navigable listing of a local directory with marks.
+// It backs both the upload picker and the local side of the dual-pane mode.
+type LocalPaneModel struct {
+	currentDir    string
+	entries       []localEntry
+	selectedIndex int
+	scrollOffset  int
+	visibleLines  int
+	marked        map[string]bool // Full paths of marked entries
+	showHidden    bool
+	err           error
+}
+
+// NewLocalPane creates a pane listing startDir
+func NewLocalPane(startDir string) LocalPaneModel {
+	m := LocalPaneModel{
+		currentDir:   startDir,
+		marked:       make(map[string]bool),
+		visibleLines: 10,
+	}
+	m.Reload()
+	return m
+}
+
+// Reload reads the current directory again, directories first
+func (m *LocalPaneModel) Reload() {
+	dirEntries, err := os.ReadDir(m.currentDir)
+	if err != nil {
+		m.err = err
+		return
+	}
+
+	entries := make([]localEntry, 0, len(dirEntries))
+	for _, e := range dirEntries {
+		if !m.showHidden && strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		info, err := os.Stat(filepath.Join(m.currentDir, e.Name()))
+		if err != nil {
+			// Broken link, still show it so it can be picked
+			info, err = e.Info()
+			if err != nil {
+				continue
+			}
+		}
+		entries = append(entries, localEntry{
+			name:  e.Name(),
+			size:  info.Size(),
+			isDir: info.IsDir(),
+		})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].isDir && !entries[j].isDir
+	})
+
+	m.entries = entries
+	m.err = nil
+	if m.selectedIndex >= len(m.entries) {
+		m.selectedIndex = max(0, len(m.entries)-1)
+	}
+	m.adjustScroll()
+}
+
+// chdir switches to dir and resets the cursor
+func (m *LocalPaneModel) chdir(dir string) {
+	m.currentDir = dir
+	m.selectedIndex = 0
+	m.scrollOffset = 0
+	m.Reload()
+}
+
+// Update handles navigation and marking keys
+func (m LocalPaneModel) Update(msg tea.Msg) (LocalPaneModel, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	switch keyMsg.String() {
+	case "k", "up":
+		if m.selectedIndex > 0 {
+			m.selectedIndex--
+		}
+	case "j", "down":
+		if m.selectedIndex < len(m.entries)-1 {
+			m.selectedIndex++
+		}
+	case "ctrl+u":
+		m.selectedIndex = max(0, m.selectedIndex-max(1, m.visibleLines/2))
+	case "ctrl+d":
+		m.selectedIndex = max(0, min(len(m.entries)-1, m.selectedIndex+max(1, m.visibleLines/2)))
+	case "G":
+		m.selectedIndex = max(0, len(m.entries)-1)
+	case "h", "left", "backspace":
+		parent := filepath.Dir(m.currentDir)
+		if parent != m.currentDir {
+			m.chdir(parent)
+		}
+	case "l", "right", "enter":
+		if m.selectedIndex < len(m.entries) && m.entries[m.selectedIndex].isDir {
+			m.chdir(filepath.Join(m.currentDir, m.entries[m.selectedIndex].name))
+		}
+	case "~":
+		if homeDir, err := os.UserHomeDir(); err == nil {
+			m.chdir(homeDir)
+		}
+	case ".":
+		m.showHidden = !m.showHidden
+		m.Reload()
+	case " ": // Toggle mark
+		if p := m.SelectedPath(); p != "" {
+			if m.marked[p] {
+				delete(m.marked, p)
+			} else {
+				m.marked[p] = true
+			}
+			if m.selectedIndex < len(m.entries)-1 {
+				m.selectedIndex++
+			}
+		}
+	}
+
+	m.adjustScroll()
+	return m, nil
+}
+
+// adjustScroll keeps the selected entry visible
+func (m *LocalPaneModel) adjustScroll() {
+	visible := max(1, m.visibleLines)
+	if m.selectedIndex < m.scrollOffset {
+		m.scrollOffset = m.selectedIndex
+	}
+	if m.selectedIndex >= m.scrollOffset+visible {
+		m.scrollOffset = m.selectedIndex - visible + 1
+	}
+}
+
+// SetVisibleLines sets how many entries fit in the pane
+func (m *LocalPaneModel) SetVisibleLines(n int) {
+	m.visibleLines = max(1, n)
+	m.adjustScroll()
+}
+
+// RenderList renders the visible entries, each padded or cut to width
+func (m LocalPaneModel) RenderList(width int, focused bool) string {
+	var s strings.Builder
+
+	if m.err != nil {
+		s.WriteString(styles.ErrorStyle.Render(truncateText("Error: "+m.err.Error(), width)) + "\n")
+	}
+	if len(m.entries) == 0 {
+		s.WriteString(styles.SubtleStyle.Render("(empty directory)") + "\n")
+		return s.String()
+	}
+
+	end := min(m.scrollOffset+max(1, m.visibleLines), len(m.entries))
+	for i := m.scrollOffset; i < end; i++ {
+		e := m.entries[i]
+		marked := m.marked[filepath.Join(m.currentDir, e.name)]
+		s.WriteString(renderPaneLine(e.name, e.size, e.isDir, marked, i == m.selectedIndex, focused, width, nil) + "\n")
+	}
+
+	return s.String()
+}
+
+// CurrentDir returns the directory being listed
+func (m LocalPaneModel) CurrentDir() string {
+	return m.currentDir
+}
+
+// SelectedPath returns the full path of the highlighted entry
+func (m LocalPaneModel) SelectedPath() string {
+	if m.selectedIndex >= len(m.entries) {
+		return ""
+	}
+	return filepath.Join(m.currentDir, m.entries[m.selectedIndex].name)
+}
+
+// Selected returns the marked paths, or the highlighted entry if nothing is marked
+func (m LocalPaneModel) Selected() []string {
+	if len(m.marked) > 0 {
+		paths := make([]string, 0, len(m.marked))
+		for p := range m.marked {
+			paths = append(paths, p)
+		}
+		sort.Strings(paths)
+		return paths
+	}
+	if p := m.SelectedPath(); p != "" {
+		return []string{p}
+	}
+	return nil
+}
+
+// MarkedCount returns the number of marked entries
+func (m LocalPaneModel) MarkedCount() int {
+	return len(m.marked)
+}
+
+// ClearMarks unmarks every entry
+func (m *LocalPaneModel) ClearMarks() {
+	m.marked = make(map[string]bool)
+}
+
+// renderPaneLine formats one entry of a file pane
+func renderPaneLine(name string, size int64, isDir, marked, selected, focused bool, width int, matched []int) string {
+	icon := "\uf15b" // File icon
+	sizeStr := FormatFileSize(size)
+	if isDir {
+		icon = "\uf07c" // Folder icon
+		sizeStr = "-"
+	}
+	mark := " "
+	if marked {
+		mark = "*"
+	}
+
+	// mark + icon + spaces + size column + style padding
+	nameWidth := max(8, width-18)
+	shown := truncateText(name, nameWidth)
+	line := fmt.Sprintf("%s %s  %-*s %10s", mark, icon, nameWidth, shown, sizeStr)
+
+	style := styles.ItemStyle
+	switch {
+	case selected && focused:
+		style = styles.SelectedStyle
+	case selected:
+		style = styles.InactiveSelectedStyle
+	}
+	return renderMatched(line, matchIndices(matched, name, shown, utf8.RuneCountInString(mark+" "+icon+"  ")), style)
+}
+
+// truncateText cuts text to width cells, marking the cut with an ellipsis
+func truncateText(text string, width int) string {
+	if lipgloss.Width(text) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && lipgloss.Width(string(runes))+1 > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "…"
+}
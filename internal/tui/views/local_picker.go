@@ -0,0 +1,90 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/steevenmentech/bifrost/internal/tui/styles"
+)
+
+// LocalPickerModel lets the user pick one or more local files and directories
+type LocalPickerModel struct {
+	title string
+	pane  LocalPaneModel
+
+	confirmed bool
+	cancelled bool
+}
+
+// NewLocalPicker creates a picker starting at startDir
+func NewLocalPicker(title, startDir string) LocalPickerModel {
+	return LocalPickerModel{
+		title: title,
+		pane:  NewLocalPane(startDir),
+	}
+}
+
+// Init initializes the picker
+func (m LocalPickerModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles messages
+func (m LocalPickerModel) Update(msg tea.Msg) (LocalPickerModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.pane.SetVisibleLines(msg.Height - 10)
+		return m, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc", "q":
+			m.cancelled = true
+			return m, nil
+		case "enter":
+			if len(m.pane.Selected()) > 0 {
+				m.confirmed = true
+			}
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.pane, cmd = m.pane.Update(msg)
+	return m, cmd
+}
+
+// View renders the picker
+func (m LocalPickerModel) View() string {
+	var s strings.Builder
+	s.WriteString("\n")
+	s.WriteString(styles.TitleStyle.Render("  "+m.title) + "\n")
+	s.WriteString(styles.SubtleStyle.Render("   "+m.pane.CurrentDir()) + "\n\n")
+
+	for _, line := range strings.Split(strings.TrimSuffix(m.pane.RenderList(80, true), "\n"), "\n") {
+		s.WriteString("  " + line + "\n")
+	}
+
+	s.WriteString("\n")
+	if n := m.pane.MarkedCount(); n > 0 {
+		s.WriteString(styles.SuccessStyle.Render(fmt.Sprintf("  %d marked", n)) + "\n")
+	}
+	s.WriteString(styles.SubtleStyle.Render("  Up/Down: j/k | Parent: h | Open: l | Mark: space | Hidden: . | Confirm: enter | Cancel: esc") + "\n")
+	return s.String()
+}
+
+// Selected returns the marked paths, or the highlighted entry if nothing is marked
+func (m LocalPickerModel) Selected() []string {
+	return m.pane.Selected()
+}
+
+// IsConfirmed returns whether user confirmed the selection
+func (m LocalPickerModel) IsConfirmed() bool {
+	return m.confirmed
+}
+
+// IsCancelled returns whether user cancelled
+func (m LocalPickerModel) IsCancelled() bool {
+	return m.cancelled
+}
@@ -0,0 +1,721 @@
+package views
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/steevenmentech/bifrost/internal/sftp"
+	"github.com/steevenmentech/bifrost/internal/tui/styles"
+)
+
+const (
+	// pagerAutoIndex is the largest file whose lines are counted as soon as it
+	// is opened. Larger files are only counted on request, as that reads them whole.
+	pagerAutoIndex = 64 * 1024 * 1024
+
+	pagerFollowInterval = time.Second // how often a followed file is checked for growth
+)
+
+var pagerMatchStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#000000")).Background(styles.Warning)
+
+// pagerIndexMsg reports progress of the background line count. updates is
+// nil once the count stops.
+type pagerIndexMsg struct {
+	index   *lineIndex
+	updates <-chan struct{}
+}
+
+// pagerSearchMsg carries the result of a search
+type pagerSearchMsg struct {
+	seq    int
+	offset int64
+	err    error
+}
+
+// pagerPollMsg asks for the next check of a followed file
+type pagerPollMsg struct {
+	seq int
+}
+
+// pagerFollowMsg carries what a check of a followed file found. file is the
+// file opened again after it was truncated or rotated.
+type pagerFollowMsg struct {
+	seq    int
+	change sftp.FileChange
+	file   *sftp.RemoteFile
+	err    error
+}
+
+// pagerModel is a less-like viewer for a remote file. It keeps only the
+// visible window in memory and reads it with ranged reads.
+type pagerModel struct {
+	client  *sftp.Client
+	path    string
+	file    *sftp.RemoteFile
+	src     *pagerSource
+	top     int64       // offset of the first visible line
+	topLine int64       // number of the top line when known from scrolling, 0 otherwise
+	window  []pagerLine // visible lines
+	left    int         // first visible column
+	width   int
+	height  int
+
+	lineNumbers  bool
+	index        *lineIndex
+	indexCancel  context.CancelFunc
+	indexRunning bool
+
+	following bool // keeping the end of a growing file on screen, like tail -f
+	followSeq int
+
+	searchInput   textinput.Model
+	typing        bool // entering a search query
+	backward      bool // direction of the last search
+	query         string
+	searchSeq     int
+	searchCancel  context.CancelFunc
+	searchRunning bool
+
+	message string
+	err     error
+	closed  bool
+}
+
+// openPager shows the selected file in the full-screen pager, following
+// its end as it grows with follow set
+func (m *SFTPBrowserModel) openPager(follow bool) tea.Cmd {
+	pager, cmd, err := openPager(m.client, m.selectedPath(), m.width, m.height)
+	if err != nil {
+		m.err = err
+		return nil
+	}
+	m.pager = pager
+	m.state = PagerState
+	if follow {
+		return tea.Batch(cmd, pager.follow())
+	}
+	return cmd
+}
+
+func (m *SFTPBrowserModel) updatePager(msg tea.Msg) (tea.Model, tea.Cmd) {
+	cmd := m.pager.Update(msg)
+	if m.pager.Closed() {
+		m.pager = nil
+		m.state = BrowsingState
+	}
+	return m, cmd
+}
+
+// openPager opens a remote file in the pager
+func openPager(client *sftp.Client, remotePath string, width, height int) (*pagerModel, tea.Cmd, error) {
+	file, err := client.OpenRemote(remotePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	size, err := file.Size()
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+
+	searchInput := textinput.New()
+	searchInput.Prompt = "/"
+	searchInput.CharLimit = 256
+	searchInput.Width = 60
+
+	p := &pagerModel{
+		client:      client,
+		path:        remotePath,
+		file:        file,
+		src:         newPagerSource(file, size),
+		topLine:     1,
+		width:       width,
+		height:      height,
+		lineNumbers: true,
+		searchInput: searchInput,
+	}
+	p.fill()
+
+	var cmd tea.Cmd
+	if size <= pagerAutoIndex {
+		cmd = p.startIndex()
+	}
+	return p, cmd, nil
+}
+
+// Closed reports whether the user left the pager
+func (p *pagerModel) Closed() bool {
+	return p.closed
+}
+
+// close releases the remote file and stops background work
+func (p *pagerModel) close() {
+	p.stopFollow()
+	p.stopBackground()
+	p.file.Close()
+	p.closed = true
+}
+
+// stopBackground cancels the line count and any running search
+func (p *pagerModel) stopBackground() {
+	if p.indexCancel != nil {
+		p.indexCancel()
+	}
+	if p.searchCancel != nil {
+		p.searchCancel()
+	}
+	p.indexRunning = false
+	p.searchRunning = false
+	p.searchSeq++
+}
+
+// visibleLines is the number of file lines that fit on screen
+func (p *pagerModel) visibleLines() int {
+	return max(1, p.height-6)
+}
+
+// fill reads the visible window at the current position
+func (p *pagerModel) fill() {
+	lines, err := p.src.readLines(p.top, p.visibleLines())
+	p.window = lines
+	if err != nil {
+		p.err = err
+	}
+}
+
+// startIndex counts the lines of the file in the background
+func (p *pagerModel) startIndex() tea.Cmd {
+	if p.index != nil {
+		return nil
+	}
+	p.index = &lineIndex{}
+	return p.runIndex()
+}
+
+// runIndex counts lines up to the current size of the file, carrying on
+// from where the index stopped
+func (p *pagerModel) runIndex() tea.Cmd {
+	ctx, cancel := context.WithCancel(context.Background())
+	p.indexCancel = cancel
+	p.indexRunning = true
+
+	index := p.index
+	updates := make(chan struct{}, 1)
+	file, size := p.file, p.src.size
+	go func() {
+		defer close(updates)
+		index.build(ctx, file, size, func() {
+			// Drop updates if the UI is behind; the view reads the index directly
+			select {
+			case updates <- struct{}{}:
+			default:
+			}
+		})
+	}()
+	return waitForIndex(index, updates)
+}
+
+// waitForIndex waits for the next line count update, until the count ends
+func waitForIndex(index *lineIndex, updates <-chan struct{}) tea.Cmd {
+	return func() tea.Msg {
+		if _, ok := <-updates; !ok {
+			return pagerIndexMsg{index: index}
+		}
+		return pagerIndexMsg{index: index, updates: updates}
+	}
+}
+
+// down scrolls n lines towards the end, stopping when the last line is on screen
+func (p *pagerModel) down(n int) {
+	lines, err := p.src.readLines(p.top, n+p.visibleLines())
+	if err != nil {
+		p.err = err
+		return
+	}
+	idx := min(n, max(0, len(lines)-p.visibleLines()))
+	if idx == 0 {
+		return
+	}
+	for _, l := range lines[1 : idx+1] {
+		if p.topLine > 0 && !l.continued {
+			p.topLine++
+		}
+	}
+	p.top = lines[idx].offset
+	p.fill()
+}
+
+// up scrolls n lines towards the start
+func (p *pagerModel) up(n int) {
+	for i := 0; i < n && p.top > 0; i++ {
+		prev, err := p.src.prevLine(p.top)
+		if err != nil {
+			p.err = err
+			break
+		}
+		if p.topLine > 1 && p.src.startsLine(p.top) {
+			p.topLine--
+		}
+		p.top = prev
+	}
+	if p.top == 0 {
+		p.topLine = 1
+	}
+	p.fill()
+}
+
+// toStart jumps to the first line
+func (p *pagerModel) toStart() {
+	p.top = 0
+	p.topLine = 1
+	p.fill()
+}
+
+// toEnd jumps to the last page of the file
+func (p *pagerModel) toEnd() {
+	if p.src.size == 0 {
+		p.toStart()
+		return
+	}
+	last, err := p.src.prevLine(p.src.size)
+	if err != nil {
+		p.err = err
+		return
+	}
+	p.jumpTo(last)
+	p.up(p.visibleLines() - 1)
+}
+
+// jumpTo shows the line holding offset at the top
+func (p *pagerModel) jumpTo(offset int64) {
+	start, err := p.src.lineStart(offset)
+	if err != nil {
+		p.err = err
+		return
+	}
+	p.top = start
+	p.topLine = 0
+	if start == 0 {
+		p.topLine = 1
+	}
+	p.fill()
+}
+
+// follow shows the end of the file and keeps checking it for new lines
+func (p *pagerModel) follow() tea.Cmd {
+	p.following = true
+	p.followSeq++
+	p.toEnd()
+	return p.poll()
+}
+
+// stopFollow stops checking the file, dropping any check in flight
+func (p *pagerModel) stopFollow() {
+	p.following = false
+	p.followSeq++
+}
+
+// poll checks the followed file for growth, truncation and rotation in the
+// background, opening it again when it was replaced
+func (p *pagerModel) poll() tea.Cmd {
+	seq := p.followSeq
+	client, remotePath, file, known := p.client, p.path, p.file, p.src.size
+	return func() tea.Msg {
+		change, err := client.PollRemote(remotePath, file, known)
+		msg := pagerFollowMsg{seq: seq, change: change, err: err}
+		if err != nil || !change.Replaced {
+			return msg
+		}
+
+		msg.file, msg.err = client.OpenRemote(remotePath)
+		if msg.err != nil {
+			return msg
+		}
+		if msg.change.Size, msg.err = msg.file.Size(); msg.err != nil {
+			msg.file.Close()
+			msg.file = nil
+		}
+		return msg
+	}
+}
+
+// updateFollow applies what a check of the followed file found and
+// schedules the next one
+func (p *pagerModel) updateFollow(msg pagerFollowMsg) tea.Cmd {
+	if msg.seq != p.followSeq {
+		if msg.file != nil {
+			msg.file.Close()
+		}
+		return nil
+	}
+
+	var cmd tea.Cmd
+	switch {
+	case msg.err != nil:
+		// The file may be missing for a moment while it is rotated, keep trying
+		p.err = msg.err
+	case msg.file != nil:
+		p.err = nil
+		cmd = p.reopen(msg.file, msg.change.Size)
+		p.message = "File was truncated or replaced, reading it from the start"
+	case msg.change.Size != p.src.size:
+		p.err = nil
+		p.src.setSize(msg.change.Size)
+		if p.index != nil && !p.indexRunning {
+			cmd = p.runIndex()
+		}
+		p.toEnd()
+	default:
+		p.err = nil
+	}
+
+	seq := p.followSeq
+	return tea.Batch(cmd, tea.Tick(pagerFollowInterval, func(time.Time) tea.Msg {
+		return pagerPollMsg{seq: seq}
+	}))
+}
+
+// reopen switches to a new file at the same path, after the old one was
+// truncated or rotated
+func (p *pagerModel) reopen(file *sftp.RemoteFile, size int64) tea.Cmd {
+	p.stopBackground()
+	p.file.Close()
+	p.file = file
+	p.src = newPagerSource(file, size)
+
+	counted := p.index != nil
+	p.index = nil
+	p.toEnd()
+	if counted {
+		return p.startIndex()
+	}
+	return nil
+}
+
+// startSearch looks for the query in the background, from the line after
+// the top one, or before it when searching backwards
+func (p *pagerModel) startSearch(backward bool) tea.Cmd {
+	if p.query == "" {
+		return nil
+	}
+	if p.searchCancel != nil {
+		p.searchCancel()
+	}
+
+	from := p.top
+	if !backward {
+		from = p.src.size
+		if len(p.window) > 1 {
+			from = p.window[1].offset
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p.searchCancel = cancel
+	p.searchSeq++
+	p.searchRunning = true
+	p.backward = backward
+	p.message = ""
+
+	seq := p.searchSeq
+	query := []byte(p.query)
+	fold := p.query == strings.ToLower(p.query)
+	file, size := p.file, p.src.size
+	return func() tea.Msg {
+		offset, err := search(ctx, file, size, from, query, fold, backward)
+		return pagerSearchMsg{seq: seq, offset: offset, err: err}
+	}
+}
+
+func (p *pagerModel) Update(msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		p.width = msg.Width
+		p.height = msg.Height
+		if p.following {
+			p.toEnd()
+		} else {
+			p.fill()
+		}
+		return nil
+
+	case pagerIndexMsg:
+		if msg.index != p.index {
+			return nil
+		}
+		if msg.updates == nil {
+			p.indexRunning = false
+			return nil
+		}
+		return waitForIndex(msg.index, msg.updates)
+
+	case pagerPollMsg:
+		if msg.seq != p.followSeq {
+			return nil
+		}
+		return p.poll()
+
+	case pagerFollowMsg:
+		return p.updateFollow(msg)
+
+	case pagerSearchMsg:
+		if msg.seq != p.searchSeq {
+			return nil
+		}
+		p.searchRunning = false
+		switch {
+		case errors.Is(msg.err, context.Canceled):
+			p.message = "Search cancelled"
+		case msg.err != nil:
+			p.err = msg.err
+		case msg.offset < 0:
+			p.message = "Pattern not found: " + p.query
+		default:
+			p.jumpTo(msg.offset)
+		}
+		return nil
+
+	case tea.KeyMsg:
+		if p.typing {
+			return p.updateSearchInput(msg)
+		}
+		return p.updateKeys(msg)
+	}
+	return nil
+}
+
+func (p *pagerModel) updateSearchInput(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "enter":
+		p.typing = false
+		p.searchInput.Blur()
+		if q := p.searchInput.Value(); q != "" {
+			p.query = q
+		}
+		return p.startSearch(p.backward)
+	case "esc":
+		p.typing = false
+		p.searchInput.Blur()
+		return nil
+	}
+
+	var cmd tea.Cmd
+	p.searchInput, cmd = p.searchInput.Update(msg)
+	return cmd
+}
+
+func (p *pagerModel) updateKeys(msg tea.KeyMsg) tea.Cmd {
+	p.message = ""
+	p.err = nil
+	page := p.visibleLines()
+
+	// Any key stops following, and then does what it does
+	if p.following {
+		p.stopFollow()
+	}
+
+	switch msg.String() {
+	case "q", "esc":
+		if p.searchRunning {
+			p.searchCancel()
+			return nil
+		}
+		p.close()
+	case "j", "down", "enter":
+		p.down(1)
+	case "k", "up":
+		p.up(1)
+	case " ", "f", "pgdown", "ctrl+f":
+		p.down(page)
+	case "b", "pgup", "ctrl+b":
+		p.up(page)
+	case "d", "ctrl+d":
+		p.down(page / 2)
+	case "u", "ctrl+u":
+		p.up(page / 2)
+	case "g", "home":
+		p.toStart()
+	case "G", "end":
+		p.toEnd()
+	case "F":
+		return p.follow()
+	case "left":
+		p.left = max(0, p.left-8)
+	case "right":
+		p.left += 8
+	case "/", "?":
+		p.typing = true
+		p.backward = msg.String() == "?"
+		p.searchInput.Prompt = msg.String()
+		p.searchInput.SetValue("")
+		p.searchInput.Focus()
+		return textinput.Blink
+	case "n":
+		return p.startSearch(p.backward)
+	case "N":
+		return p.startSearch(!p.backward)
+	case "#":
+		// Large files are only counted when asked for
+		if p.lineNumbers && p.index == nil {
+			return p.startIndex()
+		}
+		p.lineNumbers = !p.lineNumbers
+		if p.lineNumbers {
+			return p.startIndex()
+		}
+	}
+	return nil
+}
+
+// lineNumberOfTop returns the number of the top line, or 0 when unknown
+func (p *pagerModel) lineNumberOfTop() int64 {
+	if p.index != nil {
+		if n := p.index.lineNumber(p.src, p.top); n > 0 {
+			return n
+		}
+	}
+	return p.topLine
+}
+
+func (p *pagerModel) View() string {
+	var s strings.Builder
+	s.WriteString("\n")
+	s.WriteString(styles.TitleStyle.Render("  "+p.path) + "\n\n")
+
+	number := p.lineNumberOfTop()
+	gutter := 0
+	if p.lineNumbers {
+		gutter = max(6, len(fmt.Sprint(number+int64(len(p.window)))))
+	}
+
+	for i, line := range p.window {
+		if i > 0 && number > 0 && !line.continued {
+			number++
+		}
+
+		prefix := "  "
+		if p.lineNumbers {
+			label := ""
+			if number > 0 && !line.continued {
+				label = fmt.Sprint(number)
+			}
+			prefix += styles.SubtleStyle.Render(fmt.Sprintf("%*s │ ", gutter, label))
+		}
+		s.WriteString(prefix + p.renderLine(line.text, max(10, p.width-lipgloss.Width(prefix)-1)) + "\n")
+	}
+	for i := len(p.window); i < p.visibleLines(); i++ {
+		s.WriteString(styles.SubtleStyle.Render("  ~") + "\n")
+	}
+
+	s.WriteString("\n" + p.renderStatus() + "\n")
+	switch {
+	case p.typing:
+		s.WriteString("  " + p.searchInput.View() + "\n")
+	case p.following:
+		s.WriteString(styles.SubtleStyle.Render("  Waiting for new lines... | Stop following: any key | Close: q") + "\n")
+	default:
+		s.WriteString(styles.SubtleStyle.Render("  Scroll: j/k | Page: space/b | Half page: d/u | Top/End: g/G | Search: / ? n N | Follow: F | Line numbers: # | Close: q") + "\n")
+	}
+	return s.String()
+}
+
+// renderLine prepares a line of the file for the terminal: tabs expanded,
+// control characters replaced, scrolled horizontally and search matches highlighted
+func (p *pagerModel) renderLine(text []byte, width int) string {
+	var b strings.Builder
+	for len(text) > 0 {
+		r, size := utf8.DecodeRune(text)
+		text = text[size:]
+		switch {
+		case r == '\t':
+			b.WriteString("    ")
+		case r == utf8.RuneError && size == 1:
+			b.WriteRune('�')
+		case r < 0x20 || r == 0x7f:
+			b.WriteRune('·')
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	line := ansi.Cut(b.String(), p.left, p.left+width)
+	if p.query == "" {
+		return line
+	}
+	return highlightMatches(line, p.query)
+}
+
+// highlightMatches marks each occurrence of query in line, ignoring the case
+// of ASCII letters for a lowercase query
+func highlightMatches(line, query string) string {
+	haystack := line
+	if query == strings.ToLower(query) {
+		lowered := []byte(line)
+		lowerASCII(lowered)
+		haystack = string(lowered)
+	}
+
+	var b strings.Builder
+	for {
+		i := strings.Index(haystack, query)
+		if i < 0 {
+			b.WriteString(line)
+			return b.String()
+		}
+		b.WriteString(line[:i])
+		b.WriteString(pagerMatchStyle.Render(line[i : i+len(query)]))
+		line, haystack = line[i+len(query):], haystack[i+len(query):]
+	}
+}
+
+// renderStatus describes the position in the file and any background work
+func (p *pagerModel) renderStatus() string {
+	size := p.src.size
+	end := size
+	if len(p.window) > 0 {
+		last := p.window[len(p.window)-1]
+		end = last.offset + int64(len(last.text))
+	}
+	percent := 100.0
+	if size > 0 {
+		percent = float64(end) / float64(size) * 100
+	}
+
+	status := fmt.Sprintf("  %s  %.0f%%", FormatFileSize(size), percent)
+	if n := p.lineNumberOfTop(); n > 0 {
+		status = fmt.Sprintf("  line %d  %s", n, status[2:])
+	}
+	if p.index != nil {
+		switch progress, err := p.index.progress(size); {
+		case err != nil:
+			status += "  (line count failed)"
+		case progress < 1 && !p.following:
+			status += fmt.Sprintf("  counting lines %.0f%%", progress*100)
+		}
+	}
+	if p.left > 0 {
+		status += fmt.Sprintf("  column %d", p.left+1)
+	}
+	if p.searchRunning {
+		status += "  searching... (esc to cancel)"
+	}
+	if p.following {
+		status += "  following"
+	}
+
+	switch {
+	case p.err != nil:
+		return styles.ErrorStyle.Render(status + "  " + p.err.Error())
+	case p.message != "":
+		return styles.SubtleStyle.Render(status) + "  " + styles.SuccessStyle.Render(p.message)
+	}
+	return styles.SubtleStyle.Render(status)
+}
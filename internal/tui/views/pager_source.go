@@ -0,0 +1,334 @@
+package views
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"sync"
+)
+
+const (
+	pagerBlockSize   = 64 * 1024 // unit of ranged reads and of the line index
+	pagerCacheBlocks = 64        // blocks kept in memory, 4 MB
+	pagerMaxLine     = 16 * 1024 // longer lines are shown in pieces
+	pagerSearchChunk = 1024 * 1024
+)
+
+// pagerLine is one line of the visible window
+type pagerLine struct {
+	offset    int64
+	text      []byte
+	continued bool // a piece of a line longer than pagerMaxLine
+}
+
+// pagerSource reads lines of a remote file through a small block cache, so
+// only the parts of the file that are looked at are transferred
+type pagerSource struct {
+	r      io.ReaderAt
+	size   int64
+	blocks map[int64][]byte
+	order  []int64 // cached block numbers, oldest first
+}
+
+func newPagerSource(r io.ReaderAt, size int64) *pagerSource {
+	return &pagerSource{r: r, size: size, blocks: make(map[int64][]byte)}
+}
+
+// setSize updates the size of a file that grew or shrank, dropping cached
+// blocks that no longer match it
+func (s *pagerSource) setSize(size int64) {
+	if size == s.size {
+		return
+	}
+	from := min(size, s.size) / pagerBlockSize
+	for n := range s.blocks {
+		if n >= from {
+			delete(s.blocks, n)
+		}
+	}
+	kept := s.order[:0]
+	for _, n := range s.order {
+		if _, ok := s.blocks[n]; ok {
+			kept = append(kept, n)
+		}
+	}
+	s.order = kept
+	s.size = size
+}
+
+// block returns block n of the file, reading it if it is not cached
+func (s *pagerSource) block(n int64) ([]byte, error) {
+	if b, ok := s.blocks[n]; ok {
+		return b, nil
+	}
+
+	off := n * pagerBlockSize
+	buf := make([]byte, max(0, min(pagerBlockSize, s.size-off)))
+	read, err := s.r.ReadAt(buf, off)
+	if err != nil && !(errors.Is(err, io.EOF) && read == len(buf)) {
+		if !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		// The file shrank since its size was taken
+		return buf[:read], nil
+	}
+
+	if len(s.order) >= pagerCacheBlocks {
+		delete(s.blocks, s.order[0])
+		s.order = s.order[1:]
+	}
+	s.blocks[n] = buf
+	s.order = append(s.order, n)
+	return buf, nil
+}
+
+// bytes returns the contents of [off, end), clipped to the file size
+func (s *pagerSource) bytes(off, end int64) ([]byte, error) {
+	end = min(end, s.size)
+	if off >= end {
+		return nil, nil
+	}
+
+	out := make([]byte, 0, end-off)
+	for pos := off; pos < end; {
+		n := pos / pagerBlockSize
+		b, err := s.block(n)
+		if err != nil {
+			return nil, err
+		}
+		start := pos - n*pagerBlockSize
+		if start >= int64(len(b)) {
+			break
+		}
+		chunk := b[start:min(int64(len(b)), end-n*pagerBlockSize)]
+		out = append(out, chunk...)
+		pos += int64(len(chunk))
+	}
+	return out, nil
+}
+
+// readLines returns up to n lines starting at off
+func (s *pagerSource) readLines(off int64, n int) ([]pagerLine, error) {
+	var lines []pagerLine
+	continued := off > 0 && !s.startsLine(off)
+	for len(lines) < n && off < s.size {
+		data, err := s.bytes(off, off+pagerMaxLine)
+		if err != nil {
+			return lines, err
+		}
+		if len(data) == 0 {
+			break
+		}
+
+		line := pagerLine{offset: off, continued: continued}
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			line.text = data[:i]
+			off += int64(i) + 1
+			continued = false
+		} else {
+			line.text = data
+			off += int64(len(data))
+			continued = true
+		}
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+// startsLine reports whether off is the first byte of a line
+func (s *pagerSource) startsLine(off int64) bool {
+	if off == 0 {
+		return true
+	}
+	b, err := s.bytes(off-1, off)
+	return err == nil && len(b) == 1 && b[0] == '\n'
+}
+
+// lineStart returns the start of the line holding the byte at pos, looking
+// back at most pagerMaxLine bytes
+func (s *pagerSource) lineStart(pos int64) (int64, error) {
+	lower := max(0, pos-pagerMaxLine)
+	data, err := s.bytes(lower, pos)
+	if err != nil {
+		return 0, err
+	}
+	if i := bytes.LastIndexByte(data, '\n'); i >= 0 {
+		return lower + int64(i) + 1, nil
+	}
+	return lower, nil
+}
+
+// prevLine returns the start of the line before the one starting at off
+func (s *pagerSource) prevLine(off int64) (int64, error) {
+	if off <= 0 {
+		return 0, nil
+	}
+	return s.lineStart(off - 1)
+}
+
+// search looks for query from off to the end of the file, or backwards from
+// off to the start with backward set. It returns the offset of the match, or
+// -1. With fold set, ASCII letters match regardless of case.
+func search(ctx context.Context, r io.ReaderAt, size, off int64, query []byte, fold, backward bool) (int64, error) {
+	if fold {
+		query = bytes.Clone(query)
+		lowerASCII(query)
+	}
+	overlap := int64(len(query) - 1)
+	buf := make([]byte, pagerSearchChunk)
+
+	find := func(start int64, data []byte) int64 {
+		if fold {
+			lowerASCII(data) // the buffer is read again for the next chunk
+		}
+		var i int
+		if backward {
+			i = bytes.LastIndex(data, query)
+		} else {
+			i = bytes.Index(data, query)
+		}
+		if i < 0 {
+			return -1
+		}
+		return start + int64(i)
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return -1, err
+		}
+
+		var start, end int64
+		if backward {
+			if off <= 0 {
+				return -1, nil
+			}
+			start, end = max(0, off-pagerSearchChunk), off
+		} else {
+			if off >= size {
+				return -1, nil
+			}
+			start, end = off, min(size, off+pagerSearchChunk)
+		}
+
+		n, err := r.ReadAt(buf[:end-start], start)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return -1, err
+		}
+		if pos := find(start, buf[:n]); pos >= 0 {
+			return pos, nil
+		}
+
+		if backward {
+			if start == 0 {
+				return -1, nil
+			}
+			off = start + overlap
+		} else {
+			if end >= size || n < int(end-start) {
+				return -1, nil
+			}
+			off = end - overlap
+		}
+	}
+}
+
+// lowerASCII lowercases the ASCII letters of b in place. Other bytes are
+// left alone, so offsets into b stay offsets into the file.
+func lowerASCII(b []byte) {
+	for i, c := range b {
+		if 'A' <= c && c <= 'Z' {
+			b[i] = c + 'a' - 'A'
+		}
+	}
+}
+
+// lineIndex counts the lines of a file in the background, to number lines
+// anywhere in it. counts[i] is the number of newlines before block i.
+type lineIndex struct {
+	mu     sync.Mutex
+	counts []int64
+	done   bool
+	err    error
+}
+
+// build reads the file block by block until ctx is done, calling progress
+// now and then. Called again for a file that grew, it carries on from the
+// last block counted.
+func (x *lineIndex) build(ctx context.Context, r io.ReaderAt, size int64, progress func()) {
+	// The last block may have been partial, count it again
+	x.mu.Lock()
+	var lines int64
+	if n := len(x.counts); n > 0 {
+		lines = x.counts[n-1]
+		x.counts = x.counts[:n-1]
+	}
+	x.done = false
+	x.err = nil
+	start := int64(len(x.counts)) * pagerBlockSize
+	x.mu.Unlock()
+
+	buf := make([]byte, pagerSearchChunk)
+	for off := start; off < size; {
+		if ctx.Err() != nil {
+			return
+		}
+		n, err := r.ReadAt(buf[:min(int64(len(buf)), size-off)], off)
+		if err != nil && !errors.Is(err, io.EOF) {
+			x.mu.Lock()
+			x.err = err
+			x.mu.Unlock()
+			progress()
+			return
+		}
+		if n == 0 {
+			break
+		}
+
+		// One count per block inside the chunk
+		x.mu.Lock()
+		for b := 0; b < n; b += pagerBlockSize {
+			x.counts = append(x.counts, lines)
+			lines += int64(bytes.Count(buf[b:min(n, b+pagerBlockSize)], []byte{'\n'}))
+		}
+		x.mu.Unlock()
+		off += int64(n)
+		progress()
+	}
+
+	x.mu.Lock()
+	x.done = true
+	x.mu.Unlock()
+	progress()
+}
+
+// lineNumber returns the 1-based number of the line starting at off, or 0
+// while the index has not reached it
+func (x *lineIndex) lineNumber(s *pagerSource, off int64) int64 {
+	x.mu.Lock()
+	n := off / pagerBlockSize
+	if n >= int64(len(x.counts)) {
+		x.mu.Unlock()
+		return 0
+	}
+	base := x.counts[n]
+	x.mu.Unlock()
+
+	data, err := s.bytes(n*pagerBlockSize, off)
+	if err != nil {
+		return 0
+	}
+	return base + int64(bytes.Count(data, []byte{'\n'})) + 1
+}
+
+// progress returns how much of the file has been indexed, from 0 to 1, and
+// why counting stopped early
+func (x *lineIndex) progress(size int64) (float64, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.done || size == 0 {
+		return 1, x.err
+	}
+	return min(1, float64(int64(len(x.counts))*pagerBlockSize)/float64(size)), x.err
+}
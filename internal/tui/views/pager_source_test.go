@@ -0,0 +1,56 @@
+package views
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestSearch(t *testing.T) {
+	// Invalid UTF-8 and letters whose lowercase has another length in front of the match
+	prefix := "\xff\xfe İstanbul ẞ \x00\x80"
+	tests := []struct {
+		name     string
+		data     string
+		query    string
+		fold     bool
+		off      int64
+		backward bool
+		want     int64
+	}{
+		{"forward", "abc needle def", "needle", false, 0, false, 4},
+		{"not found", "abc", "needle", false, 0, false, -1},
+		{"case matters without fold", "NEEDLE", "needle", false, 0, false, -1},
+		{"fold", "abc NeEdLe", "needle", true, 0, false, 4},
+		{"fold after non-ASCII and invalid bytes", prefix + "NEEDLE", "needle", true, 0, false, int64(len(prefix))},
+		{"no fold after non-ASCII and invalid bytes", prefix + "needle", "needle", false, 0, false, int64(len(prefix))},
+		{"non-ASCII letters are not folded", "İ", "i̇", true, 0, false, -1},
+		{"from an offset", "needle needle", "needle", false, 1, false, 7},
+		{"backward", "needle needle x", "needle", false, 15, true, 7},
+		{"backward fold after invalid bytes", prefix + "Needle" + prefix, "needle", true, int64(2*len(prefix) + 6), true, int64(len(prefix))},
+		{"across chunks", strings.Repeat("\xff", pagerSearchChunk-3) + "NEEDLE", "needle", true, 0, false, pagerSearchChunk - 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := bytes.NewReader([]byte(tt.data))
+			got, err := search(context.Background(), r, int64(len(tt.data)), tt.off, []byte(tt.query), tt.fold, tt.backward)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLowerASCII(t *testing.T) {
+	in := []byte("AbZ İ\xff@[`{")
+	want := []byte("abz İ\xff@[`{")
+	lowerASCII(in)
+	if !bytes.Equal(in, want) {
+		t.Errorf("got %q, want %q", in, want)
+	}
+}
@@ -0,0 +1,297 @@
+package views
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/formatters"
+	"github.com/alecthomas/chroma/v2/lexers"
+	chromastyles "github.com/alecthomas/chroma/v2/styles"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/steevenmentech/bifrost/internal/sftp"
+	"github.com/steevenmentech/bifrost/internal/tui/styles"
+)
+
+const (
+	previewSize  = 32 * 1024              // bytes read from the start of a previewed file
+	previewDelay = 150 * time.Millisecond // wait for the selection to settle before reading
+	previewStyle = "dracula"              // chroma style for highlighted previews
+)
+
+// filePreview is the content shown for the selected entry in the preview pane
+type filePreview struct {
+	path    string
+	loading bool
+	isDir   bool
+	size    int64
+	binary  bool
+	data    []byte
+	lines   []string // highlighted text lines
+	entries []sftp.FileInfo
+	err     error
+}
+
+// previewTickMsg fires once the selection has stayed on an entry for previewDelay
+type previewTickMsg struct {
+	seq int
+}
+
+// previewMsg carries a loaded preview
+type previewMsg filePreview
+
+// togglePreview shows or hides the preview pane, which replaces dual-pane mode
+func (m *SFTPBrowserModel) togglePreview() tea.Cmd {
+	m.showPreview = !m.showPreview
+	m.preview = filePreview{}
+	if m.showPreview && m.dualPane {
+		m.toggleDualPane()
+	}
+	return m.schedulePreview()
+}
+
+// schedulePreview loads the preview of the selected entry once the selection settles
+func (m *SFTPBrowserModel) schedulePreview() tea.Cmd {
+	if !m.showPreview {
+		return nil
+	}
+	p := m.selectedPath()
+	if p == "" || p == m.preview.path {
+		return nil
+	}
+
+	m.previewSeq++
+	seq := m.previewSeq
+	m.preview = filePreview{path: p, loading: true}
+	return tea.Tick(previewDelay, func(time.Time) tea.Msg {
+		return previewTickMsg{seq: seq}
+	})
+}
+
+// loadPreview reads the selected entry in the background
+func (m *SFTPBrowserModel) loadPreview(msg previewTickMsg) tea.Cmd {
+	if msg.seq != m.previewSeq || m.selectedIndex >= len(m.files) || m.selectedPath() != m.preview.path {
+		return nil
+	}
+
+	file := m.files[m.selectedIndex]
+	p := m.preview.path
+	client := m.client
+	return func() tea.Msg {
+		preview := filePreview{path: p, isDir: file.IsDir || file.LinksToDir, size: file.Size}
+		if preview.isDir {
+			preview.entries, preview.err = client.ListDir(p)
+			return previewMsg(preview)
+		}
+
+		preview.data, preview.err = client.ReadHead(p, previewSize)
+		if preview.err == nil {
+			preview.binary = isBinary(preview.data)
+			if !preview.binary {
+				preview.lines = highlightLines(file.Name, preview.data)
+			}
+		}
+		return previewMsg(preview)
+	}
+}
+
+// isBinary guesses whether data is not text: it has NUL bytes or is mostly not UTF-8
+func isBinary(data []byte) bool {
+	if bytes.IndexByte(data, 0) >= 0 {
+		return true
+	}
+	invalid := 0
+	for rest := data; len(rest) > 0; {
+		r, size := utf8.DecodeRune(rest)
+		if r == utf8.RuneError && size == 1 {
+			invalid++
+		}
+		rest = rest[size:]
+	}
+	return invalid*20 > len(data)
+}
+
+// highlightLines splits text into lines colored for the language of name.
+// Text in an unknown language is returned plain.
+func highlightLines(name string, data []byte) []string {
+	text := strings.ReplaceAll(string(data), "\t", "    ")
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+
+	lexer := lexers.Match(name)
+	if lexer == nil {
+		lexer = lexers.Analyse(text)
+	}
+	if lexer == nil {
+		return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	}
+
+	iterator, err := chroma.Coalesce(lexer).Tokenise(nil, text)
+	if err != nil {
+		return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	}
+
+	formatter := formatters.TTY256
+	style := chromastyles.Get(previewStyle)
+	var lines []string
+	for _, tokens := range chroma.SplitTokensIntoLines(iterator.Tokens()) {
+		for i := range tokens {
+			tokens[i].Value = strings.TrimSuffix(tokens[i].Value, "\n")
+		}
+		var b strings.Builder
+		if err := formatter.Format(&b, style, chroma.Literator(tokens...)); err != nil {
+			return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+		}
+		lines = append(lines, b.String())
+	}
+	return lines
+}
+
+// hexDumpLines formats the first maxLines rows of a hex dump of data
+func hexDumpLines(data []byte, perLine, maxLines int) []string {
+	var lines []string
+	for off := 0; off < len(data) && len(lines) < maxLines; off += perLine {
+		chunk := data[off:min(off+perLine, len(data))]
+
+		var hexPart, text strings.Builder
+		for i := 0; i < perLine; i++ {
+			if i < len(chunk) {
+				fmt.Fprintf(&hexPart, "%02x ", chunk[i])
+			} else {
+				hexPart.WriteString("   ")
+			}
+			if i == perLine/2-1 {
+				hexPart.WriteString(" ")
+			}
+		}
+		for _, b := range chunk {
+			if b >= 0x20 && b < 0x7f {
+				text.WriteByte(b)
+			} else {
+				text.WriteByte('.')
+			}
+		}
+		lines = append(lines, styles.SubtleStyle.Render(fmt.Sprintf("%08x", off))+"  "+hexPart.String()+"|"+text.String()+"|")
+	}
+	return lines
+}
+
+// renderPreview renders the preview of the selected entry in at most height lines of width columns
+func (m *SFTPBrowserModel) renderPreview(width, height int) string {
+	p := m.preview
+	var lines []string
+	switch {
+	case p.path == "":
+		lines = []string{styles.SubtleStyle.Render("Nothing selected")}
+	case p.loading:
+		lines = []string{styles.SubtleStyle.Render("Loading...")}
+	case p.err != nil:
+		lines = []string{styles.ErrorStyle.Render(p.err.Error())}
+	case p.isDir:
+		lines = m.directorySummary(p.entries)
+	case len(p.data) == 0:
+		lines = []string{styles.SubtleStyle.Render("(empty file)")}
+	case p.binary:
+		perLine := 16
+		if width < 78 {
+			perLine = 8
+		}
+		lines = append([]string{styles.SubtleStyle.Render("Binary file")}, hexDumpLines(p.data, perLine, height)...)
+	default:
+		lines = p.lines
+	}
+
+	// Note when only the start of a large file is shown, on a line of its own
+	footer := ""
+	if !p.loading && !p.isDir && p.size > int64(len(p.data)) && len(p.data) > 0 {
+		footer = styles.SubtleStyle.Render(fmt.Sprintf("── first %s of %s ──", FormatFileSize(int64(len(p.data))), FormatFileSize(p.size)))
+		height--
+	}
+
+	// Truncate into a new slice, the highlighted lines are kept for the next render
+	out := make([]string, 0, height+1)
+	for _, line := range lines[:min(len(lines), max(0, height))] {
+		out = append(out, ansi.Truncate(line, width, "…"))
+	}
+	if footer != "" {
+		out = append(out, ansi.Truncate(footer, width, "…"))
+	}
+	return strings.Join(out, "\n")
+}
+
+// directorySummary counts the entries of a previewed directory and lists them
+func (m *SFTPBrowserModel) directorySummary(entries []sftp.FileInfo) []string {
+	var files, dirs int
+	var size int64
+	var names []string
+	for _, e := range entries {
+		if !m.showHidden && strings.HasPrefix(e.Name, ".") {
+			continue
+		}
+		if e.IsDir {
+			dirs++
+			names = append(names, " "+e.Name+"/")
+		} else {
+			files++
+			size += e.Size
+			names = append(names, " "+e.Name)
+		}
+	}
+
+	if files+dirs == 0 {
+		return []string{styles.SubtleStyle.Render("(empty directory)")}
+	}
+	summary := fmt.Sprintf("%d directories, %d files, %s", dirs, files, FormatFileSize(size))
+	return append([]string{styles.SubtleStyle.Render(summary), ""}, names...)
+}
+
+// viewPreviewPane renders the remote listing next to the preview of the selected entry
+func (m *SFTPBrowserModel) viewPreviewPane() string {
+	var s strings.Builder
+
+	s.WriteString("\n")
+	s.WriteString(m.renderTitle("SFTP Browser") + "\n\n")
+
+	if m.err != nil {
+		s.WriteString(styles.ErrorStyle.Render("  Error: "+m.err.Error()) + "\n\n")
+	}
+	if m.successMsg != "" {
+		s.WriteString(styles.SuccessStyle.Render("  "+m.successMsg) + "\n\n")
+	}
+
+	listWidth := max(30, (m.width-8)*2/5)
+	previewWidth := max(30, m.width-8-listWidth)
+	visible := m.getVisibleFileCount()
+
+	title := ""
+	if m.preview.path != "" {
+		title = path.Base(m.preview.path)
+	}
+	remote := renderPane("Remote", m.currentPath, m.renderRemoteList(listWidth, visible), listWidth, visible, true)
+	preview := renderPane("Preview", title, m.renderPreview(previewWidth, visible), previewWidth, visible, false)
+	s.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, "  ", remote, "  ", preview) + "\n\n")
+
+	helpLine1 := "  Up/Down: j/k | Page: ctrl-u/d | Parent: h | Open: l/enter | Path: g/tab | Hidden: . | Mark: space | Range: V | Mark/Unmark pattern: +/- | Invert: * | Filter: / | Sort: s/o/f | Hide preview: p"
+	helpLine2 := "  New file: n | New dir: N | Delete: d | Rename: r | Copy: C | Yank/Cut/Paste: Y/X/ctrl+p | Perms: P | Link: L | Edit: e | View: v | Follow: F | Download: D | Upload: u | Find: ctrl+f | Grep: ctrl+g | Sync: S | Watch: W | Transfers: t | Quit: q"
+	if status := m.renderTransferStatus(); status != "" {
+		s.WriteString(status + "\n")
+	}
+	if status := m.renderClipboardStatus(); status != "" {
+		s.WriteString(status + "\n")
+	}
+	if status := m.renderSelectionStatus(); status != "" {
+		s.WriteString(status + "\n")
+	}
+	if status := m.renderFilterStatus(); status != "" {
+		s.WriteString(status + "\n")
+	}
+	s.WriteString(styles.SubtleStyle.Render(helpLine1) + "\n")
+	s.WriteString(styles.SubtleStyle.Render(helpLine2) + "\n")
+
+	return s.String()
+}
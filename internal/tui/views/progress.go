@@ -0,0 +1,38 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/steevenmentech/bifrost/internal/tui/styles"
+)
+
+// waitForMsg returns a command that delivers the next message sent on ch.
+// Background operations report progress through a channel and the model
+// re-issues this command after each message until the channel is drained.
+func waitForMsg(ch <-chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		return <-ch
+	}
+}
+
+// renderProgressBar draws a fixed-width progress bar with a percentage
+func renderProgressBar(done, total int64, width int) string {
+	if width < 10 {
+		width = 10
+	}
+
+	ratio := 0.0
+	if total > 0 {
+		ratio = float64(done) / float64(total)
+	}
+	ratio = min(1, max(0, ratio))
+
+	filled := int(ratio * float64(width))
+	bar := lipgloss.NewStyle().Foreground(styles.Secondary).Render(strings.Repeat("█", filled)) +
+		lipgloss.NewStyle().Foreground(styles.Dim).Render(strings.Repeat("░", width-filled))
+
+	return fmt.Sprintf("%s %3.0f%%", bar, ratio*100)
+}
@@ -0,0 +1,119 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/steevenmentech/bifrost/internal/config"
+	"github.com/steevenmentech/bifrost/internal/ssh"
+	"github.com/steevenmentech/bifrost/internal/tui/styles"
+)
+
+// SessionSummaryModel is shown after an SSH or SFTP session ends
+type SessionSummaryModel struct {
+	conn     config.Connection
+	protocol string
+	result   *ssh.SessionResult // nil for SFTP sessions or failed connects
+	err      error
+	width    int
+	height   int
+}
+
+// NewSessionSummary creates a post-session summary screen
+func NewSessionSummary(conn config.Connection, protocol string, result *ssh.SessionResult, err error) *SessionSummaryModel {
+	return &SessionSummaryModel{
+		conn:     conn,
+		protocol: protocol,
+		result:   result,
+		err:      err,
+	}
+}
+
+// Init initializes the summary
+func (m *SessionSummaryModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles messages
+func (m *SessionSummaryModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "enter", "esc", "q", "ctrl+c":
+			return m, tea.Quit
+		}
+	}
+	return m, nil
+}
+
+// View renders the summary
+func (m *SessionSummaryModel) View() string {
+	title := styles.ModalTitleStyle.Render(fmt.Sprintf("%s session: %s", m.protocol, m.conn.Label))
+	target := styles.SubtleStyle.Render(fmt.Sprintf("%s@%s:%d", m.conn.Username, m.conn.Host, m.conn.Port))
+
+	var rows []string
+	if m.result != nil {
+		status := m.result.Status()
+		if m.result.ExitStatus == 0 && m.result.Signal == "" && !m.result.ExitMissing {
+			status = styles.SuccessStyle.Render(status)
+		} else {
+			status = lipgloss.NewStyle().Foreground(styles.Warning).Render(status)
+		}
+		rows = append(rows,
+			summaryRow("Status", status),
+			summaryRow("Duration", formatDuration(m.result.Duration)),
+			summaryRow("Sent", FormatFileSize(m.result.BytesSent)),
+			summaryRow("Received", FormatFileSize(m.result.BytesReceived)),
+		)
+	} else if m.err == nil {
+		rows = append(rows, summaryRow("Status", styles.SuccessStyle.Render("Disconnected")))
+	}
+
+	if m.err != nil {
+		rows = append(rows, summaryRow("Error", styles.ErrorStyle.Render(m.err.Error())))
+	}
+
+	help := styles.SubtleStyle.Render("Return to Bifrost: enter/esc/q")
+
+	content := lipgloss.JoinVertical(lipgloss.Left,
+		title,
+		target,
+		"",
+		strings.Join(rows, "\n"),
+		"",
+		help,
+	)
+
+	modal := styles.ModalStyle.Render(content)
+	if m.width == 0 {
+		return modal
+	}
+
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		modal,
+		lipgloss.WithWhitespaceChars(" "),
+		lipgloss.WithWhitespaceForeground(styles.Dim),
+	)
+}
+
+func summaryRow(label, value string) string {
+	return styles.SubtleStyle.Render(fmt.Sprintf("%-10s", label)) + " " + value
+}
+
+// formatDuration renders a duration rounded to the second
+func formatDuration(d time.Duration) string {
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(time.Second).String()
+}
@@ -6,6 +6,7 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/atotto/clipboard"
 	"github.com/charmbracelet/bubbles/key"
@@ -26,11 +27,56 @@ const (
 	CreateDirState
 	RenameState
 	DeleteConfirmState
+	DeletingState
+	DownloadConfirmState
+	UploadPickState
+	TransferConfirmState
+	TransfersState
+	SyncFormState
+	SyncPreviewState
+	WatchSetupState
+	WatchingState
+	PagerState
+	PermissionsState
+	LinkState
+	CopyState
+	PasteState
+	MarkGlobState
+	FilterState
+	FindFormState
+	FindResultsState
+	GrepFormState
+	GrepResultsState
+	OperationResultState
 )
 
+// treeSummaryMsg carries the contents of a directory scanned before deletion
+type treeSummaryMsg struct {
+	path    string
+	summary *sftp.TreeSummary
+	err     error
+}
+
+// deleteProgressMsg reports progress of a recursive delete
+type deleteProgressMsg sftp.DeleteProgress
+
+// deleteDoneMsg is sent when a recursive delete finishes
+type deleteDoneMsg struct {
+	name     string
+	failures []sftp.FailedEntry
+	err      error
+}
+
+// resultSection is a titled list of entries on the operation result screen
+type resultSection struct {
+	heading string
+	entries []sftp.FailedEntry
+}
+
 type SFTPBrowserModel struct {
 	client        *sftp.Client
-	files         []sftp.FileInfo
+	files         []sftp.FileInfo // entries shown, those matching the filter
+	allFiles      []sftp.FileInfo // every entry of the directory, sorted
 	selectedIndex int
 	scrollOffset  int // First visible file index for scrolling
 	currentPath   string
@@ -38,15 +84,104 @@ type SFTPBrowserModel struct {
 	pathInput     textinput.Model
 	nameInput     textinput.Model
 	showHidden    bool
+	sort          listingSort
+	sortChanged   bool   // the order differs from the one saved with the connection
+	connectionID  string // saved connection the sort order is remembered for
 	err           error
 	successMsg    string
 	width         int
 	height        int
 	keys          keys.KeyMap
 	fileToEdit    string // Path of file to edit (when quitting to edit)
+	lineToEdit    int    // Line to open fileToEdit at, 0 for the top
+
+	// Fuzzy filter of the listing, typed in FilterState
+	filter        string
+	filterInput   textinput.Model
+	filterDir     string           // directory the filter was typed in
+	filterMatches map[string][]int // matched rune indices by entry name
+
+	// Marked entries of the listing, by full path, for bulk operations
+	marked       map[string]bool
+	visual       bool // drawing a range to mark from visualAnchor to the selection
+	visualAnchor int
+	globMark     bool            // MarkGlobState marks matches, otherwise unmarks them
+	bulk         []sftp.FileInfo // marked entries a pending delete/download/chmod applies to
+
+	// Recursive operations
+	treeSummary    *sftp.TreeSummary // Contents of the directory pending delete/download
+	treeScanErr    error
+	scanKey        string // what the pending scan is for, to drop stale results
+	deleteProgress sftp.DeleteProgress
+	progressCh     chan tea.Msg // Progress of the running background operation
+
+	// Transfers
+	downloadInput     textinput.Model // Destination of a directory download
+	downloadSymlinks  sftp.SymlinkMode
+	localPicker       LocalPickerModel
+	pendingTransfer   transferJob
+	transferConflicts []transferConflict // Destinations of the pending transfer that already exist
+	transferProbing   bool               // Looking for existing destinations of the pending transfer
+	transferProbeSeq  int                // Bumped on every probe, to drop the result of an abandoned one
+
+	// Transfer queue, shared with later browser sessions on the same connection
+	transfers      *sftp.TransferManager
+	transferList   []sftp.Transfer            // Snapshot shown in the transfers panel
+	transferStates map[int]sftp.TransferState // Last seen state, to announce transfers once
+	transferIndex  int
+	quitArmed      bool // Quit was pressed once while transfers were active
+
+	// Dual-pane mode: a local pane next to the remote listing
+	dualPane   bool
+	focusLocal bool
+	localPane  LocalPaneModel
+
+	// Preview pane next to the remote listing
+	showPreview bool
+	preview     filePreview
+	previewSeq  int // Bumped on every selection change, to drop stale loads
+
+	// Full-screen pager for the selected file
+	pager *pagerModel
+
+	// Mode and ownership of the selected entry, edited in PermissionsState
+	perms permForm
+
+	// Link being created in LinkState
+	link linkForm
+
+	// Remote copy of the selected entry, destination in nameInput
+	copySource  string
+	copyRunning bool
+
+	// Entries yanked or cut for pasting elsewhere, and the paste in PasteState
+	clipboard remoteClipboard
+	paste     pasteJob
+
+	// Recursive search from the current directory, in FindFormState and FindResultsState
+	find findSearch
+
+	// Content search from the current directory, in GrepFormState and GrepResultsState
+	grep grepSearch
+
+	// Directory sync
+	syncForm    syncForm
+	syncPlan    *sftp.SyncPlan // Plan shown in SyncPreviewState, nil while comparing
+	syncPlanErr error
+	syncScroll  int
+
+	// Watch & push of a local directory
+	watchLocalInput  textinput.Model
+	watchIgnoreInput textinput.Model
+	watch            *watchSession
+
+	// Outcome of the last background operation, shown in OperationResultState
+	resultTitle    string
+	resultSections []resultSection
+	resultReturn   SFTPBrowserState // State to go back to from the result screen
 }
 
-func NewSFTPBrowser(client *sftp.Client, keymap keys.KeyMap) *SFTPBrowserModel {
+func NewSFTPBrowser(client *sftp.Client, transfers *sftp.TransferManager, keymap keys.KeyMap) *SFTPBrowserModel {
 	pathInput := textinput.New()
 	pathInput.Placeholder = "Enter path..."
 	pathInput.CharLimit = 256
@@ -57,15 +192,49 @@ func NewSFTPBrowser(client *sftp.Client, keymap keys.KeyMap) *SFTPBrowserModel {
 	nameInput.CharLimit = 256
 	nameInput.Width = 50
 
+	downloadInput := textinput.New()
+	downloadInput.Placeholder = "~/Downloads"
+	downloadInput.CharLimit = 512
+	downloadInput.Width = 80
+
+	watchLocalInput := textinput.New()
+	watchLocalInput.Placeholder = "local directory"
+	watchLocalInput.CharLimit = 512
+	watchLocalInput.Width = 80
+
+	watchIgnoreInput := textinput.New()
+	watchIgnoreInput.Placeholder = "e.g. *.map, dist/"
+	watchIgnoreInput.CharLimit = 512
+	watchIgnoreInput.Width = 80
+
+	filterInput := textinput.New()
+	filterInput.Prompt = "/"
+	filterInput.Placeholder = "filter"
+	filterInput.CharLimit = 256
+	filterInput.Width = 40
+
 	browser := &SFTPBrowserModel{
-		client:        client,
-		selectedIndex: 0,
-		currentPath:   client.GetWorkingDir(),
-		state:         BrowsingState,
-		pathInput:     pathInput,
-		nameInput:     nameInput,
-		showHidden:    false,
-		keys:          keymap,
+		client:           client,
+		selectedIndex:    0,
+		currentPath:      client.GetWorkingDir(),
+		state:            BrowsingState,
+		pathInput:        pathInput,
+		nameInput:        nameInput,
+		filterInput:      filterInput,
+		downloadInput:    downloadInput,
+		watchLocalInput:  watchLocalInput,
+		watchIgnoreInput: watchIgnoreInput,
+		showHidden:       false,
+		keys:             keymap,
+		transfers:        transfers,
+		transferStates:   make(map[int]sftp.TransferState),
+		marked:           make(map[string]bool),
+	}
+
+	// Transfers that ended before this browser opened were already announced
+	browser.transferList = transfers.Transfers()
+	for _, t := range browser.transferList {
+		browser.transferStates[t.ID] = t.State
 	}
 
 	// Load initial directory
@@ -91,8 +260,16 @@ func (m *SFTPBrowserModel) loadCurrentDirectory() {
 		files = filtered
 	}
 
-	m.files = files
+	sortFiles(files, m.sort)
+	m.allFiles = files
 	m.err = nil
+	m.pruneMarks()
+
+	// A filter stays on while the directory is refreshed, not when leaving it
+	if m.filterDir != m.currentPath {
+		m.filter = ""
+	}
+	m.applyFilter()
 
 	// Reset selection if out of bounds
 	if m.selectedIndex >= len(m.files) {
@@ -104,7 +281,11 @@ func (m *SFTPBrowserModel) loadCurrentDirectory() {
 }
 
 func (m *SFTPBrowserModel) Init() tea.Cmd {
-	return nil
+	// A browser run again after an edit picks its watch session back up
+	if m.watch != nil && m.watch.running {
+		return tea.Batch(waitForTransfers(m.transfers), waitForMsg(m.watch.events))
+	}
+	return waitForTransfers(m.transfers)
 }
 
 func (m *SFTPBrowserModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -117,6 +298,79 @@ func (m *SFTPBrowserModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Account for: left margin (2) + right margin (2) + border (2) + padding (2) + icon (3) = 11 chars
 		m.pathInput.Width = max(30, msg.Width-11)
 
+		if m.state == UploadPickState {
+			m.localPicker, _ = m.localPicker.Update(msg)
+		}
+		m.localPane.SetVisibleLines(m.getVisibleFileCount())
+		if m.pager != nil {
+			m.pager.Update(msg)
+		}
+
+		return m, nil
+	}
+
+	// Results of background operations arrive regardless of key state
+	switch msg := msg.(type) {
+	case treeSummaryMsg:
+		if (m.state == DeleteConfirmState || m.state == DownloadConfirmState) && m.scanKey == msg.path {
+			m.treeSummary = msg.summary
+			m.treeScanErr = msg.err
+		}
+		return m, nil
+	case deleteProgressMsg:
+		m.deleteProgress = sftp.DeleteProgress(msg)
+		return m, waitForMsg(m.progressCh)
+	case deleteDoneMsg:
+		return m.finishRecursiveDelete(msg)
+	case findHitMsg, findDoneMsg:
+		return m.receiveFind(msg)
+	case findDeleteMsg:
+		return m.finishDeleteHit(msg)
+	case grepMatchMsg, grepDoneMsg:
+		return m.receiveGrep(msg)
+	case transfersUpdatedMsg:
+		return m.refreshTransfers()
+	case transferProbeMsg:
+		return m.finishTransferProbe(msg)
+	case syncPlanMsg:
+		if m.state == SyncPreviewState {
+			m.syncPlan = msg.plan
+			m.syncPlanErr = msg.err
+		}
+		return m, nil
+	case previewTickMsg:
+		return m, m.loadPreview(msg)
+	case previewMsg:
+		if m.showPreview && msg.path == m.preview.path {
+			m.preview = filePreview(msg)
+		}
+		return m, nil
+	case pagerIndexMsg, pagerSearchMsg, pagerPollMsg:
+		if m.pager == nil {
+			return m, nil
+		}
+		return m, m.pager.Update(msg)
+	case pagerFollowMsg:
+		if m.pager == nil {
+			if msg.file != nil {
+				msg.file.Close()
+			}
+			return m, nil
+		}
+		return m, m.pager.Update(msg)
+	case permsDoneMsg:
+		return m.finishPermissions(msg)
+	case copyDoneMsg:
+		return m.finishCopy(msg)
+	case pasteDoneMsg:
+		return m.finishPaste(msg)
+	case watchEventMsg:
+		return m, m.recordWatchEvent(msg)
+	case watchStoppedMsg:
+		if msg.session == m.watch {
+			m.watch.running = false
+			m.watch.err = msg.err
+		}
 		return m, nil
 	}
 
@@ -131,14 +385,74 @@ func (m *SFTPBrowserModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m.updateRename(msg)
 	case DeleteConfirmState:
 		return m.updateDeleteConfirm(msg)
+	case DeletingState:
+		// Wait for the operation to finish
+		return m, nil
+	case TransfersState:
+		return m.updateTransfers(msg)
+	case DownloadConfirmState:
+		return m.updateDownloadConfirm(msg)
+	case UploadPickState:
+		return m.updateUploadPick(msg)
+	case TransferConfirmState:
+		return m.updateTransferConfirm(msg)
+	case SyncFormState:
+		return m.updateSyncForm(msg)
+	case SyncPreviewState:
+		return m.updateSyncPreview(msg)
+	case WatchSetupState:
+		return m.updateWatchSetup(msg)
+	case WatchingState:
+		return m.updateWatching(msg)
+	case PagerState:
+		return m.updatePager(msg)
+	case PermissionsState:
+		return m.updatePermissions(msg)
+	case LinkState:
+		return m.updateLinkForm(msg)
+	case CopyState:
+		return m.updateCopy(msg)
+	case PasteState:
+		return m.updatePaste(msg)
+	case MarkGlobState:
+		return m.updateMarkGlob(msg)
+	case FilterState:
+		model, cmd := m.updateFilter(msg)
+		return model, tea.Batch(cmd, m.schedulePreview())
+	case FindFormState:
+		return m.updateFindForm(msg)
+	case FindResultsState:
+		return m.updateFindResults(msg)
+	case GrepFormState:
+		return m.updateGrepForm(msg)
+	case GrepResultsState:
+		return m.updateGrepResults(msg)
+	case OperationResultState:
+		return m.updateOperationResult(msg)
 	default:
-		return m.updateBrowsing(msg)
+		model, cmd := m.updateBrowsing(msg)
+		return model, tea.Batch(cmd, m.schedulePreview())
 	}
 }
 
 func (m *SFTPBrowserModel) updateBrowsing(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if m.dualPane {
+			switch msg.String() {
+			case "tab": // Switch pane
+				m.focusLocal = !m.focusLocal
+				m.err = nil
+				m.successMsg = ""
+				return m, nil
+			case "c", "m": // Copy/move to the other pane
+				return m, m.transferToOtherPane(msg.String() == "m")
+			}
+			if m.focusLocal {
+				return m.updateLocalPane(msg)
+			}
+		}
+
 		switch {
 		case key.Matches(msg, m.keys.Up):
 			if m.selectedIndex > 0 {
@@ -195,8 +509,20 @@ func (m *SFTPBrowserModel) updateBrowsing(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.nameInput.Focus()
 			return m, textinput.Blink
 		case msg.String() == "d": // Delete
+			if m.bulk = m.markedEntries(); m.bulk != nil {
+				m.state = DeleteConfirmState
+				m.treeSummary = nil
+				m.treeScanErr = nil
+				return m, m.scanSelection()
+			}
 			if len(m.files) > 0 && m.selectedIndex < len(m.files) {
 				m.state = DeleteConfirmState
+				m.treeSummary = nil
+				m.treeScanErr = nil
+				if m.files[m.selectedIndex].IsDir {
+					// Count what would be removed before asking for confirmation
+					return m, m.scanTree(m.selectedPath())
+				}
 				return m, nil
 			}
 		case msg.String() == "r": // Rename
@@ -214,27 +540,117 @@ func (m *SFTPBrowserModel) updateBrowsing(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		case msg.String() == "e": // Edit file
 			if len(m.files) > 0 && m.selectedIndex < len(m.files) {
-				if !m.files[m.selectedIndex].IsDir {
+				if !m.files[m.selectedIndex].IsDir && !m.files[m.selectedIndex].LinksToDir {
 					// Mark file for editing and quit to let main handle it
+					m.stopSearches()
 					m.fileToEdit = path.Join(m.currentPath, m.files[m.selectedIndex].Name)
 					return m, tea.Quit
 				}
 			}
-		case msg.String() == "D": // Download file to ~/Downloads
+		case msg.String() == "D": // Download to ~/Downloads
+			if m.bulk = m.markedEntries(); m.bulk != nil {
+				return m, m.showDownloadConfirm()
+			}
 			if len(m.files) > 0 && m.selectedIndex < len(m.files) {
 				if !m.files[m.selectedIndex].IsDir {
 					m.downloadSelectedFile()
+					return m, nil
 				} else {
-					m.err = fmt.Errorf("cannot download directories")
+					return m, m.showDownloadConfirm()
 				}
 			}
+		case msg.String() == "w": // Toggle dual-pane mode
+			m.showPreview = false
+			m.toggleDualPane()
+		case msg.String() == "v": // View file in the pager
+			if len(m.files) > 0 && m.selectedIndex < len(m.files) && !m.files[m.selectedIndex].IsDir && !m.files[m.selectedIndex].LinksToDir {
+				return m, m.openPager(false)
+			}
+		case msg.String() == "F": // Follow file as it grows, like tail -f
+			if len(m.files) > 0 && m.selectedIndex < len(m.files) && !m.files[m.selectedIndex].IsDir && !m.files[m.selectedIndex].LinksToDir {
+				return m, m.openPager(true)
+			}
+		case msg.String() == "P": // Change mode and ownership
+			return m, m.showPermissions()
+		case msg.String() == "C": // Copy on the server, a backup next to it by default
+			return m, m.showCopy()
+		case msg.String() == "Y": // Yank for pasting in another directory
+			m.clipboardAdd(false)
+			return m, nil
+		case msg.String() == "X": // Cut for moving to another directory
+			m.clipboardAdd(true)
+			return m, nil
+		case msg.String() == "ctrl+p": // Paste yanked or cut entries here
+			return m, m.startPaste()
+		case msg.String() == " ": // Mark and move on
+			m.toggleMark()
+			return m, nil
+		case msg.String() == "V": // Start or mark a visual range
+			m.toggleVisual()
+			return m, nil
+		case msg.String() == "+": // Mark by pattern
+			return m, m.showMarkGlob(true)
+		case msg.String() == "-": // Unmark by pattern
+			return m, m.showMarkGlob(false)
+		case msg.String() == "s": // Next sort key
+			m.changeSort(listingSort{key: (m.sort.key + 1) % sortKeyCount, desc: m.sort.desc, mixDirs: m.sort.mixDirs})
+			return m, nil
+		case msg.String() == "o": // Reverse the sort order
+			m.changeSort(listingSort{key: m.sort.key, desc: !m.sort.desc, mixDirs: m.sort.mixDirs})
+			return m, nil
+		case msg.String() == "f": // Directories first, or among files
+			m.changeSort(listingSort{key: m.sort.key, desc: m.sort.desc, mixDirs: !m.sort.mixDirs})
+			return m, nil
+		case msg.String() == "*": // Invert marks
+			m.invertMarks()
+			return m, nil
+		case msg.String() == "/": // Fuzzy filter the listing
+			return m, m.showFilter()
+		case msg.String() == "ctrl+f": // Find below the current directory
+			return m, m.showFind()
+		case msg.String() == "ctrl+g": // Search file contents below the current directory
+			return m, m.showGrep()
+		case msg.String() == "esc": // Cancel the visual range, then the filter, marks, then the clipboard
+			switch {
+			case m.visual:
+				m.visual = false
+			case m.filter != "":
+				m.clearFilter()
+			case len(m.marked) > 0:
+				m.marked = make(map[string]bool)
+			case len(m.clipboard.paths) > 0:
+				m.clipboard = remoteClipboard{}
+				m.successMsg = "Clipboard cleared"
+			}
+			return m, nil
+		case msg.String() == "L": // Create a link, to the selected entry by default
+			return m, m.showLinkForm()
+		case msg.String() == "p": // Toggle preview pane
+			return m, m.togglePreview()
+		case msg.String() == "t": // Transfers panel
+			m.state = TransfersState
+			return m, nil
+		case msg.String() == "S": // Sync a local directory with this one
+			return m, m.showSyncForm()
+		case msg.String() == "W": // Watch a local directory and push changes here
+			return m, m.showWatchSetup()
+		case msg.String() == "u": // Upload from local filesystem
+			startDir, err := os.Getwd()
+			if err != nil {
+				startDir, _ = os.UserHomeDir()
+			}
+			m.localPicker = NewLocalPicker(fmt.Sprintf("Upload to %s", m.currentPath), startDir)
+			m.localPicker, _ = m.localPicker.Update(tea.WindowSizeMsg{Width: m.width, Height: m.height})
+			m.state = UploadPickState
+			return m, nil
 		case key.Matches(msg, m.keys.Quit):
-			return m, tea.Quit
+			return m, m.quit()
 		}
 
 		// Clear messages after showing
 		m.err = nil
 		m.successMsg = ""
+		m.quitArmed = false
 
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
@@ -378,13 +794,27 @@ func (m *SFTPBrowserModel) updateDeleteConfirm(msg tea.Msg) (tea.Model, tea.Cmd)
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "y", "Y": // Confirm delete
+			if m.bulk != nil {
+				if m.treeSummary == nil && m.treeScanErr == nil {
+					return m, nil
+				}
+				return m, m.startBulkDelete()
+			}
 			if m.selectedIndex < len(m.files) {
-				itemPath := path.Join(m.currentPath, m.files[m.selectedIndex].Name)
-				err := m.client.Delete(itemPath)
+				selected := m.files[m.selectedIndex]
+				if selected.IsDir {
+					if m.treeSummary == nil && m.treeScanErr == nil {
+						// Still scanning, don't confirm blind
+						return m, nil
+					}
+					return m, m.startRecursiveDelete(selected.Name)
+				}
+
+				err := m.client.Delete(m.selectedPath())
 				if err != nil {
 					m.err = err
 				} else {
-					m.successMsg = fmt.Sprintf("Deleted: %s", m.files[m.selectedIndex].Name)
+					m.successMsg = fmt.Sprintf("Deleted: %s", selected.Name)
 					m.loadCurrentDirectory()
 				}
 			}
@@ -392,6 +822,7 @@ func (m *SFTPBrowserModel) updateDeleteConfirm(msg tea.Msg) (tea.Model, tea.Cmd)
 			return m, nil
 		case "n", "N", "esc": // Cancel delete
 			m.state = BrowsingState
+			m.bulk = nil
 			return m, nil
 		}
 	}
@@ -399,6 +830,81 @@ func (m *SFTPBrowserModel) updateDeleteConfirm(msg tea.Msg) (tea.Model, tea.Cmd)
 	return m, nil
 }
 
+func (m *SFTPBrowserModel) updateOperationResult(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "enter", "esc", "q":
+			m.state = m.resultReturn
+			m.resultReturn = BrowsingState
+			m.resultTitle = ""
+			m.resultSections = nil
+		}
+	}
+	return m, nil
+}
+
+// scanTree counts the contents of a directory in the background
+func (m *SFTPBrowserModel) scanTree(dirPath string) tea.Cmd {
+	m.scanKey = dirPath
+	client := m.client
+	return func() tea.Msg {
+		summary, err := client.SummarizeTree(dirPath)
+		return treeSummaryMsg{path: dirPath, summary: summary, err: err}
+	}
+}
+
+// startRecursiveDelete deletes the named directory in the background, reporting progress
+func (m *SFTPBrowserModel) startRecursiveDelete(name string) tea.Cmd {
+	itemPath := path.Join(m.currentPath, name)
+	ch := make(chan tea.Msg, 16)
+	m.progressCh = ch
+	m.deleteProgress = sftp.DeleteProgress{}
+	m.state = DeletingState
+
+	client := m.client
+	go func() {
+		failures, err := client.DeleteRecursive(itemPath, func(p sftp.DeleteProgress) {
+			// Drop updates if the UI is behind; the next one supersedes it
+			select {
+			case ch <- deleteProgressMsg(p):
+			default:
+			}
+		})
+		ch <- deleteDoneMsg{name: name, failures: failures, err: err}
+	}()
+
+	return waitForMsg(ch)
+}
+
+// finishRecursiveDelete shows the outcome of a recursive delete
+func (m *SFTPBrowserModel) finishRecursiveDelete(msg deleteDoneMsg) (tea.Model, tea.Cmd) {
+	m.progressCh = nil
+	m.loadCurrentDirectory()
+
+	switch {
+	case msg.err != nil:
+		m.err = msg.err
+		m.state = BrowsingState
+	case len(msg.failures) > 0:
+		m.resultTitle = fmt.Sprintf("%d entries could not be deleted", len(msg.failures))
+		m.resultSections = []resultSection{{heading: "Failed", entries: msg.failures}}
+		m.state = OperationResultState
+	default:
+		m.successMsg = fmt.Sprintf("Deleted: %s", msg.name)
+		m.state = BrowsingState
+	}
+
+	return m, nil
+}
+
+// selectedPath returns the full remote path of the selected entry
+func (m *SFTPBrowserModel) selectedPath() string {
+	if m.selectedIndex >= len(m.files) {
+		return ""
+	}
+	return path.Join(m.currentPath, m.files[m.selectedIndex].Name)
+}
+
 func (m *SFTPBrowserModel) goToParentDirectory() {
 	if m.currentPath == "/" {
 		return
@@ -419,8 +925,8 @@ func (m *SFTPBrowserModel) enterSelected() {
 	}
 
 	selected := m.files[m.selectedIndex]
-	if selected.IsDir {
-		// Enter directory
+	if selected.IsDir || selected.LinksToDir {
+		// Enter directory, or follow a link to one
 		newPath := path.Join(m.currentPath, selected.Name)
 		err := m.client.ChangeDir(newPath)
 		if err == nil {
@@ -433,6 +939,81 @@ func (m *SFTPBrowserModel) enterSelected() {
 	// For files, we'll handle download/edit in PYC-23
 }
 
+// toggleDualPane switches between the remote listing and the local/remote commander
+func (m *SFTPBrowserModel) toggleDualPane() {
+	m.dualPane = !m.dualPane
+	m.focusLocal = false
+	if !m.dualPane {
+		return
+	}
+
+	if m.localPane.CurrentDir() == "" {
+		startDir, err := os.Getwd()
+		if err != nil {
+			startDir, _ = os.UserHomeDir()
+		}
+		m.localPane = NewLocalPane(startDir)
+	} else {
+		m.localPane.Reload()
+	}
+	m.localPane.SetVisibleLines(m.getVisibleFileCount())
+}
+
+// updateLocalPane handles keys while the local pane has focus
+func (m *SFTPBrowserModel) updateLocalPane(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if key.Matches(msg, m.keys.Quit) {
+		return m, m.quit()
+	}
+
+	m.err = nil
+	m.successMsg = ""
+	m.quitArmed = false
+
+	switch msg.String() {
+	case "t":
+		m.state = TransfersState
+		return m, nil
+	case "S":
+		return m, m.showSyncForm()
+	case "W":
+		return m, m.showWatchSetup()
+	case "w":
+		m.toggleDualPane()
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.localPane, cmd = m.localPane.Update(msg)
+	return m, cmd
+}
+
+// transferToOtherPane copies or moves the selection of the focused pane into the other pane's directory
+func (m *SFTPBrowserModel) transferToOtherPane(move bool) tea.Cmd {
+	if m.focusLocal {
+		sources := m.localPane.Selected()
+		if len(sources) == 0 {
+			return nil
+		}
+		job := m.uploadJob(sources, move)
+		job.symlinks = sftp.SymlinkPreserve
+		return m.queueTransfer(job)
+	}
+
+	if len(m.files) == 0 || m.selectedIndex >= len(m.files) {
+		return nil
+	}
+	entries := m.markedEntries()
+	if entries == nil {
+		entries = []sftp.FileInfo{m.files[m.selectedIndex]}
+	}
+	job := transferJob{move: move, symlinks: sftp.SymlinkPreserve}
+	for _, f := range entries {
+		job.items = append(job.items, transferItem{source: path.Join(m.currentPath, f.Name), dest: filepath.Join(m.localPane.CurrentDir(), f.Name)})
+	}
+	m.marked = make(map[string]bool)
+	return m.queueTransfer(job)
+}
+
 func (m *SFTPBrowserModel) copyToClipboard(text string) {
 	err := clipboard.WriteAll(text)
 	if err != nil {
@@ -440,15 +1021,17 @@ func (m *SFTPBrowserModel) copyToClipboard(text string) {
 	}
 }
 
-// downloadSelectedFile downloads the selected file to ~/Downloads
+// downloadSelectedFile queues a download of the selected file to ~/Downloads
 func (m *SFTPBrowserModel) downloadSelectedFile() {
 	if m.selectedIndex >= len(m.files) {
 		return
 	}
 
-	file := m.files[m.selectedIndex]
-	remotePath := path.Join(m.currentPath, file.Name)
+	m.downloadToDownloads(m.selectedPath())
+}
 
+// downloadToDownloads queues the download of a remote file or directory into ~/Downloads
+func (m *SFTPBrowserModel) downloadToDownloads(remotePath string) {
 	// Get home directory and build Downloads path
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
@@ -463,19 +1046,25 @@ func (m *SFTPBrowserModel) downloadSelectedFile() {
 		return
 	}
 
-	localPath := filepath.Join(downloadsDir, file.Name)
-
 	// Check if file already exists and add suffix if needed
-	localPath = m.getUniqueFilePath(localPath)
+	localPath := m.getUniqueFilePath(filepath.Join(downloadsDir, path.Base(remotePath)))
 
-	// Download the file
-	err = m.client.DownloadFile(remotePath, localPath)
-	if err != nil {
-		m.err = fmt.Errorf("download failed: %w", err)
-		return
-	}
+	m.enqueueJob(transferJob{
+		symlinks: sftp.SymlinkFollow,
+		items:    []transferItem{{source: remotePath, dest: localPath}},
+	})
+}
 
-	m.successMsg = fmt.Sprintf("Downloaded: %s", filepath.Base(localPath))
+// quit exits the browser, asking for a second q while transfers are still active
+func (m *SFTPBrowserModel) quit() tea.Cmd {
+	running, queued := m.activeTransfers()
+	if running+queued > 0 && !m.quitArmed {
+		m.quitArmed = true
+		m.err = fmt.Errorf("%d transfers still active, press q again to cancel them and quit", running+queued)
+		return nil
+	}
+	m.stopSearches()
+	return tea.Quit
 }
 
 // getUniqueFilePath returns a unique file path by adding (1), (2), etc. if file exists
@@ -529,6 +1118,18 @@ func (m *SFTPBrowserModel) getVisibleFileCount() int {
 	if m.err != nil || m.successMsg != "" {
 		reservedLines += 2
 	}
+	if running, queued := m.activeTransfers(); running+queued > 0 {
+		reservedLines++
+	}
+	if len(m.clipboard.paths) > 0 {
+		reservedLines++
+	}
+	if m.renderSelectionStatus() != "" {
+		reservedLines++
+	}
+	if m.state == FilterState || m.filter != "" {
+		reservedLines++
+	}
 
 	availableLines := m.height - reservedLines
 	if availableLines < 1 {
@@ -542,6 +1143,20 @@ func (m *SFTPBrowserModel) GetFileToEdit() string {
 	return m.fileToEdit
 }
 
+// GetLineToEdit returns the line to open the file to edit at, 0 for the top
+func (m *SFTPBrowserModel) GetLineToEdit() int {
+	return m.lineToEdit
+}
+
+// ResumeAfterEdit readies the browser to run again once the file it quit
+// for was edited, with the listing refreshed and search results kept
+func (m *SFTPBrowserModel) ResumeAfterEdit() {
+	m.fileToEdit = ""
+	m.lineToEdit = 0
+	m.loadCurrentDirectory()
+	m.adjustScroll()
+}
+
 func (m *SFTPBrowserModel) View() string {
 	switch m.state {
 	case CreateFileState:
@@ -552,7 +1167,54 @@ func (m *SFTPBrowserModel) View() string {
 		return m.viewRename()
 	case DeleteConfirmState:
 		return m.viewDeleteConfirm()
+	case DeletingState:
+		return m.viewDeleting()
+	case DownloadConfirmState:
+		return m.viewDownloadConfirm()
+	case UploadPickState:
+		return m.localPicker.View()
+	case TransferConfirmState:
+		return m.viewTransferConfirm()
+	case TransfersState:
+		return m.viewTransfers()
+	case SyncFormState:
+		return m.viewSyncForm()
+	case SyncPreviewState:
+		return m.viewSyncPreview()
+	case WatchSetupState:
+		return m.viewWatchSetup()
+	case WatchingState:
+		return m.viewWatching()
+	case PagerState:
+		return m.pager.View()
+	case PermissionsState:
+		return m.viewPermissions()
+	case LinkState:
+		return m.viewLinkForm()
+	case CopyState:
+		return m.viewCopy()
+	case PasteState:
+		return m.viewPaste()
+	case MarkGlobState:
+		return m.viewMarkGlob()
+	case FindFormState:
+		return m.viewFindForm()
+	case FindResultsState:
+		return m.viewFindResults()
+	case GrepFormState:
+		return m.viewGrepForm()
+	case GrepResultsState:
+		return m.viewGrepResults()
+	case OperationResultState:
+		return m.viewOperationResult()
 	default:
+		listing := m.state == BrowsingState || m.state == FilterState
+		if m.dualPane && listing {
+			return m.viewDualPane()
+		}
+		if m.showPreview && listing {
+			return m.viewPreviewPane()
+		}
 		// GoToPathState and BrowsingState use the same view (inline editing)
 		return m.viewBrowsing()
 	}
@@ -590,26 +1252,96 @@ func (m *SFTPBrowserModel) viewRename() string {
 }
 
 func (m *SFTPBrowserModel) viewDeleteConfirm() string {
+	if m.bulk != nil {
+		return m.viewBulkDeleteConfirm()
+	}
 	var s strings.Builder
 	s.WriteString("\n\n")
 	if m.selectedIndex < len(m.files) {
+		selected := m.files[m.selectedIndex]
 		itemType := "file"
-		if m.files[m.selectedIndex].IsDir {
+		switch {
+		case selected.IsLink:
+			itemType = "symlink"
+		case selected.IsDir:
 			itemType = "directory"
 		}
-		s.WriteString(styles.TitleStyle.Render(fmt.Sprintf("  Delete %s: %s", itemType, m.files[m.selectedIndex].Name)) + "\n\n")
+		s.WriteString(styles.TitleStyle.Render(fmt.Sprintf("  Delete %s: %s", itemType, selected.Name)) + "\n\n")
+
+		if selected.IsDir {
+			s.WriteString(m.renderTreeSummary() + "\n\n")
+		}
+
 		s.WriteString("  Are you sure? This action cannot be undone.\n\n")
 		s.WriteString(styles.SubtleStyle.Render("  Confirm: y | Cancel: n/esc") + "\n")
 	}
 	return s.String()
 }
 
+func (m *SFTPBrowserModel) viewDeleting() string {
+	var s strings.Builder
+	p := m.deleteProgress
+	s.WriteString("\n\n")
+	s.WriteString(styles.TitleStyle.Render("  Deleting...") + "\n\n")
+	s.WriteString("  " + renderProgressBar(int64(p.Done), int64(p.Total), min(50, m.width-20)) + "\n")
+	s.WriteString(styles.SubtleStyle.Render(fmt.Sprintf("  %d / %d entries", p.Done, p.Total)) + "\n\n")
+	if p.Current != "" {
+		s.WriteString(styles.SubtleStyle.Render("  "+p.Current) + "\n")
+	}
+	return s.String()
+}
+
+func (m *SFTPBrowserModel) viewOperationResult() string {
+	var s strings.Builder
+	s.WriteString("\n\n")
+	s.WriteString(styles.TitleStyle.Render("  "+m.resultTitle) + "\n\n")
+
+	// Keep the lists within the screen
+	remaining := max(len(m.resultSections), m.height-8)
+	for _, section := range m.resultSections {
+		if len(section.entries) == 0 {
+			continue
+		}
+		s.WriteString(styles.ErrorStyle.Render(fmt.Sprintf("  %s (%d)", section.heading, len(section.entries))) + "\n")
+		remaining--
+		for i, e := range section.entries {
+			if remaining <= 1 {
+				s.WriteString(styles.SubtleStyle.Render(fmt.Sprintf("    ... and %d more", len(section.entries)-i)) + "\n")
+				break
+			}
+			s.WriteString(fmt.Sprintf("    %s  %s\n", e.Path, styles.SubtleStyle.Render(e.Err.Error())))
+			remaining--
+		}
+		s.WriteString("\n")
+	}
+
+	s.WriteString(styles.SubtleStyle.Render("  Back: enter/esc") + "\n")
+	return s.String()
+}
+
+// renderTreeSummary describes the scanned contents of the selected directory
+func (m *SFTPBrowserModel) renderTreeSummary() string {
+	switch {
+	case m.treeScanErr != nil:
+		return styles.ErrorStyle.Render("  Could not scan directory: " + m.treeScanErr.Error())
+	case m.treeSummary == nil:
+		return styles.SubtleStyle.Render("  Scanning directory...")
+	}
+
+	sum := m.treeSummary
+	line := fmt.Sprintf("  Contains %d files, %d directories", sum.Files, sum.Dirs)
+	if sum.Symlinks > 0 {
+		line += fmt.Sprintf(", %d symlinks", sum.Symlinks)
+	}
+	return line + fmt.Sprintf(" - %s total", FormatFileSize(sum.TotalSize))
+}
+
 func (m *SFTPBrowserModel) viewBrowsing() string {
 	var s strings.Builder
 
 	// Title
 	s.WriteString("\n")
-	s.WriteString(styles.TitleStyle.Render("  SFTP Browser") + "\n")
+	s.WriteString(m.renderTitle("SFTP Browser") + "\n")
 
 	// Always show path in a search box style
 	var pathContent string
@@ -696,7 +1428,7 @@ func (m *SFTPBrowserModel) viewBrowsing() string {
 
 		// Render visible files
 		for i := m.scrollOffset; i < endIndex; i++ {
-			line := m.renderFileLine(m.files[i], i == m.selectedIndex)
+			line := m.renderFileLine(m.files[i], i == m.selectedIndex, m.isMarked(i))
 			s.WriteString(line + "\n")
 		}
 
@@ -711,8 +1443,21 @@ func (m *SFTPBrowserModel) viewBrowsing() string {
 	s.WriteString("\n")
 
 	// All commands in 2 lines with lazygit-style format
-	helpLine1 := "  Up/Down: j/k | Page: ctrl-u/d | Bottom: G | Parent: h | Open: l/enter | Path: g/tab | Home: ~ | Hidden: ."
-	helpLine2 := "  New file: n | New dir: N | Delete: d | Rename: r | Edit: e | Download: D | Copy path: y | Quit: q"
+	helpLine1 := "  Up/Down: j/k | Page: ctrl-u/d | Bottom: G | Parent: h | Open: l/enter | Path: g/tab | Home: ~ | Hidden: . | Mark: space | Range: V | Mark/Unmark pattern: +/- | Invert: * | Filter: / | Sort: s | Reverse: o | Dirs first: f"
+	helpLine2 := "  New file: n | New dir: N | Delete: d | Rename: r | Copy: C | Yank/Cut/Paste: Y/X/ctrl+p | Perms: P | Link: L | Edit: e | View: v | Follow: F | Download: D | Upload: u | Sync: S | Watch: W | Transfers: t | Find: ctrl+f | Grep: ctrl+g | Copy path: y | Dual pane: w | Preview: p | Quit: q"
+
+	if status := m.renderTransferStatus(); status != "" {
+		s.WriteString(status + "\n")
+	}
+	if status := m.renderClipboardStatus(); status != "" {
+		s.WriteString(status + "\n")
+	}
+	if status := m.renderSelectionStatus(); status != "" {
+		s.WriteString(status + "\n")
+	}
+	if status := m.renderFilterStatus(); status != "" {
+		s.WriteString(status + "\n")
+	}
 
 	s.WriteString(styles.SubtleStyle.Render(helpLine1) + "\n")
 	s.WriteString(styles.SubtleStyle.Render(helpLine2) + "\n")
@@ -720,6 +1465,83 @@ func (m *SFTPBrowserModel) viewBrowsing() string {
 	return s.String()
 }
 
+// viewDualPane renders the local and remote panes side by side
+func (m *SFTPBrowserModel) viewDualPane() string {
+	var s strings.Builder
+
+	s.WriteString("\n")
+	s.WriteString(m.renderTitle("SFTP Browser - Local / Remote") + "\n\n")
+
+	if m.err != nil {
+		s.WriteString(styles.ErrorStyle.Render("  Error: "+m.err.Error()) + "\n\n")
+	}
+	if m.successMsg != "" {
+		s.WriteString(styles.SuccessStyle.Render("  "+m.successMsg) + "\n\n")
+	}
+
+	// Two bordered panes with a two column gap and margin
+	paneWidth := max(30, (m.width-8)/2)
+	visible := m.getVisibleFileCount()
+
+	local := renderPane("Local", m.localPane.CurrentDir(), m.localPane.RenderList(paneWidth, m.focusLocal), paneWidth, visible, m.focusLocal)
+	remote := renderPane("Remote", m.currentPath, m.renderRemoteList(paneWidth, visible), paneWidth, visible, !m.focusLocal)
+	s.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, "  ", local, "  ", remote) + "\n\n")
+
+	helpLine1 := "  Switch pane: tab | Up/Down: j/k | Parent: h | Open: l | Mark: space | Filter: / | Copy to other pane: c | Move: m"
+	helpLine2 := "  Remote: New file: n | New dir: N | Delete: d | Rename: r | Copy: C | Yank/Cut/Paste: Y/X/ctrl+p | Perms: P | Link: L | Edit: e | Hidden: . | Sort: s/o/f | Find: ctrl+f | Grep: ctrl+g | Sync: S | Watch: W | Transfers: t | Single pane: w | Quit: q"
+	if status := m.renderTransferStatus(); status != "" {
+		s.WriteString(status + "\n")
+	}
+	if status := m.renderClipboardStatus(); status != "" {
+		s.WriteString(status + "\n")
+	}
+	if status := m.renderSelectionStatus(); status != "" {
+		s.WriteString(status + "\n")
+	}
+	if status := m.renderFilterStatus(); status != "" {
+		s.WriteString(status + "\n")
+	}
+	s.WriteString(styles.SubtleStyle.Render(helpLine1) + "\n")
+	s.WriteString(styles.SubtleStyle.Render(helpLine2) + "\n")
+
+	return s.String()
+}
+
+// renderRemoteList renders the visible remote entries for the dual-pane view
+func (m *SFTPBrowserModel) renderRemoteList(width, visible int) string {
+	if len(m.files) == 0 {
+		return styles.SubtleStyle.Render("(empty directory)") + "\n"
+	}
+
+	var s strings.Builder
+	end := min(m.scrollOffset+visible, len(m.files))
+	for i := m.scrollOffset; i < end; i++ {
+		f := m.files[i]
+		s.WriteString(renderPaneLine(linkLabel(f, width), f.Size, f.IsDir || f.LinksToDir, m.isMarked(i), i == m.selectedIndex, !m.focusLocal, width, m.filterMatches[f.Name]) + "\n")
+	}
+	return s.String()
+}
+
+// renderPane draws a bordered pane with a header line above its list
+func renderPane(label, dir, list string, width, visible int, focused bool) string {
+	borderColor := styles.Dim
+	labelStyle := styles.SubtleStyle
+	if focused {
+		borderColor = styles.Primary
+		labelStyle = styles.ModalTitleStyle
+	}
+
+	header := labelStyle.Render(label+": ") + truncateText(dir, max(10, width-len(label)-2))
+	body := header + "\n" + strings.TrimSuffix(list, "\n")
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(borderColor).
+		Width(width).
+		Height(visible + 1).
+		Render(body)
+}
+
 func (m *SFTPBrowserModel) renderBreadcrumb() string {
 	parts := strings.Split(m.currentPath, "/")
 	if len(parts) == 0 || (len(parts) == 1 && parts[0] == "") {
@@ -728,34 +1550,57 @@ func (m *SFTPBrowserModel) renderBreadcrumb() string {
 	return strings.Join(parts, " / ")
 }
 
-func (m *SFTPBrowserModel) renderFileLine(file sftp.FileInfo, isSelected bool) string {
+func (m *SFTPBrowserModel) renderFileLine(file sftp.FileInfo, isSelected, isMarked bool) string {
 	// Icon (using Nerd Font icons)
 	icon := "\uf15b" // File icon
-	if file.IsDir {
+	switch {
+	case file.LinkBroken:
+		icon = "\uf127" // Broken link icon
+	case file.IsLink:
+		icon = "\uf0c1" // Link icon
+	case file.IsDir:
 		icon = "\uf07c" // Folder icon
 	}
 
 	// Format size
-	sizeStr := formatFileSize(file.Size)
-	if file.IsDir {
+	sizeStr := FormatFileSize(file.Size)
+	if file.IsDir || file.LinksToDir {
 		sizeStr = "-"
 	}
 
 	// Format line
-	line := fmt.Sprintf("%s  %-40s  %10s  %s",
+	label := linkLabel(file, 40)
+	line := fmt.Sprintf("%s  %-40s  %10s  %s  %-10s %-10s",
 		icon,
-		file.Name,
+		label,
 		sizeStr,
 		file.Permissions,
+		truncateText(file.Owner, 10),
+		truncateText(file.Group, 10),
 	)
 
-	if isSelected {
-		return styles.SelectedStyle.Render("  " + line)
+	mark := "  "
+	if isMarked {
+		mark = "* "
+	}
+
+	style := styles.ItemStyle
+	switch {
+	case isSelected:
+		style = styles.SelectedStyle
+	case isMarked:
+		style = markedStyle
+	case file.LinkBroken:
+		style = brokenLinkStyle
+	case file.IsLink:
+		style = linkStyle
 	}
-	return styles.ItemStyle.Render("  " + line)
+	matched := matchIndices(m.filterMatches[file.Name], file.Name, label, utf8.RuneCountInString(mark+icon+"  "))
+	return renderMatched(mark+line, matched, style)
 }
 
-func formatFileSize(size int64) string {
+// FormatFileSize formats a byte count with binary units, like 1.5 MB
+func FormatFileSize(size int64) string {
 	const unit = 1024
 	if size < unit {
 		return fmt.Sprintf("%d B", size)
@@ -0,0 +1,323 @@
+package views
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/steevenmentech/bifrost/internal/sftp"
+	"github.com/steevenmentech/bifrost/internal/tui/styles"
+)
+
+// remoteClipboard holds entries yanked or cut in the browser, to be pasted
+// into another directory
+type remoteClipboard struct {
+	paths []string
+	cut   bool
+}
+
+// pasteResolution is what to do with entries whose name is taken in the
+// destination directory
+type pasteResolution int
+
+const (
+	pasteOverwrite pasteResolution = iota
+	pasteSkip
+	pasteRename    // paste under a free name like "name (2)"
+	pasteKeepNewer // overwrite only entries older than the pasted one
+)
+
+// pasteItem is one clipboard entry and where it goes
+type pasteItem struct {
+	src      string
+	dst      string
+	info     *sftp.FileInfo // the clipboard entry
+	existing *sftp.FileInfo // what is already at dst, if anything
+	beside   bool           // copied into its own directory, always under a free name
+}
+
+// pasteJob is a paste waiting for a conflict decision or running
+type pasteJob struct {
+	dir       string
+	cut       bool
+	items     []pasteItem
+	conflicts int
+	running   bool
+}
+
+// pasteDoneMsg is sent when a paste finishes
+type pasteDoneMsg struct {
+	cut      bool
+	done     int
+	skipped  int
+	failures []sftp.FailedEntry
+}
+
+// clipboardAdd puts the marked entries on the clipboard, or the selected
+// entry, which is taken off instead if it is already there. Switching between
+// yank and cut starts a new clipboard.
+func (m *SFTPBrowserModel) clipboardAdd(cut bool) {
+	if m.selectedIndex >= len(m.files) {
+		return
+	}
+	if m.clipboard.cut != cut {
+		m.clipboard = remoteClipboard{cut: cut}
+	}
+	verb := "Yanked"
+	if cut {
+		verb = "Cut"
+	}
+
+	if marked := m.markedEntries(); marked != nil {
+		for _, f := range marked {
+			p := path.Join(m.currentPath, f.Name)
+			if !slices.Contains(m.clipboard.paths, p) {
+				m.clipboard.paths = append(m.clipboard.paths, p)
+			}
+		}
+		m.marked = make(map[string]bool)
+		m.successMsg = fmt.Sprintf("%s %d marked entries (%d on the clipboard, paste: ctrl+p)", verb, len(marked), len(m.clipboard.paths))
+		return
+	}
+
+	p := m.selectedPath()
+	if i := slices.Index(m.clipboard.paths, p); i >= 0 {
+		m.clipboard.paths = slices.Delete(m.clipboard.paths, i, i+1)
+		verb = "Removed"
+	} else {
+		m.clipboard.paths = append(m.clipboard.paths, p)
+	}
+	m.successMsg = fmt.Sprintf("%s %s (%d on the clipboard, paste: ctrl+p)", verb, path.Base(p), len(m.clipboard.paths))
+}
+
+// startPaste pastes the clipboard into the current directory, asking first
+// when names are already taken there
+func (m *SFTPBrowserModel) startPaste() tea.Cmd {
+	if len(m.clipboard.paths) == 0 {
+		m.err = fmt.Errorf("the clipboard is empty, yank with Y or cut with X first")
+		return nil
+	}
+
+	job := pasteJob{dir: m.currentPath, cut: m.clipboard.cut}
+	for _, src := range m.clipboard.paths {
+		item := pasteItem{src: src, dst: path.Join(m.currentPath, path.Base(src))}
+		info, err := m.client.Lstat(src)
+		if err != nil {
+			m.err = fmt.Errorf("%s is gone: %w", src, err)
+			return nil
+		}
+		item.info = info
+		if item.dst != src {
+			if existing, err := m.client.Lstat(item.dst); err == nil {
+				item.existing = existing
+				job.conflicts++
+			}
+		} else if !job.cut {
+			// Copying an entry next to itself needs a new name, whatever
+			// is decided for the conflicts, so it is not one of them
+			item.beside = true
+		}
+		job.items = append(job.items, item)
+	}
+
+	m.paste = job
+	m.state = PasteState
+	if job.conflicts > 0 {
+		return nil
+	}
+	return m.runPaste(pasteOverwrite)
+}
+
+func (m *SFTPBrowserModel) updatePaste(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if m.paste.running {
+		return m, nil
+	}
+
+	if keyMsg, ok := msg.(tea.KeyMsg); ok {
+		switch keyMsg.String() {
+		case "y", "Y":
+			return m, m.runPaste(pasteOverwrite)
+		case "s", "S":
+			return m, m.runPaste(pasteSkip)
+		case "r", "R":
+			return m, m.runPaste(pasteRename)
+		case "k", "K":
+			return m, m.runPaste(pasteKeepNewer)
+		case "n", "N", "esc":
+			m.state = BrowsingState
+		}
+	}
+	return m, nil
+}
+
+// runPaste moves or copies every item in the background, settling name
+// conflicts with resolution
+func (m *SFTPBrowserModel) runPaste(resolution pasteResolution) tea.Cmd {
+	job := m.paste
+	m.paste.running = true
+	client := m.client
+
+	return func() tea.Msg {
+		result := pasteDoneMsg{cut: job.cut}
+		fail := func(p string, err error) {
+			result.failures = append(result.failures, sftp.FailedEntry{Path: p, Err: err})
+		}
+
+		for _, item := range job.items {
+			if job.cut && item.dst == item.src {
+				result.skipped++ // already here
+				continue
+			}
+			if item.info.IsDir && strings.HasPrefix(item.dst, item.src+"/") {
+				fail(item.src, fmt.Errorf("cannot paste a directory into itself"))
+				continue
+			}
+
+			replace := false
+			if item.beside {
+				name, err := client.FreeName(job.dir, path.Base(item.dst), item.info.IsDir)
+				if err != nil {
+					fail(item.src, err)
+					continue
+				}
+				item.dst = path.Join(job.dir, name)
+			} else if item.existing != nil {
+				switch resolution {
+				case pasteSkip:
+					result.skipped++
+					continue
+				case pasteKeepNewer:
+					if !item.info.ModTime.After(item.existing.ModTime) {
+						result.skipped++
+						continue
+					}
+					replace = true
+				case pasteRename:
+					name, err := client.FreeName(job.dir, path.Base(item.dst), item.info.IsDir)
+					if err != nil {
+						fail(item.src, err)
+						continue
+					}
+					item.dst = path.Join(job.dir, name)
+				default:
+					replace = true
+				}
+			}
+
+			var err error
+			switch {
+			case replace:
+				err = client.Replace(item.src, item.dst, job.cut)
+			case job.cut:
+				err = client.Rename(item.src, item.dst)
+			default:
+				_, err = client.Copy(item.src, item.dst)
+			}
+			if err != nil {
+				fail(item.src, err)
+				continue
+			}
+			result.done++
+		}
+		return result
+	}
+}
+
+// finishPaste reports a paste. Cut entries that were moved leave the
+// clipboard; yanked ones stay to be pasted again.
+func (m *SFTPBrowserModel) finishPaste(msg pasteDoneMsg) (tea.Model, tea.Cmd) {
+	m.paste.running = false
+	if msg.cut {
+		failed := make(map[string]bool, len(msg.failures))
+		for _, f := range msg.failures {
+			failed[f.Path] = true
+		}
+		var left []string
+		for _, p := range m.clipboard.paths {
+			if failed[p] {
+				left = append(left, p)
+			}
+		}
+		m.clipboard.paths = left
+	}
+
+	verb := "Copied"
+	if msg.cut {
+		verb = "Moved"
+	}
+	summary := fmt.Sprintf("%s %d entries", verb, msg.done)
+	if msg.done == 1 {
+		summary = fmt.Sprintf("%s 1 entry", verb)
+	}
+	if msg.skipped > 0 {
+		summary += fmt.Sprintf(", skipped %d", msg.skipped)
+	}
+
+	if len(msg.failures) > 0 {
+		m.resultTitle = fmt.Sprintf("%s, %d failed", summary, len(msg.failures))
+		m.resultSections = []resultSection{{heading: "Failed", entries: msg.failures}}
+		m.resultReturn = BrowsingState
+		m.state = OperationResultState
+		m.successMsg = ""
+	} else {
+		m.successMsg = summary
+		m.state = BrowsingState
+	}
+	m.loadCurrentDirectory()
+	return m, nil
+}
+
+// renderClipboardStatus describes the clipboard above the help lines
+func (m *SFTPBrowserModel) renderClipboardStatus() string {
+	if len(m.clipboard.paths) == 0 {
+		return ""
+	}
+	verb := "copy"
+	if m.clipboard.cut {
+		verb = "move"
+	}
+	line := fmt.Sprintf("  Clipboard: %d to %s", len(m.clipboard.paths), verb)
+	return styles.SuccessStyle.Render(line) + styles.SubtleStyle.Render(" | Paste: ctrl+p | Clear: esc")
+}
+
+func (m *SFTPBrowserModel) viewPaste() string {
+	job := m.paste
+	var s strings.Builder
+	s.WriteString("\n\n")
+
+	verb := "Copying"
+	if job.cut {
+		verb = "Moving"
+	}
+	if job.running {
+		s.WriteString(styles.TitleStyle.Render(fmt.Sprintf("  %s %d entries to %s...", verb, len(job.items), job.dir)) + "\n")
+		return s.String()
+	}
+
+	s.WriteString(styles.TitleStyle.Render(fmt.Sprintf("  %d of %d entries already exist in %s", job.conflicts, len(job.items), job.dir)) + "\n\n")
+	shown := 0
+	for _, item := range job.items {
+		if item.existing == nil {
+			continue
+		}
+		if shown >= max(1, m.height-12) {
+			s.WriteString(styles.SubtleStyle.Render(fmt.Sprintf("    ... and %d more", job.conflicts-shown)) + "\n")
+			break
+		}
+		age := "older"
+		switch {
+		case item.existing.ModTime.After(item.info.ModTime):
+			age = "newer"
+		case item.existing.ModTime.Equal(item.info.ModTime):
+			age = "same time"
+		}
+		s.WriteString("    " + path.Base(item.dst) + styles.SubtleStyle.Render(fmt.Sprintf("  (existing is %s, %s)", age, item.existing.ModTime.Format("2006-01-02 15:04"))) + "\n")
+		shown++
+	}
+
+	s.WriteString("\n  Overwrite replaces the existing entries, directories included.\n\n")
+	s.WriteString(styles.SubtleStyle.Render("  Overwrite: y | Skip: s | Rename: r | Keep newer: k | Cancel: n/esc") + "\n")
+	return s.String()
+}
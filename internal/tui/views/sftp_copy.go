@@ -0,0 +1,104 @@
+package views
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/steevenmentech/bifrost/internal/sftp"
+	"github.com/steevenmentech/bifrost/internal/tui/styles"
+)
+
+// copyDoneMsg is sent when a remote copy finishes
+type copyDoneMsg struct {
+	src    string
+	dst    string
+	method sftp.CopyMethod
+	err    error
+}
+
+// showCopy asks where to copy the selected entry, suggesting a backup next to it
+func (m *SFTPBrowserModel) showCopy() tea.Cmd {
+	if m.selectedIndex >= len(m.files) {
+		return nil
+	}
+	m.copySource = m.selectedPath()
+	m.copyRunning = false
+	m.nameInput.SetValue(m.files[m.selectedIndex].Name + ".bak")
+	m.nameInput.Focus()
+	m.err = nil
+	m.state = CopyState
+	return textinput.Blink
+}
+
+func (m *SFTPBrowserModel) updateCopy(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if m.copyRunning {
+		return m, nil
+	}
+
+	if keyMsg, ok := msg.(tea.KeyMsg); ok {
+		switch keyMsg.String() {
+		case "enter":
+			return m, m.startCopy()
+		case "esc":
+			m.state = BrowsingState
+			m.nameInput.Blur()
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.nameInput, cmd = m.nameInput.Update(msg)
+	return m, cmd
+}
+
+// startCopy copies the entry in the background. A relative destination is
+// taken from the current directory.
+func (m *SFTPBrowserModel) startCopy() tea.Cmd {
+	dst := strings.TrimSpace(m.nameInput.Value())
+	if dst == "" {
+		return nil
+	}
+	if !path.IsAbs(dst) {
+		dst = path.Join(m.currentPath, dst)
+	}
+
+	m.copyRunning = true
+	m.nameInput.Blur()
+	client, src := m.client, m.copySource
+	return func() tea.Msg {
+		method, err := client.Copy(src, dst)
+		return copyDoneMsg{src: src, dst: dst, method: method, err: err}
+	}
+}
+
+// finishCopy reports the outcome of a copy
+func (m *SFTPBrowserModel) finishCopy(msg copyDoneMsg) (tea.Model, tea.Cmd) {
+	m.copyRunning = false
+	if m.state == CopyState {
+		m.state = BrowsingState
+	}
+	if msg.err != nil {
+		m.err = msg.err
+		return m, nil
+	}
+	m.successMsg = fmt.Sprintf("Copied %s to %s (%s)", path.Base(msg.src), msg.dst, msg.method)
+	m.loadCurrentDirectory()
+	return m, nil
+}
+
+func (m *SFTPBrowserModel) viewCopy() string {
+	var s strings.Builder
+	s.WriteString("\n")
+	s.WriteString(styles.TitleStyle.Render(fmt.Sprintf("  Copy: %s", path.Base(m.copySource))) + "\n\n")
+	s.WriteString("  Copy to (name in this directory, or a full path):\n")
+	s.WriteString("  " + m.nameInput.View() + "\n\n")
+	if m.copyRunning {
+		s.WriteString(styles.SubtleStyle.Render("  Copying...") + "\n")
+	} else {
+		s.WriteString(styles.SubtleStyle.Render("  Copy: enter | Cancel: esc") + "\n")
+	}
+	return s.String()
+}
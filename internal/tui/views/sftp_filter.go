@@ -0,0 +1,236 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/steevenmentech/bifrost/internal/tui/styles"
+)
+
+// Weights of fuzzyMatch
+const (
+	fuzzyMatchScore     = 16 // every matched character
+	fuzzyConsecutive    = 8  // a character right after the previous match
+	fuzzyBoundary       = 10 // a character starting a word, like the b of foo_bar or fooBar
+	fuzzyFirstCharacter = 6  // a match on the very first character
+	fuzzyGapPenalty     = 1  // every skipped character between matches
+)
+
+// fuzzyMatch reports whether the characters of pattern appear in name in
+// order, how good the match is and the rune indices of name that matched.
+// Matching ignores case unless the pattern has upper case letters.
+func fuzzyMatch(pattern, name string) (int, []int, bool) {
+	if pattern == "" {
+		return 0, nil, true
+	}
+	fold := strings.ToLower(pattern) == pattern
+	p := []rune(pattern)
+	n := []rune(name)
+	eq := func(a, b rune) bool {
+		if fold {
+			return unicode.ToLower(a) == b
+		}
+		return a == b
+	}
+
+	// Find where the first complete match ends, then walk back from there
+	// for the shortest window ending at it, which scores better than the
+	// leftmost characters would
+	pi, end := 0, -1
+	for i, r := range n {
+		if eq(r, p[pi]) {
+			pi++
+			if pi == len(p) {
+				end = i
+				break
+			}
+		}
+	}
+	if end < 0 {
+		return 0, nil, false
+	}
+	positions := make([]int, len(p))
+	pi = len(p) - 1
+	for i := end; i >= 0 && pi >= 0; i-- {
+		if eq(n[i], p[pi]) {
+			positions[pi] = i
+			pi--
+		}
+	}
+
+	score := 0
+	for k, i := range positions {
+		score += fuzzyMatchScore
+		switch {
+		case i == 0:
+			score += fuzzyFirstCharacter + fuzzyBoundary
+		case isWordStart(n, i):
+			score += fuzzyBoundary
+		}
+		if k > 0 {
+			if gap := i - positions[k-1] - 1; gap == 0 {
+				score += fuzzyConsecutive
+			} else {
+				score -= gap * fuzzyGapPenalty
+			}
+		}
+	}
+	return score, positions, true
+}
+
+// isWordStart reports whether the rune at i begins a word of a name
+func isWordStart(n []rune, i int) bool {
+	prev, r := n[i-1], n[i]
+	switch {
+	case strings.ContainsRune(" ._-/", prev):
+		return true
+	case unicode.IsLower(prev) && unicode.IsUpper(r):
+		return true
+	case !unicode.IsDigit(prev) && unicode.IsDigit(r):
+		return true
+	}
+	return false
+}
+
+// applyFilter shows the entries of the directory matching the filter, in
+// listing order, and returns the index of the best match, -1 if none
+func (m *SFTPBrowserModel) applyFilter() int {
+	m.filterMatches = nil
+	if m.filter == "" {
+		m.files = m.allFiles
+		return -1
+	}
+
+	m.filterMatches = make(map[string][]int)
+	m.files = nil
+	best, bestScore := -1, 0
+	for _, f := range m.allFiles {
+		score, positions, ok := fuzzyMatch(m.filter, f.Name)
+		if !ok {
+			continue
+		}
+		m.filterMatches[f.Name] = positions
+		m.files = append(m.files, f)
+		if best < 0 || score > bestScore {
+			best, bestScore = len(m.files)-1, score
+		}
+	}
+	return best
+}
+
+// showFilter starts typing a filter, starting from the one applied
+func (m *SFTPBrowserModel) showFilter() tea.Cmd {
+	m.filterInput.SetValue(m.filter)
+	m.filterInput.CursorEnd()
+	m.filterInput.Focus()
+	m.filterDir = m.currentPath
+	m.state = FilterState
+	return textinput.Blink
+}
+
+// clearFilter shows the whole directory again, keeping the selected entry
+func (m *SFTPBrowserModel) clearFilter() {
+	selected := ""
+	if m.selectedIndex < len(m.files) {
+		selected = m.files[m.selectedIndex].Name
+	}
+	m.filter = ""
+	m.applyFilter()
+	m.selectedIndex = 0
+	for i, f := range m.files {
+		if f.Name == selected {
+			m.selectedIndex = i
+			break
+		}
+	}
+	m.adjustScroll()
+}
+
+func (m *SFTPBrowserModel) updateFilter(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if keyMsg, ok := msg.(tea.KeyMsg); ok {
+		switch keyMsg.String() {
+		case "enter": // Keep the filter and act on what it shows
+			m.state = BrowsingState
+			m.filterInput.Blur()
+			return m, nil
+		case "esc":
+			m.state = BrowsingState
+			m.filterInput.Blur()
+			m.clearFilter()
+			return m, nil
+		case "up", "ctrl+k", "ctrl+p":
+			if m.selectedIndex > 0 {
+				m.selectedIndex--
+				m.adjustScroll()
+			}
+			return m, nil
+		case "down", "ctrl+j", "ctrl+n":
+			if m.selectedIndex < len(m.files)-1 {
+				m.selectedIndex++
+				m.adjustScroll()
+			}
+			return m, nil
+		}
+		if key.Matches(keyMsg, m.keys.Quit) && keyMsg.String() != "q" {
+			return m, m.quit()
+		}
+	}
+
+	var cmd tea.Cmd
+	m.filterInput, cmd = m.filterInput.Update(msg)
+	if value := m.filterInput.Value(); value != m.filter {
+		m.filter = value
+		m.selectedIndex = max(0, m.applyFilter())
+		m.scrollOffset = 0
+		m.adjustScroll()
+	}
+	return m, cmd
+}
+
+// renderFilterStatus shows the filter being typed or applied, above the help lines
+func (m *SFTPBrowserModel) renderFilterStatus() string {
+	count := fmt.Sprintf("  %d of %d entries", len(m.files), len(m.allFiles))
+	if m.state == FilterState {
+		return "  " + m.filterInput.View() + styles.SubtleStyle.Render(count+" | Move: up/down | Keep: enter | Clear: esc")
+	}
+	if m.filter == "" {
+		return ""
+	}
+	return styles.SuccessStyle.Render("  Filter: "+m.filter) + styles.SubtleStyle.Render(count+" | Edit: / | Clear: esc")
+}
+
+// matchIndices places the matched runes of name in a rendered line where
+// the name starts at rune start and is shown as shown, possibly truncated
+func matchIndices(positions []int, name, shown string, start int) []int {
+	if len(positions) == 0 {
+		return nil
+	}
+	limit := utf8.RuneCountInString(name)
+	if !strings.HasPrefix(shown, name) {
+		limit = utf8.RuneCountInString(shown) - 1 // cut off before the ellipsis
+	}
+	var indices []int
+	for _, p := range positions {
+		if p < limit {
+			indices = append(indices, start+p)
+		}
+	}
+	return indices
+}
+
+// renderMatched renders a line in style with the runes at indices picked out
+func renderMatched(line string, indices []int, style lipgloss.Style) string {
+	if len(indices) == 0 {
+		return style.Render(line)
+	}
+	inner := style.UnsetPadding()
+	pad := inner.Render(strings.Repeat(" ", style.GetPaddingLeft()))
+	end := inner.Render(strings.Repeat(" ", style.GetPaddingRight()))
+	return pad + lipgloss.StyleRunes(line, indices, inner.Underline(true).Bold(true).Foreground(styles.Warning), inner) + end
+}
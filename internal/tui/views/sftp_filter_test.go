@@ -0,0 +1,123 @@
+package views
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/steevenmentech/bifrost/internal/sftp"
+)
+
+func TestFuzzyMatch(t *testing.T) {
+	tests := []struct {
+		pattern   string
+		name      string
+		ok        bool
+		positions []int
+	}{
+		{"", "anything", true, nil},
+		{"abc", "abc", true, []int{0, 1, 2}},
+		{"abc", "xaxbxc", true, []int{1, 3, 5}},
+		{"abd", "abc", false, nil},
+		{"ba", "ab", false, nil},
+		{"abc", "ab", false, nil},
+		{"readme", "README.md", true, []int{0, 1, 2, 3, 4, 5}}, // lower case ignores case
+		{"README", "readme.md", false, nil},                    // upper case does not
+		{"Rm", "README.md", true, []int{0, 7}},
+		{"Rm", "rm.txt", false, nil},
+		{"ab", "a_xab", true, []int{3, 4}}, // the shortest window ending at the first match
+		{"bt", "über.txt", true, []int{1, 5}},
+	}
+
+	for _, tt := range tests {
+		_, positions, ok := fuzzyMatch(tt.pattern, tt.name)
+		if ok != tt.ok {
+			t.Errorf("fuzzyMatch(%q, %q) matched = %v, want %v", tt.pattern, tt.name, ok, tt.ok)
+			continue
+		}
+		if ok && !reflect.DeepEqual(positions, tt.positions) {
+			t.Errorf("fuzzyMatch(%q, %q) positions = %v, want %v", tt.pattern, tt.name, positions, tt.positions)
+		}
+	}
+}
+
+func TestFuzzyMatchScore(t *testing.T) {
+	// Each pair lists a name that should score above the other
+	tests := []struct {
+		pattern       string
+		better, worse string
+	}{
+		{"ab", "abx", "axb"},             // consecutive characters
+		{"fb", "foo_bar", "foobar"},      // start of a word
+		{"fb", "fooBar", "foobar"},       // camel case word
+		{"v2", "v2", "vx2"},              // fewer skipped characters
+		{"log", "log.txt", "catalog"},    // first character
+		{"mk", "Makefile", "some_kinds"}, // first character over a word start
+	}
+
+	for _, tt := range tests {
+		better, _, ok1 := fuzzyMatch(tt.pattern, tt.better)
+		worse, _, ok2 := fuzzyMatch(tt.pattern, tt.worse)
+		if !ok1 || !ok2 {
+			t.Errorf("%q should match both %q and %q", tt.pattern, tt.better, tt.worse)
+			continue
+		}
+		if better <= worse {
+			t.Errorf("%q: %q scored %d, not above %q with %d", tt.pattern, tt.better, better, tt.worse, worse)
+		}
+	}
+}
+
+func TestApplyFilter(t *testing.T) {
+	m := &SFTPBrowserModel{}
+	for _, name := range []string{"xrxd", "notes", "readme", "rd"} {
+		m.allFiles = append(m.allFiles, sftp.FileInfo{Name: name})
+	}
+
+	m.filter = "rd"
+	best := m.applyFilter()
+	var names []string
+	for _, f := range m.files {
+		names = append(names, f.Name)
+	}
+	if want := []string{"xrxd", "readme", "rd"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("shown %q, want %q in listing order", names, want)
+	}
+	if best != 2 {
+		t.Errorf("best match at %d, want 2", best)
+	}
+	if got := m.filterMatches["readme"]; !reflect.DeepEqual(got, []int{0, 3}) {
+		t.Errorf("readme positions = %v, want [0 3]", got)
+	}
+
+	m.filter = ""
+	if best := m.applyFilter(); best != -1 || len(m.files) != 4 || m.filterMatches != nil {
+		t.Errorf("clearing the filter left best %d, %d entries, matches %v", best, len(m.files), m.filterMatches)
+	}
+}
+
+func TestMatchIndices(t *testing.T) {
+	tests := []struct {
+		name      string
+		positions []int
+		file      string
+		shown     string
+		start     int
+		want      []int
+	}{
+		{"no match", nil, "abc", "abc", 4, nil},
+		{"whole name", []int{0, 2}, "abc", "abc", 4, []int{4, 6}},
+		{"link label after the name", []int{0, 2}, "lnk", "lnk -> target", 4, []int{4, 6}},
+		{"truncated name drops hidden matches", []int{1, 3, 6}, "abcdefgh", "abcd…", 2, []int{3, 5}},
+		{"match under the ellipsis is dropped", []int{4}, "abcdefgh", "abcd…", 0, nil},
+		{"runes, not bytes", []int{0, 1, 4}, "ééé.txt", "éé…", 3, []int{3, 4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := matchIndices(tt.positions, tt.file, tt.shown, tt.start)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
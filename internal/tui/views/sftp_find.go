@@ -0,0 +1,490 @@
+package views
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/steevenmentech/bifrost/internal/sftp"
+	"github.com/steevenmentech/bifrost/internal/tui/styles"
+)
+
+// Fields of the find form, in tab order
+const (
+	findFieldName = iota
+	findFieldMinSize
+	findFieldMaxSize
+	findFieldAge
+	findFieldCount
+)
+
+// findSearch is a recursive search from a directory: its form in
+// FindFormState and its hits in FindResultsState
+type findSearch struct {
+	nameInput    textinput.Model
+	minSizeInput textinput.Model
+	maxSizeInput textinput.Model
+	ageInput     textinput.Model
+	focus        int
+
+	root     string
+	opts     sftp.FindOptions
+	hits     []sftp.FindHit
+	selected int
+	running  bool
+	method   sftp.FindMethod
+	err      error
+	cancel   context.CancelFunc
+	ch       chan tea.Msg
+	seq      int // Bumped on every search, to drop hits of a previous one
+
+	confirmDelete bool
+	deleting      bool
+}
+
+// findHitMsg carries an entry found by the running search
+type findHitMsg struct {
+	seq int
+	hit sftp.FindHit
+}
+
+// findDoneMsg is sent when a search went through the whole tree
+type findDoneMsg struct {
+	seq    int
+	method sftp.FindMethod
+	err    error
+}
+
+// findDeleteMsg is sent when a hit was deleted
+type findDeleteMsg struct {
+	path     string
+	failures []sftp.FailedEntry
+	err      error
+}
+
+// showFind opens the results of the last search, or the form for a new one
+func (m *SFTPBrowserModel) showFind() tea.Cmd {
+	if m.find.root != "" {
+		m.successMsg = ""
+		m.state = FindResultsState
+		return nil
+	}
+	return m.showFindForm()
+}
+
+// showFindForm asks what to look for below the current directory, keeping
+// the criteria of the last search
+func (m *SFTPBrowserModel) showFindForm() tea.Cmd {
+	if m.find.nameInput.CharLimit == 0 {
+		m.find.nameInput = newSyncInput("e.g. *.log, empty for any name")
+		m.find.minSizeInput = newSyncInput("e.g. 10M")
+		m.find.maxSizeInput = newSyncInput("e.g. 1G")
+		m.find.ageInput = newSyncInput("e.g. 30m, 12h, 7d, 2w")
+	}
+	m.find.focus = findFieldName
+	m.focusFindField()
+	m.err = nil
+	m.state = FindFormState
+	return textinput.Blink
+}
+
+// focusFindField moves the cursor to the focused field of the find form
+func (m *SFTPBrowserModel) focusFindField() {
+	inputs := []*textinput.Model{
+		findFieldName:    &m.find.nameInput,
+		findFieldMinSize: &m.find.minSizeInput,
+		findFieldMaxSize: &m.find.maxSizeInput,
+		findFieldAge:     &m.find.ageInput,
+	}
+	for i, input := range inputs {
+		if i == m.find.focus {
+			input.Focus()
+		} else {
+			input.Blur()
+		}
+	}
+}
+
+func (m *SFTPBrowserModel) updateFindForm(msg tea.Msg) (tea.Model, tea.Cmd) {
+	form := &m.find
+
+	if keyMsg, ok := msg.(tea.KeyMsg); ok {
+		switch keyMsg.String() {
+		case "esc":
+			m.err = nil
+			m.state = BrowsingState
+			return m, nil
+		case "tab", "down":
+			form.focus = (form.focus + 1) % findFieldCount
+			m.focusFindField()
+			return m, nil
+		case "shift+tab", "up":
+			form.focus = (form.focus + findFieldCount - 1) % findFieldCount
+			m.focusFindField()
+			return m, nil
+		case "enter":
+			return m, m.startFind()
+		}
+	}
+
+	var cmd tea.Cmd
+	switch form.focus {
+	case findFieldName:
+		form.nameInput, cmd = form.nameInput.Update(msg)
+	case findFieldMinSize:
+		form.minSizeInput, cmd = form.minSizeInput.Update(msg)
+	case findFieldMaxSize:
+		form.maxSizeInput, cmd = form.maxSizeInput.Update(msg)
+	case findFieldAge:
+		form.ageInput, cmd = form.ageInput.Update(msg)
+	}
+	return m, cmd
+}
+
+// findOptions reads the criteria of the find form
+func (m *SFTPBrowserModel) findOptions() (sftp.FindOptions, error) {
+	var opts sftp.FindOptions
+	var err error
+	opts.Name = strings.TrimSpace(m.find.nameInput.Value())
+	if v := strings.TrimSpace(m.find.minSizeInput.Value()); v != "" {
+		if opts.MinSize, err = sftp.ParseSize(v); err != nil {
+			return opts, err
+		}
+	}
+	if v := strings.TrimSpace(m.find.maxSizeInput.Value()); v != "" {
+		if opts.MaxSize, err = sftp.ParseSize(v); err != nil {
+			return opts, err
+		}
+	}
+	if v := strings.TrimSpace(m.find.ageInput.Value()); v != "" {
+		if opts.ModifiedWithin, err = sftp.ParseAge(v); err != nil {
+			return opts, err
+		}
+	}
+	return opts, nil
+}
+
+// startFind searches below the current directory in the background,
+// streaming hits into the results as they turn up
+func (m *SFTPBrowserModel) startFind() tea.Cmd {
+	opts, err := m.findOptions()
+	if err != nil {
+		m.err = err
+		return nil
+	}
+	m.stopFind()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := make(chan tea.Msg, 64)
+	m.find.seq++
+	m.find.root = m.currentPath
+	m.find.opts = opts
+	m.find.hits = nil
+	m.find.selected = 0
+	m.find.running = true
+	m.find.method = ""
+	m.find.err = nil
+	m.find.cancel = cancel
+	m.find.ch = ch
+	m.find.confirmDelete = false
+	m.err = nil
+	m.successMsg = ""
+	m.state = FindResultsState
+
+	client, root, seq := m.client, m.currentPath, m.find.seq
+	go func() {
+		defer close(ch)
+		method, err := client.Find(ctx, root, opts, func(hit sftp.FindHit) {
+			select {
+			case ch <- findHitMsg{seq: seq, hit: hit}:
+			case <-ctx.Done():
+			}
+		})
+		select {
+		case ch <- findDoneMsg{seq: seq, method: method, err: err}:
+		case <-ctx.Done():
+		}
+	}()
+
+	return waitForMsg(ch)
+}
+
+// stopFind cancels the running search, keeping what it found so far
+func (m *SFTPBrowserModel) stopFind() {
+	if m.find.cancel != nil {
+		m.find.cancel()
+		m.find.cancel = nil
+	}
+	m.find.running = false
+}
+
+// receiveFind records a message of the running search
+func (m *SFTPBrowserModel) receiveFind(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case findHitMsg:
+		if msg.seq != m.find.seq {
+			return m, nil
+		}
+		m.find.hits = append(m.find.hits, msg.hit)
+		return m, waitForMsg(m.find.ch)
+	case findDoneMsg:
+		if msg.seq != m.find.seq {
+			return m, nil
+		}
+		m.stopFind()
+		m.find.method = msg.method
+		if !errors.Is(msg.err, context.Canceled) {
+			m.find.err = msg.err
+		}
+	}
+	return m, nil
+}
+
+func (m *SFTPBrowserModel) updateFindResults(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok || m.find.deleting {
+		return m, nil
+	}
+
+	if m.find.confirmDelete {
+		switch keyMsg.String() {
+		case "y", "Y":
+			m.find.confirmDelete = false
+			return m, m.deleteHit()
+		case "n", "N", "esc":
+			m.find.confirmDelete = false
+		}
+		return m, nil
+	}
+
+	switch keyMsg.String() {
+	case "k", "up":
+		if m.find.selected > 0 {
+			m.find.selected--
+		}
+	case "j", "down":
+		if m.find.selected < len(m.find.hits)-1 {
+			m.find.selected++
+		}
+	case "G":
+		m.find.selected = max(0, len(m.find.hits)-1)
+	case "enter", "l":
+		if hit, ok := m.selectedHit(); ok {
+			m.jumpToHit(hit)
+		}
+	case "D":
+		if hit, ok := m.selectedHit(); ok {
+			m.downloadToDownloads(hit.Path)
+			m.state = FindResultsState
+		}
+	case "d":
+		if _, ok := m.selectedHit(); ok {
+			m.find.confirmDelete = true
+		}
+	case "n":
+		m.stopFind()
+		return m, m.showFindForm()
+	case "esc", "q":
+		if m.find.running {
+			m.stopFind()
+			m.successMsg = fmt.Sprintf("Search stopped after %d hits", len(m.find.hits))
+			return m, nil
+		}
+		m.state = BrowsingState
+	}
+	return m, nil
+}
+
+func (m *SFTPBrowserModel) selectedHit() (sftp.FindHit, bool) {
+	if m.find.selected >= len(m.find.hits) {
+		return sftp.FindHit{}, false
+	}
+	return m.find.hits[m.find.selected], true
+}
+
+// jumpToHit opens the directory holding a hit with the hit selected. The
+// results stay around for ctrl+f.
+func (m *SFTPBrowserModel) jumpToHit(hit sftp.FindHit) {
+	if err := m.client.ChangeDir(path.Dir(hit.Path)); err != nil {
+		m.err = err
+		return
+	}
+	m.currentPath = m.client.GetWorkingDir()
+	if strings.HasPrefix(hit.Info.Name, ".") {
+		m.showHidden = true
+	}
+	m.loadCurrentDirectory()
+	m.selectedIndex = 0
+	for i, f := range m.files {
+		if f.Name == hit.Info.Name {
+			m.selectedIndex = i
+			break
+		}
+	}
+	m.adjustScroll()
+	m.successMsg = ""
+	m.state = BrowsingState
+}
+
+// deleteHit removes the selected hit, everything below it for a directory
+func (m *SFTPBrowserModel) deleteHit() tea.Cmd {
+	hit, ok := m.selectedHit()
+	if !ok {
+		return nil
+	}
+	m.find.deleting = true
+	client := m.client
+	return func() tea.Msg {
+		failures, err := client.DeleteRecursive(hit.Path, nil)
+		return findDeleteMsg{path: hit.Path, failures: failures, err: err}
+	}
+}
+
+// finishDeleteHit drops a deleted hit, and those below it, from the results
+func (m *SFTPBrowserModel) finishDeleteHit(msg findDeleteMsg) (tea.Model, tea.Cmd) {
+	m.find.deleting = false
+	switch {
+	case msg.err != nil:
+		m.err = msg.err
+		return m, nil
+	case len(msg.failures) > 0:
+		m.err = fmt.Errorf("could not delete %d entries of %s, first %s: %w", len(msg.failures), msg.path, msg.failures[0].Path, msg.failures[0].Err)
+		return m, nil
+	}
+
+	kept := m.find.hits[:0]
+	for _, hit := range m.find.hits {
+		if hit.Path != msg.path && !strings.HasPrefix(hit.Path, msg.path+"/") {
+			kept = append(kept, hit)
+		}
+	}
+	m.find.hits = kept
+	m.find.selected = min(m.find.selected, max(0, len(kept)-1))
+	m.err = nil
+	m.successMsg = "Deleted: " + msg.path
+	m.loadCurrentDirectory()
+	return m, nil
+}
+
+// describeFind sums up the criteria of the last search
+func (m *SFTPBrowserModel) describeFind() string {
+	opts := m.find.opts
+	var parts []string
+	if opts.Name != "" {
+		parts = append(parts, "named "+opts.Name)
+	}
+	switch {
+	case opts.MinSize > 0 && opts.MaxSize > 0:
+		parts = append(parts, fmt.Sprintf("files of %s to %s", FormatFileSize(opts.MinSize), FormatFileSize(opts.MaxSize)))
+	case opts.MinSize > 0:
+		parts = append(parts, "files of at least "+FormatFileSize(opts.MinSize))
+	case opts.MaxSize > 0:
+		parts = append(parts, "files of at most "+FormatFileSize(opts.MaxSize))
+	}
+	if opts.ModifiedWithin > 0 {
+		parts = append(parts, "modified in the last "+strings.TrimSpace(m.find.ageInput.Value()))
+	}
+	if len(parts) == 0 {
+		return "everything"
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (m *SFTPBrowserModel) viewFindForm() string {
+	form := m.find
+	var s strings.Builder
+	s.WriteString("\n\n")
+	s.WriteString(styles.TitleStyle.Render("  Find below "+m.currentPath) + "\n\n")
+
+	rows := []struct {
+		label string
+		value string
+	}{
+		{"Name", form.nameInput.View()},
+		{"Min size", form.minSizeInput.View()},
+		{"Max size", form.maxSizeInput.View()},
+		{"Modified in", form.ageInput.View()},
+	}
+	for i, row := range rows {
+		label := fmt.Sprintf("  %-13s", row.label)
+		if i == form.focus {
+			label = styles.SelectedStyle.Render(label)
+		}
+		s.WriteString(label + " " + row.value + "\n")
+	}
+
+	if m.err != nil {
+		s.WriteString("\n" + styles.ErrorStyle.Render("  "+m.err.Error()) + "\n")
+	}
+	s.WriteString("\n" + styles.SubtleStyle.Render("  Size limits only match files | Next: tab | Search: enter | Cancel: esc") + "\n")
+	return s.String()
+}
+
+func (m *SFTPBrowserModel) viewFindResults() string {
+	f := m.find
+	var s strings.Builder
+	s.WriteString("\n\n")
+	s.WriteString(styles.TitleStyle.Render("  Find below "+f.root) + styles.SubtleStyle.Render("  "+m.describeFind()) + "\n\n")
+
+	status := fmt.Sprintf("  %d found", len(f.hits))
+	switch {
+	case f.running:
+		status += ", searching..."
+	case f.method != "":
+		status += " using " + string(f.method)
+	default:
+		status += ", stopped"
+	}
+	s.WriteString(styles.SubtleStyle.Render(status) + "\n")
+	if f.err != nil {
+		s.WriteString(styles.ErrorStyle.Render("  "+f.err.Error()) + "\n")
+	}
+	if m.err != nil {
+		s.WriteString(styles.ErrorStyle.Render("  Error: "+m.err.Error()) + "\n")
+	}
+	if m.successMsg != "" {
+		s.WriteString(styles.SuccessStyle.Render("  "+m.successMsg) + "\n")
+	}
+	s.WriteString("\n")
+
+	visible := max(1, m.height-14)
+	start := min(max(0, f.selected-visible+1), max(0, len(f.hits)-visible))
+	end := min(len(f.hits), start+visible)
+	nameWidth := max(20, m.width-40)
+	for i := start; i < end; i++ {
+		hit := f.hits[i]
+		rel := strings.TrimPrefix(strings.TrimPrefix(hit.Path, f.root), "/")
+		size := FormatFileSize(hit.Info.Size)
+		if hit.Info.IsDir {
+			rel += "/"
+			size = "-"
+		}
+		line := fmt.Sprintf("%-*s  %10s  %s", nameWidth, truncateText(rel, nameWidth), size, hit.Info.ModTime.Format("2006-01-02 15:04"))
+		if i == f.selected {
+			s.WriteString("  " + styles.SelectedStyle.Render(line) + "\n")
+		} else {
+			s.WriteString("  " + styles.ItemStyle.Render(line) + "\n")
+		}
+	}
+	if end < len(f.hits) {
+		s.WriteString(styles.SubtleStyle.Render(fmt.Sprintf("  ▼ %d more below...", len(f.hits)-end)) + "\n")
+	}
+
+	s.WriteString("\n")
+	switch {
+	case f.deleting:
+		s.WriteString(styles.SubtleStyle.Render("  Deleting...") + "\n")
+	case f.confirmDelete:
+		hit, _ := m.selectedHit()
+		s.WriteString(styles.ErrorStyle.Render("  Delete "+hit.Path+"? This cannot be undone.") + styles.SubtleStyle.Render(" Confirm: y | Cancel: n/esc") + "\n")
+	case f.running:
+		s.WriteString(styles.SubtleStyle.Render("  Up/Down: j/k | Go to: enter | Download: D | Delete: d | New search: n | Stop: esc") + "\n")
+	default:
+		s.WriteString(styles.SubtleStyle.Render("  Up/Down: j/k | Go to: enter | Download: D | Delete: d | New search: n | Close: esc") + "\n")
+	}
+	return s.String()
+}
@@ -0,0 +1,391 @@
+package views
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/steevenmentech/bifrost/internal/sftp"
+	"github.com/steevenmentech/bifrost/internal/tui/styles"
+)
+
+// grepMaxMatches stops a content search that matches far more than can be read
+const grepMaxMatches = 5000
+
+// Fields of the grep form, in tab order
+const (
+	grepFieldPattern = iota
+	grepFieldIgnoreCase
+	grepFieldFixed
+	grepFieldCount
+)
+
+// grepSearch is a content search from a directory: its form in
+// GrepFormState and its matches, grouped by file, in GrepResultsState
+type grepSearch struct {
+	patternInput textinput.Model
+	ignoreCase   bool
+	fixed        bool
+	focus        int
+
+	root     string
+	files    []grepFile
+	byPath   map[string]int // index in files
+	matches  int
+	selected int // index of the selected match, counted across files
+	running  bool
+	result   sftp.GrepResult
+	err      error
+	cancel   context.CancelFunc
+	ch       chan tea.Msg
+	seq      int // Bumped on every search, to drop matches of a previous one
+}
+
+// grepFile is a file with matching lines
+type grepFile struct {
+	path    string
+	matches []sftp.GrepMatch
+}
+
+// grepMatchMsg carries a line matched by the running search
+type grepMatchMsg struct {
+	seq   int
+	match sftp.GrepMatch
+}
+
+// grepDoneMsg is sent when a content search finished
+type grepDoneMsg struct {
+	seq    int
+	result sftp.GrepResult
+	err    error
+}
+
+// showGrep opens the matches of the last search, or the form for a new one
+func (m *SFTPBrowserModel) showGrep() tea.Cmd {
+	if m.grep.root != "" {
+		m.state = GrepResultsState
+		return nil
+	}
+	return m.showGrepForm()
+}
+
+// showGrepForm asks what to look for in the files below the current directory
+func (m *SFTPBrowserModel) showGrepForm() tea.Cmd {
+	if m.grep.patternInput.CharLimit == 0 {
+		m.grep.patternInput = newSyncInput("e.g. TODO, func \\w+Handler")
+	}
+	m.grep.focus = grepFieldPattern
+	m.focusGrepField()
+	m.err = nil
+	m.state = GrepFormState
+	return textinput.Blink
+}
+
+// focusGrepField puts the cursor in the pattern when it has the focus
+func (m *SFTPBrowserModel) focusGrepField() {
+	if m.grep.focus == grepFieldPattern {
+		m.grep.patternInput.Focus()
+	} else {
+		m.grep.patternInput.Blur()
+	}
+}
+
+func (m *SFTPBrowserModel) updateGrepForm(msg tea.Msg) (tea.Model, tea.Cmd) {
+	form := &m.grep
+
+	if keyMsg, ok := msg.(tea.KeyMsg); ok {
+		switch keyMsg.String() {
+		case "esc":
+			m.err = nil
+			m.state = BrowsingState
+			return m, nil
+		case "tab", "down":
+			form.focus = (form.focus + 1) % grepFieldCount
+			m.focusGrepField()
+			return m, nil
+		case "shift+tab", "up":
+			form.focus = (form.focus + grepFieldCount - 1) % grepFieldCount
+			m.focusGrepField()
+			return m, nil
+		case "enter":
+			return m, m.startGrep()
+		case " ", "left", "right":
+			switch form.focus {
+			case grepFieldIgnoreCase:
+				form.ignoreCase = !form.ignoreCase
+				return m, nil
+			case grepFieldFixed:
+				form.fixed = !form.fixed
+				return m, nil
+			}
+		}
+	}
+
+	if form.focus != grepFieldPattern {
+		return m, nil
+	}
+	var cmd tea.Cmd
+	form.patternInput, cmd = form.patternInput.Update(msg)
+	return m, cmd
+}
+
+// startGrep searches the files below the current directory on the server,
+// streaming matches into the results as they arrive
+func (m *SFTPBrowserModel) startGrep() tea.Cmd {
+	pattern := m.grep.patternInput.Value()
+	if strings.TrimSpace(pattern) == "" {
+		m.err = fmt.Errorf("enter something to search for")
+		return nil
+	}
+	opts := sftp.GrepOptions{
+		Pattern:    pattern,
+		Fixed:      m.grep.fixed,
+		IgnoreCase: m.grep.ignoreCase,
+		MaxMatches: grepMaxMatches,
+	}
+	m.stopGrep()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := make(chan tea.Msg, 64)
+	m.grep.seq++
+	m.grep.root = m.currentPath
+	m.grep.files = nil
+	m.grep.byPath = make(map[string]int)
+	m.grep.matches = 0
+	m.grep.selected = 0
+	m.grep.running = true
+	m.grep.result = sftp.GrepResult{}
+	m.grep.err = nil
+	m.grep.cancel = cancel
+	m.grep.ch = ch
+	m.err = nil
+	m.state = GrepResultsState
+
+	client, root, seq := m.client, m.currentPath, m.grep.seq
+	go func() {
+		defer close(ch)
+		result, err := client.Grep(ctx, root, opts, func(match sftp.GrepMatch) {
+			select {
+			case ch <- grepMatchMsg{seq: seq, match: match}:
+			case <-ctx.Done():
+			}
+		})
+		select {
+		case ch <- grepDoneMsg{seq: seq, result: result, err: err}:
+		case <-ctx.Done():
+		}
+	}()
+
+	return waitForMsg(ch)
+}
+
+// stopGrep cancels the running content search, keeping the matches so far
+func (m *SFTPBrowserModel) stopGrep() {
+	if m.grep.cancel != nil {
+		m.grep.cancel()
+		m.grep.cancel = nil
+	}
+	m.grep.running = false
+}
+
+// stopSearches cancels background searches before the browser goes away,
+// so they do not keep running on the server
+func (m *SFTPBrowserModel) stopSearches() {
+	m.stopFind()
+	m.stopGrep()
+}
+
+// receiveGrep records a message of the running content search
+func (m *SFTPBrowserModel) receiveGrep(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case grepMatchMsg:
+		if msg.seq != m.grep.seq {
+			return m, nil
+		}
+		i, ok := m.grep.byPath[msg.match.Path]
+		if !ok {
+			i = len(m.grep.files)
+			m.grep.byPath[msg.match.Path] = i
+			m.grep.files = append(m.grep.files, grepFile{path: msg.match.Path})
+		}
+		m.grep.files[i].matches = append(m.grep.files[i].matches, msg.match)
+		m.grep.matches++
+		return m, waitForMsg(m.grep.ch)
+	case grepDoneMsg:
+		if msg.seq != m.grep.seq {
+			return m, nil
+		}
+		m.stopGrep()
+		m.grep.result = msg.result
+		if !errors.Is(msg.err, context.Canceled) {
+			m.grep.err = msg.err
+		}
+	}
+	return m, nil
+}
+
+func (m *SFTPBrowserModel) updateGrepResults(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	switch keyMsg.String() {
+	case "k", "up":
+		if m.grep.selected > 0 {
+			m.grep.selected--
+		}
+	case "j", "down":
+		if m.grep.selected < m.grep.matches-1 {
+			m.grep.selected++
+		}
+	case "ctrl+u":
+		m.grep.selected = max(0, m.grep.selected-max(1, m.grepVisibleRows()/2))
+	case "ctrl+d":
+		m.grep.selected = max(0, min(m.grep.matches-1, m.grep.selected+max(1, m.grepVisibleRows()/2)))
+	case "G":
+		m.grep.selected = max(0, m.grep.matches-1)
+	case "enter", "e": // Edit the file at the matching line
+		if match, ok := m.selectedMatch(); ok {
+			m.stopSearches()
+			m.fileToEdit = match.Path
+			m.lineToEdit = match.Line
+			return m, tea.Quit
+		}
+	case "o": // Open the directory of the file
+		if match, ok := m.selectedMatch(); ok {
+			info := sftp.FileInfo{Name: path.Base(match.Path)}
+			m.jumpToHit(sftp.FindHit{Path: match.Path, Info: info})
+		}
+	case "n":
+		m.stopGrep()
+		return m, m.showGrepForm()
+	case "esc", "q":
+		if m.grep.running {
+			m.stopGrep()
+			return m, nil
+		}
+		m.state = BrowsingState
+	}
+	return m, nil
+}
+
+// selectedMatch returns the selected matching line
+func (m *SFTPBrowserModel) selectedMatch() (sftp.GrepMatch, bool) {
+	n := m.grep.selected
+	for _, f := range m.grep.files {
+		if n < len(f.matches) {
+			return f.matches[n], true
+		}
+		n -= len(f.matches)
+	}
+	return sftp.GrepMatch{}, false
+}
+
+func (m *SFTPBrowserModel) viewGrepForm() string {
+	form := m.grep
+	var s strings.Builder
+	s.WriteString("\n\n")
+	s.WriteString(styles.TitleStyle.Render("  Search file contents below "+m.currentPath) + "\n\n")
+
+	rows := []struct {
+		label string
+		value string
+	}{
+		{"Pattern", form.patternInput.View()},
+		{"Ignore case", renderCheckbox(form.ignoreCase)},
+		{"Plain text", renderCheckbox(form.fixed) + " match the pattern literally instead of as a regular expression"},
+	}
+	for i, row := range rows {
+		label := fmt.Sprintf("  %-13s", row.label)
+		if i == form.focus {
+			label = styles.SelectedStyle.Render(label)
+		}
+		s.WriteString(label + " " + row.value + "\n")
+	}
+
+	if m.err != nil {
+		s.WriteString("\n" + styles.ErrorStyle.Render("  "+m.err.Error()) + "\n")
+	}
+	s.WriteString("\n" + styles.SubtleStyle.Render("  Runs rg on the server when installed, grep -r otherwise | Next: tab | Toggle: space | Search: enter | Cancel: esc") + "\n")
+	return s.String()
+}
+
+// grepVisibleRows is how many file and match lines fit on the results screen
+func (m *SFTPBrowserModel) grepVisibleRows() int {
+	return max(1, m.height-12)
+}
+
+func (m *SFTPBrowserModel) viewGrepResults() string {
+	g := m.grep
+	var s strings.Builder
+	s.WriteString("\n\n")
+	s.WriteString(styles.TitleStyle.Render("  Search below "+g.root) + styles.SubtleStyle.Render("  "+g.patternInput.Value()) + "\n\n")
+
+	status := fmt.Sprintf("  %d matches in %d files", g.matches, len(g.files))
+	switch {
+	case g.running:
+		status += ", searching..."
+	case g.result.Truncated:
+		status += fmt.Sprintf(" using %s, stopped at the first %d", g.result.Tool, grepMaxMatches)
+	case g.result.Tool != "":
+		status += " using " + g.result.Tool
+	default:
+		status += ", stopped"
+	}
+	s.WriteString(styles.SubtleStyle.Render(status) + "\n")
+	if g.err != nil {
+		s.WriteString(styles.ErrorStyle.Render("  "+g.err.Error()) + "\n")
+	}
+	if m.err != nil {
+		s.WriteString(styles.ErrorStyle.Render("  Error: "+m.err.Error()) + "\n")
+	}
+	s.WriteString("\n")
+
+	// Lay out a header per file followed by its matches, then show the
+	// window of lines around the selected match
+	var lines []string
+	selectedLine, n := 0, 0
+	width := max(20, m.width-16)
+	for _, f := range g.files {
+		rel := strings.TrimPrefix(strings.TrimPrefix(f.path, g.root), "/")
+		lines = append(lines, "  "+styles.SuccessStyle.Render(truncateText(rel, width))+styles.SubtleStyle.Render(fmt.Sprintf("  (%d)", len(f.matches))))
+		for _, match := range f.matches {
+			text := truncateText(strings.ReplaceAll(match.Text, "\t", "    "), width)
+			line := fmt.Sprintf("%6d  %s", match.Line, text)
+			if n == g.selected {
+				selectedLine = len(lines)
+				line = styles.SelectedStyle.Render(line)
+			} else {
+				line = styles.ItemStyle.Render(line)
+			}
+			lines = append(lines, "  "+line)
+			n++
+		}
+	}
+
+	visible := m.grepVisibleRows()
+	start := min(max(0, selectedLine-visible+1), max(0, len(lines)-visible))
+	if visible > 1 && start > 0 && start == selectedLine {
+		start-- // keep the header above the first match in view
+	}
+	end := min(len(lines), start+visible)
+	for _, line := range lines[start:end] {
+		s.WriteString(line + "\n")
+	}
+	if end < len(lines) {
+		s.WriteString(styles.SubtleStyle.Render(fmt.Sprintf("  ▼ %d more lines below...", len(lines)-end)) + "\n")
+	}
+
+	s.WriteString("\n")
+	stopKey := "Close: esc"
+	if g.running {
+		stopKey = "Stop: esc"
+	}
+	s.WriteString(styles.SubtleStyle.Render("  Up/Down: j/k | Page: ctrl-u/d | Edit at line: enter | Open directory: o | New search: n | "+stopKey) + "\n")
+	return s.String()
+}
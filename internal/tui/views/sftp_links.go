@@ -0,0 +1,190 @@
+package views
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/steevenmentech/bifrost/internal/sftp"
+	"github.com/steevenmentech/bifrost/internal/tui/styles"
+)
+
+// Fields of the create link dialog, in tab order
+const (
+	linkFieldTarget = iota
+	linkFieldName
+	linkFieldKind
+	linkFieldCount
+)
+
+var (
+	linkStyle       = styles.ItemStyle.Foreground(styles.Secondary)
+	brokenLinkStyle = styles.ItemStyle.Foreground(styles.Error)
+)
+
+// linkForm holds a link being created in the current directory
+type linkForm struct {
+	targetInput textinput.Model
+	nameInput   textinput.Model
+	hard        bool
+	focus       int
+}
+
+// linkLabel is the name of an entry as listed, followed by the target of a
+// symlink, in at most width cells
+func linkLabel(f sftp.FileInfo, width int) string {
+	if !f.IsLink {
+		return f.Name
+	}
+	target := f.LinkTarget
+	if target == "" {
+		target = "?"
+	}
+	return f.Name + " → " + truncateText(target, max(5, width-lipgloss.Width(f.Name)-3))
+}
+
+// showLinkForm asks for a link to the selected entry
+func (m *SFTPBrowserModel) showLinkForm() tea.Cmd {
+	targetInput := textinput.New()
+	targetInput.Placeholder = "target path"
+	targetInput.CharLimit = 512
+	targetInput.Width = 60
+	targetInput.SetValue(m.selectedPath())
+
+	nameInput := textinput.New()
+	nameInput.Placeholder = "link name or path"
+	nameInput.CharLimit = 256
+	nameInput.Width = 60
+
+	m.link = linkForm{targetInput: targetInput, nameInput: nameInput, hard: m.link.hard, focus: linkFieldName}
+	m.focusLinkField()
+	m.err = nil
+	m.state = LinkState
+	return textinput.Blink
+}
+
+// focusLinkField moves the cursor to the focused text field of the link dialog
+func (m *SFTPBrowserModel) focusLinkField() {
+	m.link.targetInput.Blur()
+	m.link.nameInput.Blur()
+	switch m.link.focus {
+	case linkFieldTarget:
+		m.link.targetInput.Focus()
+	case linkFieldName:
+		m.link.nameInput.Focus()
+	}
+}
+
+func (m *SFTPBrowserModel) updateLinkForm(msg tea.Msg) (tea.Model, tea.Cmd) {
+	form := &m.link
+
+	if keyMsg, ok := msg.(tea.KeyMsg); ok {
+		switch keyMsg.String() {
+		case "esc":
+			m.state = BrowsingState
+			return m, nil
+		case "tab", "down":
+			form.focus = (form.focus + 1) % linkFieldCount
+			m.focusLinkField()
+			return m, nil
+		case "shift+tab", "up":
+			form.focus = (form.focus + linkFieldCount - 1) % linkFieldCount
+			m.focusLinkField()
+			return m, nil
+		case "enter":
+			m.createLink()
+			return m, nil
+		case " ", "left", "right":
+			if form.focus == linkFieldKind {
+				form.hard = !form.hard
+				return m, nil
+			}
+		}
+	}
+
+	var cmd tea.Cmd
+	switch form.focus {
+	case linkFieldTarget:
+		form.targetInput, cmd = form.targetInput.Update(msg)
+	case linkFieldName:
+		form.nameInput, cmd = form.nameInput.Update(msg)
+	}
+	return m, cmd
+}
+
+// createLink makes the link described by the dialog. The link name is taken
+// from the current directory. A relative symlink target is kept as typed,
+// so it stays relative to the link.
+func (m *SFTPBrowserModel) createLink() {
+	form := m.link
+	target := strings.TrimSpace(form.targetInput.Value())
+	name := strings.TrimSpace(form.nameInput.Value())
+	if target == "" || name == "" {
+		m.err = fmt.Errorf("both a target and a link name are required")
+		return
+	}
+
+	linkPath := name
+	if !path.IsAbs(linkPath) {
+		linkPath = path.Join(m.currentPath, name)
+	}
+
+	var err error
+	if form.hard {
+		// The server resolves relative paths from its own working directory
+		if !path.IsAbs(target) {
+			target = path.Join(m.currentPath, target)
+		}
+		err = m.client.Link(target, linkPath)
+	} else {
+		err = m.client.Symlink(target, linkPath)
+	}
+	if err != nil {
+		m.err = err
+		return
+	}
+
+	kind := "symlink"
+	if form.hard {
+		kind = "hard link"
+	}
+	m.successMsg = fmt.Sprintf("Created %s %s → %s", kind, name, target)
+	m.state = BrowsingState
+	m.loadCurrentDirectory()
+}
+
+func (m *SFTPBrowserModel) viewLinkForm() string {
+	form := m.link
+	var s strings.Builder
+	s.WriteString("\n\n")
+	s.WriteString(styles.TitleStyle.Render("  Create link in "+m.currentPath) + "\n\n")
+
+	kind := "symbolic"
+	if form.hard {
+		kind = "hard (files only, same filesystem)"
+	}
+	rows := []struct {
+		label string
+		value string
+	}{
+		{"Target", form.targetInput.View()},
+		{"Link name", form.nameInput.View()},
+		{"Kind", kind},
+	}
+	for i, row := range rows {
+		label := fmt.Sprintf("  %-10s", row.label)
+		if i == form.focus {
+			label = styles.SelectedStyle.Render(label)
+		}
+		s.WriteString(label + " " + row.value + "\n")
+	}
+
+	if m.err != nil {
+		s.WriteString("\n" + styles.ErrorStyle.Render("  "+m.err.Error()) + "\n")
+	}
+	s.WriteString("\n" + styles.SubtleStyle.Render("  Next: tab | Toggle kind: space | Create: enter | Cancel: esc") + "\n")
+	return s.String()
+}
@@ -0,0 +1,396 @@
+package views
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/steevenmentech/bifrost/internal/sftp"
+	"github.com/steevenmentech/bifrost/internal/tui/styles"
+)
+
+// Fields of the permissions dialog, in tab order
+const (
+	permFieldGrid = iota
+	permFieldMode
+	permFieldOwner
+	permFieldGroup
+	permFieldRecursive
+	permFieldCount
+)
+
+// permGridBits are the mode bits of the rwx checkboxes, by row and column.
+// The last row holds setuid, setgid and sticky.
+var permGridBits = [4][3]fs.FileMode{
+	{0400, 0200, 0100},
+	{0040, 0020, 0010},
+	{0004, 0002, 0001},
+	{fs.ModeSetuid, fs.ModeSetgid, fs.ModeSticky},
+}
+
+// permForm holds the mode and ownership being edited for one entry
+type permForm struct {
+	path     string   // shown in the title, a count for marked entries
+	paths    []string // entries to change
+	isDir    bool     // any of them is a directory
+	original fs.FileMode
+	initial  string      // mode field as first shown, empty when the entries differ
+	mode     fs.FileMode // result of the mode field, shown by the checkboxes
+	modeErr  error       // why the mode field does not parse
+
+	modeInput  textinput.Model
+	ownerInput textinput.Model
+	groupInput textinput.Model
+	owner      string // current owner and group, as first shown
+	group      string
+	recursive  bool
+
+	focus   int
+	row     int // checkbox under the cursor
+	col     int
+	running bool
+}
+
+// permCursorStyle marks the focused field without the padding of SelectedStyle,
+// which would shift the checkbox columns
+var permCursorStyle = styles.SelectedStyle.Padding(0)
+
+// permsDoneMsg is sent when a chmod/chown finishes
+type permsDoneMsg struct {
+	path     string
+	failures []sftp.FailedEntry
+	err      error
+}
+
+func newPermInput(placeholder string, width int) textinput.Model {
+	input := textinput.New()
+	input.Placeholder = placeholder
+	input.CharLimit = 64
+	input.Width = width
+	return input
+}
+
+// showPermissions opens the permissions dialog for the marked entries, or
+// the selected one. Fields the marked entries disagree on start out empty.
+func (m *SFTPBrowserModel) showPermissions() tea.Cmd {
+	if m.selectedIndex >= len(m.files) {
+		return nil
+	}
+	entries := m.markedEntries()
+	title := fmt.Sprintf("%d marked entries", len(entries))
+	if entries == nil {
+		entries = []sftp.FileInfo{m.files[m.selectedIndex]}
+		title = m.selectedPath()
+	}
+
+	first := entries[0]
+	form := permForm{
+		path:       title,
+		original:   first.Mode,
+		mode:       first.Mode,
+		initial:    sftp.OctalMode(first.Mode),
+		modeInput:  newPermInput("e.g. 0644 or u+x,go-w", 24),
+		ownerInput: newPermInput("name or uid", 24),
+		groupInput: newPermInput("name or gid", 24),
+		owner:      first.Owner,
+		group:      first.Group,
+	}
+	for _, f := range entries {
+		form.paths = append(form.paths, path.Join(m.currentPath, f.Name))
+		form.isDir = form.isDir || f.IsDir
+		if sftp.OctalMode(f.Mode) != form.initial {
+			form.initial = ""
+		}
+		if f.Owner != form.owner {
+			form.owner = ""
+		}
+		if f.Group != form.group {
+			form.group = ""
+		}
+	}
+	form.modeInput.SetValue(form.initial)
+	form.ownerInput.SetValue(form.owner)
+	form.groupInput.SetValue(form.group)
+	m.perms = form
+	m.focusPermField()
+
+	m.err = nil
+	m.state = PermissionsState
+	return textinput.Blink
+}
+
+// focusPermField moves the cursor to the focused text field of the permissions dialog
+func (m *SFTPBrowserModel) focusPermField() {
+	inputs := map[int]*textinput.Model{
+		permFieldMode:  &m.perms.modeInput,
+		permFieldOwner: &m.perms.ownerInput,
+		permFieldGroup: &m.perms.groupInput,
+	}
+	for field, input := range inputs {
+		if field == m.perms.focus {
+			input.Focus()
+		} else {
+			input.Blur()
+		}
+	}
+}
+
+// movePermFocus moves to the next or previous field, skipping the recursive
+// flag for files
+func (m *SFTPBrowserModel) movePermFocus(delta int) {
+	form := &m.perms
+	for {
+		form.focus = (form.focus + delta + permFieldCount) % permFieldCount
+		if form.focus != permFieldRecursive || form.isDir {
+			break
+		}
+	}
+	m.focusPermField()
+}
+
+// parsePermMode updates the checkboxes from the mode field
+func (form *permForm) parsePermMode() {
+	change, err := sftp.ParseMode(form.modeInput.Value())
+	form.modeErr = err
+	if err == nil {
+		form.mode = change.Apply(form.original, form.isDir)
+	}
+}
+
+func (m *SFTPBrowserModel) updatePermissions(msg tea.Msg) (tea.Model, tea.Cmd) {
+	form := &m.perms
+	if form.running {
+		return m, nil
+	}
+	keyMsg, ok := msg.(tea.KeyMsg)
+
+	if ok {
+		switch keyMsg.String() {
+		case "esc":
+			m.state = BrowsingState
+			return m, nil
+		case "tab":
+			m.movePermFocus(1)
+			return m, nil
+		case "shift+tab":
+			m.movePermFocus(-1)
+			return m, nil
+		case "enter":
+			return m, m.applyPermissions()
+		}
+
+		switch form.focus {
+		case permFieldGrid:
+			switch keyMsg.String() {
+			case "up", "k":
+				form.row = max(0, form.row-1)
+			case "down", "j":
+				form.row = min(len(permGridBits)-1, form.row+1)
+			case "left", "h":
+				form.col = max(0, form.col-1)
+			case "right", "l":
+				form.col = min(2, form.col+1)
+			case " ", "x":
+				form.mode ^= permGridBits[form.row][form.col]
+				form.modeInput.SetValue(sftp.OctalMode(form.mode))
+				form.modeErr = nil
+			}
+			return m, nil
+		case permFieldRecursive:
+			if keyMsg.String() == " " || keyMsg.String() == "x" {
+				form.recursive = !form.recursive
+			}
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	switch form.focus {
+	case permFieldMode:
+		form.modeInput, cmd = form.modeInput.Update(msg)
+		form.parsePermMode()
+	case permFieldOwner:
+		form.ownerInput, cmd = form.ownerInput.Update(msg)
+	case permFieldGroup:
+		form.groupInput, cmd = form.groupInput.Update(msg)
+	}
+	return m, cmd
+}
+
+// applyPermissions changes what was edited in the background. The mode is
+// left alone unless the mode field changed, so a recursive chown does not
+// also give every file the mode of the directory.
+func (m *SFTPBrowserModel) applyPermissions() tea.Cmd {
+	form := &m.perms
+
+	var change *sftp.ModeChange
+	if value := strings.TrimSpace(form.modeInput.Value()); value != form.initial {
+		c, err := sftp.ParseMode(value)
+		if err != nil {
+			m.err = err
+			return nil
+		}
+		change = &c
+	}
+
+	owner := strings.TrimSpace(form.ownerInput.Value())
+	group := strings.TrimSpace(form.groupInput.Value())
+	chown, chgrp := owner != form.owner, group != form.group
+	if change == nil && !chown && !chgrp {
+		m.state = BrowsingState
+		return nil
+	}
+
+	m.err = nil
+	form.running = true
+	client := m.client
+	label, paths, recursive := form.path, form.paths, form.recursive && form.isDir
+	return func() tea.Msg {
+		// Names may have to be asked of the server, so they are resolved here
+		uid, gid := -1, -1
+		var err error
+		if chown {
+			if uid, err = client.LookupUser(owner); err != nil {
+				return permsDoneMsg{path: label, err: err}
+			}
+		}
+		if chgrp {
+			if gid, err = client.LookupGroup(group); err != nil {
+				return permsDoneMsg{path: label, err: err}
+			}
+		}
+
+		// With several entries, one that fails does not stop the others
+		var failures []sftp.FailedEntry
+		for _, p := range paths {
+			if change != nil {
+				f, err := client.Chmod(p, *change, recursive)
+				if err != nil {
+					failures = append(failures, sftp.FailedEntry{Path: p, Err: err})
+					continue
+				}
+				failures = append(failures, f...)
+			}
+			if uid >= 0 || gid >= 0 {
+				f, err := client.Chown(p, uid, gid, recursive)
+				if err != nil {
+					failures = append(failures, sftp.FailedEntry{Path: p, Err: err})
+					continue
+				}
+				failures = append(failures, f...)
+			}
+		}
+		if len(paths) == 1 && len(failures) == 1 && failures[0].Path == paths[0] {
+			return permsDoneMsg{path: label, err: failures[0].Err}
+		}
+		return permsDoneMsg{path: label, failures: failures}
+	}
+}
+
+// finishPermissions shows the outcome of a chmod/chown
+func (m *SFTPBrowserModel) finishPermissions(msg permsDoneMsg) (tea.Model, tea.Cmd) {
+	m.perms.running = false
+	if m.state != PermissionsState {
+		return m, nil
+	}
+
+	switch {
+	case msg.err != nil:
+		m.err = msg.err
+		return m, nil
+	case len(msg.failures) > 0:
+		m.resultTitle = fmt.Sprintf("%d entries could not be changed", len(msg.failures))
+		m.resultSections = []resultSection{{heading: "Failed", entries: msg.failures}}
+		m.state = OperationResultState
+	default:
+		m.successMsg = fmt.Sprintf("Changed permissions of %s", msg.path)
+		m.state = BrowsingState
+	}
+	m.loadCurrentDirectory()
+	return m, nil
+}
+
+// formatMode renders mode bits the way ls does, like rwsr-xr-x
+func formatMode(mode fs.FileMode) string {
+	b := []byte("rwxrwxrwx")
+	for i := range b {
+		if mode&(1<<uint(8-i)) == 0 {
+			b[i] = '-'
+		}
+	}
+	special := func(i int, set bool, on, off byte) {
+		if !set {
+			return
+		}
+		if b[i] == 'x' {
+			b[i] = on
+		} else {
+			b[i] = off
+		}
+	}
+	special(2, mode&fs.ModeSetuid != 0, 's', 'S')
+	special(5, mode&fs.ModeSetgid != 0, 's', 'S')
+	special(8, mode&fs.ModeSticky != 0, 't', 'T')
+	return string(b)
+}
+
+func (m *SFTPBrowserModel) viewPermissions() string {
+	form := m.perms
+	var s strings.Builder
+	s.WriteString("\n\n")
+	s.WriteString(styles.TitleStyle.Render("  Permissions of "+form.path) + "\n\n")
+
+	label := func(field int, text string) string {
+		text = fmt.Sprintf("  %-11s", text)
+		if form.focus == field {
+			return "  " + permCursorStyle.Render(text[2:])
+		}
+		return text
+	}
+
+	// Checkboxes, with the cell under the cursor highlighted while the grid has focus
+	s.WriteString(label(permFieldGrid, "Access") + styles.SubtleStyle.Render("  read   write  exec") + "\n")
+	rowNames := []string{"owner", "group", "others", "special"}
+	for r, bits := range permGridBits {
+		line := fmt.Sprintf("    %-9s", rowNames[r])
+		for c, bit := range bits {
+			box := renderCheckbox(form.mode&bit != 0)
+			if form.focus == permFieldGrid && r == form.row && c == form.col {
+				box = permCursorStyle.Render(box)
+			}
+			line += "  " + box + "  "
+		}
+		if r == len(permGridBits)-1 {
+			line += styles.SubtleStyle.Render("setuid / setgid / sticky")
+		}
+		s.WriteString(line + "\n")
+	}
+	s.WriteString("\n")
+
+	preview := styles.SubtleStyle.Render(formatMode(form.mode))
+	if form.modeErr != nil {
+		preview = styles.ErrorStyle.Render(form.modeErr.Error())
+	}
+	s.WriteString(label(permFieldMode, "Mode") + " " + form.modeInput.View() + "  " + preview + "\n")
+	s.WriteString(label(permFieldOwner, "Owner") + " " + form.ownerInput.View() + "\n")
+	s.WriteString(label(permFieldGroup, "Group") + " " + form.groupInput.View() + "\n")
+	if form.isDir {
+		s.WriteString(label(permFieldRecursive, "Recursive") + " " + renderCheckbox(form.recursive) + " also change everything inside, except symlinks\n")
+		if form.recursive {
+			s.WriteString(styles.SubtleStyle.Render("              Octal modes apply to files and directories alike; a mode like u+rwX,go-w keeps them apart") + "\n")
+		}
+	}
+
+	if m.err != nil {
+		s.WriteString("\n" + styles.ErrorStyle.Render("  "+m.err.Error()) + "\n")
+	}
+	if form.running {
+		s.WriteString("\n" + styles.SubtleStyle.Render("  Applying...") + "\n")
+	} else {
+		s.WriteString("\n" + styles.SubtleStyle.Render("  Next: tab | Move: arrows | Toggle: space | Apply: enter | Cancel: esc") + "\n")
+	}
+	return s.String()
+}
@@ -0,0 +1,317 @@
+package views
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/steevenmentech/bifrost/internal/sftp"
+	"github.com/steevenmentech/bifrost/internal/tui/styles"
+)
+
+// markedStyle shows marked entries of the remote listing
+var markedStyle = styles.ItemStyle.Foreground(styles.Warning)
+
+// isMarked reports whether the entry at index i is marked, or inside the
+// visual range being drawn
+func (m *SFTPBrowserModel) isMarked(i int) bool {
+	if i >= len(m.files) {
+		return false
+	}
+	if m.visual && i >= min(m.visualAnchor, m.selectedIndex) && i <= max(m.visualAnchor, m.selectedIndex) {
+		return true
+	}
+	return m.marked[path.Join(m.currentPath, m.files[i].Name)]
+}
+
+// toggleMark marks or unmarks the selected entry and moves to the next one
+func (m *SFTPBrowserModel) toggleMark() {
+	p := m.selectedPath()
+	if p == "" {
+		return
+	}
+	if m.marked[p] {
+		delete(m.marked, p)
+	} else {
+		m.marked[p] = true
+	}
+	if m.selectedIndex < len(m.files)-1 {
+		m.selectedIndex++
+		m.adjustScroll()
+	}
+}
+
+// toggleVisual starts a visual range at the selected entry, or marks the
+// range drawn since
+func (m *SFTPBrowserModel) toggleVisual() {
+	if !m.visual {
+		if len(m.files) > 0 {
+			m.visual = true
+			m.visualAnchor = m.selectedIndex
+		}
+		return
+	}
+	for i := min(m.visualAnchor, m.selectedIndex); i <= max(m.visualAnchor, m.selectedIndex) && i < len(m.files); i++ {
+		m.marked[path.Join(m.currentPath, m.files[i].Name)] = true
+	}
+	m.visual = false
+}
+
+// invertMarks marks every unmarked entry of the listing and unmarks the rest
+func (m *SFTPBrowserModel) invertMarks() {
+	for _, f := range m.files {
+		p := path.Join(m.currentPath, f.Name)
+		if m.marked[p] {
+			delete(m.marked, p)
+		} else {
+			m.marked[p] = true
+		}
+	}
+}
+
+// pruneMarks drops marks of entries that are no longer listed, after a
+// directory change or an operation that removed them
+func (m *SFTPBrowserModel) pruneMarks() {
+	listed := make(map[string]bool, len(m.allFiles))
+	for _, f := range m.allFiles {
+		listed[path.Join(m.currentPath, f.Name)] = true
+	}
+	for p := range m.marked {
+		if !listed[p] {
+			delete(m.marked, p)
+		}
+	}
+	m.visual = false
+}
+
+// markedEntries returns the marked entries shown, in listing order, nil when none are marked
+func (m *SFTPBrowserModel) markedEntries() []sftp.FileInfo {
+	if len(m.marked) == 0 {
+		return nil
+	}
+	var entries []sftp.FileInfo
+	for _, f := range m.files {
+		if m.marked[path.Join(m.currentPath, f.Name)] {
+			entries = append(entries, f)
+		}
+	}
+	return entries
+}
+
+// showMarkGlob asks for a pattern of names to mark, or to unmark
+func (m *SFTPBrowserModel) showMarkGlob(mark bool) tea.Cmd {
+	m.globMark = mark
+	m.nameInput.SetValue("")
+	m.nameInput.Placeholder = "e.g. *.log"
+	m.nameInput.Focus()
+	m.state = MarkGlobState
+	return textinput.Blink
+}
+
+func (m *SFTPBrowserModel) updateMarkGlob(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if keyMsg, ok := msg.(tea.KeyMsg); ok {
+		switch keyMsg.String() {
+		case "enter":
+			pattern := strings.TrimSpace(m.nameInput.Value())
+			if pattern == "" {
+				return m, nil
+			}
+			if _, err := path.Match(pattern, ""); err != nil {
+				m.err = fmt.Errorf("invalid pattern: %w", err)
+				return m, nil
+			}
+			n := 0
+			for _, f := range m.files {
+				if ok, _ := path.Match(pattern, f.Name); !ok {
+					continue
+				}
+				p := path.Join(m.currentPath, f.Name)
+				if m.globMark {
+					m.marked[p] = true
+				} else {
+					delete(m.marked, p)
+				}
+				n++
+			}
+			verb := "Marked"
+			if !m.globMark {
+				verb = "Unmarked"
+			}
+			m.successMsg = fmt.Sprintf("%s %d entries matching %s", verb, n, pattern)
+			m.err = nil
+			m.closeMarkGlob()
+			return m, nil
+		case "esc":
+			m.err = nil
+			m.closeMarkGlob()
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.nameInput, cmd = m.nameInput.Update(msg)
+	return m, cmd
+}
+
+func (m *SFTPBrowserModel) closeMarkGlob() {
+	m.state = BrowsingState
+	m.nameInput.Blur()
+	m.nameInput.Placeholder = "filename"
+}
+
+func (m *SFTPBrowserModel) viewMarkGlob() string {
+	title := "  Mark entries matching"
+	if !m.globMark {
+		title = "  Unmark entries matching"
+	}
+	var s strings.Builder
+	s.WriteString("\n")
+	s.WriteString(styles.TitleStyle.Render(title) + "\n\n")
+	s.WriteString("  " + m.nameInput.View() + "\n\n")
+	if m.err != nil {
+		s.WriteString(styles.ErrorStyle.Render("  "+m.err.Error()) + "\n\n")
+	}
+	s.WriteString(styles.SubtleStyle.Render("  Patterns use * ? and [...] | Apply: enter | Cancel: esc") + "\n")
+	return s.String()
+}
+
+// renderSelectionStatus describes the marks above the help lines
+func (m *SFTPBrowserModel) renderSelectionStatus() string {
+	if m.visual {
+		n := max(m.visualAnchor, m.selectedIndex) - min(m.visualAnchor, m.selectedIndex) + 1
+		return styles.SuccessStyle.Render(fmt.Sprintf("  Visual: %d entries", n)) + styles.SubtleStyle.Render(" | Mark range: V | Cancel: esc")
+	}
+	if len(m.marked) == 0 {
+		return ""
+	}
+	var size int64
+	marked := m.markedEntries()
+	for _, f := range marked {
+		if !f.IsDir {
+			size += f.Size
+		}
+	}
+	line := fmt.Sprintf("  Marked: %d entries, %s in files", len(marked), FormatFileSize(size))
+	if hidden := len(m.marked) - len(marked); hidden > 0 {
+		line += fmt.Sprintf(" (%d more hidden by the filter, not acted on)", hidden)
+	}
+	return styles.SuccessStyle.Render(line) + styles.SubtleStyle.Render(" | Delete: d | Download: D | Perms: P | Yank/Cut: Y/X | Clear: esc")
+}
+
+// scanSelection counts what the bulk entries hold in the background, for
+// the confirmation of a bulk delete or download
+func (m *SFTPBrowserModel) scanSelection() tea.Cmd {
+	entries, dir, client := m.bulk, m.currentPath, m.client
+	key := bulkScanKey(dir, entries)
+	m.scanKey = key
+	return func() tea.Msg {
+		total := &sftp.TreeSummary{}
+		for _, f := range entries {
+			switch {
+			case f.IsLink:
+				total.Symlinks++
+			case f.IsDir:
+				total.Dirs++
+				sum, err := client.SummarizeTree(path.Join(dir, f.Name))
+				if err != nil {
+					return treeSummaryMsg{path: key, err: err}
+				}
+				total.Files += sum.Files
+				total.Dirs += sum.Dirs
+				total.Symlinks += sum.Symlinks
+				total.TotalSize += sum.TotalSize
+			default:
+				total.Files++
+				total.TotalSize += f.Size
+			}
+		}
+		return treeSummaryMsg{path: key, summary: total}
+	}
+}
+
+// bulkScanKey identifies the scan of a set of entries, so a stale result is
+// not shown for another selection
+func bulkScanKey(dir string, entries []sftp.FileInfo) string {
+	names := make([]string, len(entries))
+	for i, f := range entries {
+		names[i] = f.Name
+	}
+	return dir + "\x00" + strings.Join(names, "\x00")
+}
+
+// startBulkDelete deletes every bulk entry in the background, with one
+// progress display and one summary for all of them
+func (m *SFTPBrowserModel) startBulkDelete() tea.Cmd {
+	paths := make([]string, len(m.bulk))
+	for i, f := range m.bulk {
+		paths[i] = path.Join(m.currentPath, f.Name)
+	}
+	ch := make(chan tea.Msg, 16)
+	m.progressCh = ch
+	m.deleteProgress = sftp.DeleteProgress{}
+	m.state = DeletingState
+	m.bulk = nil
+
+	// The scan counted every entry, which gives the total up front
+	grandTotal := 0
+	if sum := m.treeSummary; sum != nil {
+		grandTotal = sum.Files + sum.Dirs + sum.Symlinks
+	}
+
+	client := m.client
+	go func() {
+		var failures []sftp.FailedEntry
+		done := 0 // entries of the roots already deleted
+		for _, p := range paths {
+			total := 0
+			f, err := client.DeleteRecursive(p, func(prog sftp.DeleteProgress) {
+				total = prog.Total
+				prog.Done += done
+				prog.Total = max(grandTotal, prog.Total+done)
+				select {
+				case ch <- deleteProgressMsg(prog):
+				default:
+				}
+			})
+			if err != nil {
+				failures = append(failures, sftp.FailedEntry{Path: p, Err: err})
+			}
+			failures = append(failures, f...)
+			done += total
+		}
+		ch <- deleteDoneMsg{name: fmt.Sprintf("%d entries", len(paths)), failures: failures}
+	}()
+
+	return waitForMsg(ch)
+}
+
+func (m *SFTPBrowserModel) viewBulkDeleteConfirm() string {
+	var s strings.Builder
+	s.WriteString("\n\n")
+	s.WriteString(styles.TitleStyle.Render(fmt.Sprintf("  Delete %d marked entries", len(m.bulk))) + "\n\n")
+	s.WriteString(m.renderBulkList() + "\n")
+	s.WriteString(m.renderTreeSummary() + "\n\n")
+	s.WriteString("  Are you sure? This action cannot be undone.\n\n")
+	s.WriteString(styles.SubtleStyle.Render("  Confirm: y | Cancel: n/esc") + "\n")
+	return s.String()
+}
+
+// renderBulkList lists the bulk entries, as many as fit
+func (m *SFTPBrowserModel) renderBulkList() string {
+	var s strings.Builder
+	limit := max(1, m.height-16)
+	for i, f := range m.bulk {
+		if i >= limit {
+			s.WriteString(styles.SubtleStyle.Render(fmt.Sprintf("    ... and %d more", len(m.bulk)-i)) + "\n")
+			break
+		}
+		name := f.Name
+		if f.IsDir {
+			name += "/"
+		}
+		s.WriteString("    " + name + "\n")
+	}
+	return s.String()
+}
@@ -0,0 +1,250 @@
+package views
+
+import (
+	"cmp"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/steevenmentech/bifrost/internal/config"
+	"github.com/steevenmentech/bifrost/internal/sftp"
+	"github.com/steevenmentech/bifrost/internal/tui/styles"
+)
+
+// sortKey is what the remote listing is ordered by
+type sortKey int
+
+const (
+	sortByName sortKey = iota
+	sortBySize
+	sortByModified
+	sortByExtension
+	sortByType
+	sortKeyCount
+)
+
+func (k sortKey) String() string {
+	switch k {
+	case sortBySize:
+		return "size"
+	case sortByModified:
+		return "modified"
+	case sortByExtension:
+		return "extension"
+	case sortByType:
+		return "type"
+	default:
+		return "name"
+	}
+}
+
+// parseSortKey reads a key saved in the config, name for anything unknown
+func parseSortKey(s string) sortKey {
+	for k := sortByName; k < sortKeyCount; k++ {
+		if k.String() == s {
+			return k
+		}
+	}
+	return sortByName
+}
+
+// listingSort is the order of the remote listing
+type listingSort struct {
+	key     sortKey
+	desc    bool
+	mixDirs bool // sort directories among files instead of listing them first
+}
+
+// sortFiles orders files in place. Directories, and links to them, come
+// first unless mixDirs is set; entries the key considers equal are ordered by name.
+func sortFiles(files []sftp.FileInfo, s listingSort) {
+	slices.SortStableFunc(files, func(a, b sftp.FileInfo) int {
+		if !s.mixDirs {
+			if c := -cmp.Compare(isDirEntry(a), isDirEntry(b)); c != 0 {
+				return c
+			}
+		}
+
+		var c int
+		switch s.key {
+		case sortBySize:
+			c = cmp.Compare(a.Size, b.Size)
+		case sortByModified:
+			c = a.ModTime.Compare(b.ModTime)
+		case sortByExtension:
+			c = strings.Compare(fileExtension(a), fileExtension(b))
+		case sortByType:
+			c = cmp.Compare(typeRank(a), typeRank(b))
+		}
+		if c == 0 {
+			c = naturalCompare(a.Name, b.Name)
+		}
+		if c == 0 {
+			c = strings.Compare(a.Name, b.Name)
+		}
+		if s.desc {
+			return -c
+		}
+		return c
+	})
+}
+
+func isDirEntry(f sftp.FileInfo) int {
+	if f.IsDir || f.LinksToDir {
+		return 1
+	}
+	return 0
+}
+
+// fileExtension is the lowercased extension of a file, empty for directories
+// and dotfiles like .bashrc
+func fileExtension(f sftp.FileInfo) string {
+	if f.IsDir {
+		return ""
+	}
+	ext := path.Ext(f.Name)
+	if ext == f.Name {
+		return ""
+	}
+	return strings.ToLower(ext)
+}
+
+// typeRank orders kinds of entries: directories, symlinks, regular files,
+// then devices, sockets and pipes
+func typeRank(f sftp.FileInfo) int {
+	switch {
+	case f.IsDir:
+		return 0
+	case f.IsLink:
+		return 1
+	case f.Mode.IsRegular():
+		return 2
+	default:
+		return 3
+	}
+}
+
+// naturalCompare compares names case-insensitively, taking runs of digits
+// by their value so that file2 sorts before file10
+func naturalCompare(a, b string) int {
+	for a != "" && b != "" {
+		if isASCIIDigit(a[0]) && isASCIIDigit(b[0]) {
+			na, restA := digitRun(a)
+			nb, restB := digitRun(b)
+			if c := cmp.Compare(len(na), len(nb)); c != 0 {
+				return c
+			}
+			if c := strings.Compare(na, nb); c != 0 {
+				return c
+			}
+			a, b = restA, restB
+			continue
+		}
+
+		ra, sizeA := utf8.DecodeRuneInString(a)
+		rb, sizeB := utf8.DecodeRuneInString(b)
+		if c := cmp.Compare(unicode.ToLower(ra), unicode.ToLower(rb)); c != 0 {
+			return c
+		}
+		a, b = a[sizeA:], b[sizeB:]
+	}
+	return cmp.Compare(len(a), len(b))
+}
+
+func isASCIIDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// digitRun splits the leading digits off s, dropping leading zeros
+func digitRun(s string) (string, string) {
+	end := 0
+	for end < len(s) && isASCIIDigit(s[end]) {
+		end++
+	}
+	return strings.TrimLeft(s[:end], "0"), s[end:]
+}
+
+// changeSort applies a new order to the listing, keeping the selected entry
+// under the cursor. It is saved with the connection by SaveSort.
+func (m *SFTPBrowserModel) changeSort(s listingSort) {
+	selected := ""
+	if m.selectedIndex < len(m.files) {
+		selected = m.files[m.selectedIndex].Name
+	}
+
+	m.sort = s
+	sortFiles(m.allFiles, s)
+	m.applyFilter()
+	for i, f := range m.files {
+		if f.Name == selected {
+			m.selectedIndex = i
+			break
+		}
+	}
+	m.adjustScroll()
+	m.successMsg = "Sorted by " + m.sortIndicator()
+	m.sortChanged = true
+}
+
+// LoadSavedSort orders the listing as last chosen for the connection, and
+// lets SaveSort remember later changes there
+func (m *SFTPBrowserModel) LoadSavedSort(connectionID string) {
+	m.connectionID = connectionID
+	cfg, err := config.Load()
+	if err != nil {
+		return
+	}
+	conn, err := cfg.GetConnection(connectionID)
+	if err != nil {
+		return
+	}
+	m.sort = listingSort{key: parseSortKey(conn.SortKey), desc: conn.SortDesc, mixDirs: conn.SortMixDirs}
+	sortFiles(m.allFiles, m.sort)
+	m.applyFilter()
+}
+
+// SaveSort stores the listing order with the connection, if the browser has
+// one and the order changed. It is called once the browser quits, so the
+// config is not rewritten on every key press.
+func (m *SFTPBrowserModel) SaveSort() error {
+	if m.connectionID == "" || !m.sortChanged {
+		return nil
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to save sort order: %w", err)
+	}
+	conn, err := cfg.GetConnection(m.connectionID)
+	if err != nil {
+		return fmt.Errorf("failed to save sort order: %w", err)
+	}
+	conn.SortKey = m.sort.key.String()
+	conn.SortDesc = m.sort.desc
+	conn.SortMixDirs = m.sort.mixDirs
+	if err := cfg.UpdateConnection(*conn); err != nil {
+		return fmt.Errorf("failed to save sort order: %w", err)
+	}
+	m.sortChanged = false
+	return nil
+}
+
+// sortIndicator describes the listing order, like "name ↑, dirs first"
+func (m *SFTPBrowserModel) sortIndicator() string {
+	arrow := "↑"
+	if m.sort.desc {
+		arrow = "↓"
+	}
+	s := m.sort.key.String() + " " + arrow
+	if !m.sort.mixDirs {
+		s += ", dirs first"
+	}
+	return s
+}
+
+// renderTitle is the browser title with the sort indicator next to it
+func (m *SFTPBrowserModel) renderTitle(title string) string {
+	return styles.TitleStyle.Render("  "+title) + styles.SubtleStyle.Render("  Sort: "+m.sortIndicator())
+}
@@ -0,0 +1,110 @@
+package views
+
+import (
+	"io/fs"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/steevenmentech/bifrost/internal/sftp"
+)
+
+func TestNaturalCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"file2", "file10", -1},
+		{"file10", "file2", 1},
+		{"x9", "x10", -1},
+		{"10", "9", 1},
+		{"a1b2", "a1b10", -1},
+		{"file007", "file7", 0}, // leading zeros do not count
+		{"file01b", "file1a", 1},
+		{"0", "00", 0},
+		{"a", "A", 0},
+		{"Zeta", "alpha", 1},
+		{"abc", "abcd", -1},
+		{"abc", "abc", 0},
+		{"", "a", -1},
+		{"émile", "Émile", 0},
+	}
+
+	for _, tt := range tests {
+		if got := naturalCompare(tt.a, tt.b); got != tt.want {
+			t.Errorf("naturalCompare(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestSortFiles(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	file := func(name string, size int64, age int) sftp.FileInfo {
+		return sftp.FileInfo{Name: name, Size: size, ModTime: base.Add(-time.Duration(age) * time.Hour)}
+	}
+	dir := func(name string) sftp.FileInfo {
+		return sftp.FileInfo{Name: name, IsDir: true, Mode: fs.ModeDir | 0755}
+	}
+	dirLink := sftp.FileInfo{Name: "lnk", IsLink: true, LinksToDir: true, Mode: fs.ModeSymlink}
+	fileLink := sftp.FileInfo{Name: "flnk", IsLink: true, Mode: fs.ModeSymlink}
+	pipe := sftp.FileInfo{Name: "fifo", Mode: fs.ModeNamedPipe}
+
+	listing := []sftp.FileInfo{file("b.txt", 10, 1), dir("dir2"), file("a.txt", 20, 3), dir("dir10"), dirLink, file("c.txt", 10, 2)}
+
+	tests := []struct {
+		name  string
+		files []sftp.FileInfo
+		sort  listingSort
+		want  []string
+	}{
+		{"name, dirs and links to them first", listing, listingSort{}, []string{"dir2", "dir10", "lnk", "a.txt", "b.txt", "c.txt"}},
+		{"name descending keeps dirs first", listing, listingSort{desc: true}, []string{"lnk", "dir10", "dir2", "c.txt", "b.txt", "a.txt"}},
+		{"name with dirs mixed in", listing, listingSort{mixDirs: true}, []string{"a.txt", "b.txt", "c.txt", "dir2", "dir10", "lnk"}},
+		{"size, equal sizes by name", listing, listingSort{key: sortBySize}, []string{"dir2", "dir10", "lnk", "b.txt", "c.txt", "a.txt"}},
+		{"size descending", listing, listingSort{key: sortBySize, desc: true}, []string{"lnk", "dir10", "dir2", "a.txt", "c.txt", "b.txt"}},
+		{"modified", listing, listingSort{key: sortByModified}, []string{"dir2", "dir10", "lnk", "a.txt", "c.txt", "b.txt"}},
+		{
+			"extension, none first",
+			[]sftp.FileInfo{file("b.go", 0, 0), file("a.txt", 0, 0), file("C.GO", 0, 0), file("Makefile", 0, 0), file(".bashrc", 0, 0)},
+			listingSort{key: sortByExtension},
+			[]string{".bashrc", "Makefile", "b.go", "C.GO", "a.txt"},
+		},
+		{
+			"type",
+			[]sftp.FileInfo{pipe, file("a", 0, 0), fileLink, dir("d")},
+			listingSort{key: sortByType, mixDirs: true},
+			[]string{"d", "flnk", "a", "fifo"},
+		},
+		{
+			"names equal but for case",
+			[]sftp.FileInfo{file("B", 0, 0), file("a", 0, 0), file("A", 0, 0)},
+			listingSort{},
+			[]string{"A", "a", "B"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := append([]sftp.FileInfo(nil), tt.files...)
+			sortFiles(files, tt.sort)
+			var got []string
+			for _, f := range files {
+				got = append(got, f.Name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSortKey(t *testing.T) {
+	for k := sortByName; k < sortKeyCount; k++ {
+		if got := parseSortKey(k.String()); got != k {
+			t.Errorf("parseSortKey(%q) = %v", k.String(), got)
+		}
+	}
+	if got := parseSortKey("bogus"); got != sortByName {
+		t.Errorf("parseSortKey(bogus) = %v, want name", got)
+	}
+}
@@ -0,0 +1,362 @@
+package views
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/steevenmentech/bifrost/internal/sftp"
+	"github.com/steevenmentech/bifrost/internal/tui/styles"
+)
+
+// Fields of the sync form, in tab order
+const (
+	syncFieldLocal = iota
+	syncFieldRemote
+	syncFieldDirection
+	syncFieldInclude
+	syncFieldExclude
+	syncFieldDelete
+	syncFieldChecksum
+	syncFieldCount
+)
+
+// syncForm holds the options of a directory sync being set up
+type syncForm struct {
+	localInput   textinput.Model
+	remoteInput  textinput.Model
+	includeInput textinput.Model
+	excludeInput textinput.Model
+	download     bool
+	delete       bool
+	checksum     bool
+	focus        int
+}
+
+// syncPlanMsg carries the plan computed for the sync form
+type syncPlanMsg struct {
+	plan *sftp.SyncPlan
+	err  error
+}
+
+func newSyncInput(placeholder string) textinput.Model {
+	input := textinput.New()
+	input.Placeholder = placeholder
+	input.CharLimit = 512
+	input.Width = 60
+	return input
+}
+
+// showSyncForm opens the sync form for the current remote directory
+func (m *SFTPBrowserModel) showSyncForm() tea.Cmd {
+	localDir := m.localPane.CurrentDir()
+	if !m.dualPane || localDir == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			wd, _ = os.UserHomeDir()
+		}
+		localDir = wd
+	}
+
+	// Keep the options of the previous sync, only the directories follow the panes
+	form := m.syncForm
+	if form.localInput.CharLimit == 0 {
+		form = syncForm{
+			localInput:   newSyncInput("local directory"),
+			remoteInput:  newSyncInput("remote directory"),
+			includeInput: newSyncInput("e.g. *.go, docs/*"),
+			excludeInput: newSyncInput("e.g. .git, node_modules, *.tmp"),
+		}
+	}
+	form.localInput.SetValue(localDir)
+	form.remoteInput.SetValue(m.currentPath)
+	form.focus = syncFieldLocal
+	m.syncForm = form
+	m.focusSyncField()
+
+	m.syncPlan = nil
+	m.syncPlanErr = nil
+	m.state = SyncFormState
+	return textinput.Blink
+}
+
+// focusSyncField moves the cursor to the focused text field of the sync form
+func (m *SFTPBrowserModel) focusSyncField() {
+	inputs := []*textinput.Model{
+		syncFieldLocal:   &m.syncForm.localInput,
+		syncFieldRemote:  &m.syncForm.remoteInput,
+		syncFieldInclude: &m.syncForm.includeInput,
+		syncFieldExclude: &m.syncForm.excludeInput,
+	}
+	for i, input := range inputs {
+		if input == nil {
+			continue
+		}
+		if i == m.syncForm.focus {
+			input.Focus()
+		} else {
+			input.Blur()
+		}
+	}
+}
+
+func (m *SFTPBrowserModel) updateSyncForm(msg tea.Msg) (tea.Model, tea.Cmd) {
+	form := &m.syncForm
+
+	if keyMsg, ok := msg.(tea.KeyMsg); ok {
+		switch keyMsg.String() {
+		case "esc":
+			m.state = BrowsingState
+			return m, nil
+		case "tab", "down":
+			form.focus = (form.focus + 1) % syncFieldCount
+			m.focusSyncField()
+			return m, nil
+		case "shift+tab", "up":
+			form.focus = (form.focus + syncFieldCount - 1) % syncFieldCount
+			m.focusSyncField()
+			return m, nil
+		case "enter":
+			return m, m.planSync()
+		case " ", "left", "right":
+			switch form.focus {
+			case syncFieldDirection:
+				form.download = !form.download
+				return m, nil
+			case syncFieldDelete:
+				form.delete = !form.delete
+				return m, nil
+			case syncFieldChecksum:
+				form.checksum = !form.checksum
+				return m, nil
+			}
+		}
+	}
+
+	var cmd tea.Cmd
+	switch form.focus {
+	case syncFieldLocal:
+		form.localInput, cmd = form.localInput.Update(msg)
+	case syncFieldRemote:
+		form.remoteInput, cmd = form.remoteInput.Update(msg)
+	case syncFieldInclude:
+		form.includeInput, cmd = form.includeInput.Update(msg)
+	case syncFieldExclude:
+		form.excludeInput, cmd = form.excludeInput.Update(msg)
+	}
+	return m, cmd
+}
+
+// planSync compares both directories in the background for the preview
+func (m *SFTPBrowserModel) planSync() tea.Cmd {
+	form := m.syncForm
+	localDir := expandHome(strings.TrimSpace(form.localInput.Value()))
+	remoteDir := strings.TrimSpace(form.remoteInput.Value())
+	if localDir == "" || remoteDir == "" {
+		m.err = fmt.Errorf("both directories are required")
+		return nil
+	}
+
+	opts := sftp.SyncOptions{
+		Direction: sftp.SyncUpload,
+		Checksum:  form.checksum,
+		Delete:    form.delete,
+		Include:   splitGlobs(form.includeInput.Value()),
+		Exclude:   splitGlobs(form.excludeInput.Value()),
+	}
+	if form.download {
+		opts.Direction = sftp.SyncDownload
+	}
+
+	m.err = nil
+	m.syncPlan = nil
+	m.syncPlanErr = nil
+	m.syncScroll = 0
+	m.state = SyncPreviewState
+
+	client := m.client
+	return func() tea.Msg {
+		plan, err := client.PlanSync(localDir, remoteDir, opts)
+		return syncPlanMsg{plan: plan, err: err}
+	}
+}
+
+// splitGlobs parses a comma-separated list of glob patterns
+func splitGlobs(value string) []string {
+	var globs []string
+	for _, g := range strings.Split(value, ",") {
+		if g = strings.TrimSpace(g); g != "" {
+			globs = append(globs, g)
+		}
+	}
+	return globs
+}
+
+func (m *SFTPBrowserModel) updateSyncPreview(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	switch keyMsg.String() {
+	case "k", "up":
+		if m.syncScroll > 0 {
+			m.syncScroll--
+		}
+	case "j", "down":
+		if m.syncPlan != nil && m.syncScroll < len(m.syncPlan.Actions)-1 {
+			m.syncScroll++
+		}
+	case "enter", "y":
+		if m.syncPlan == nil {
+			return m, nil
+		}
+		m.enqueueSync(m.syncPlan)
+	case "esc", "n":
+		m.state = SyncFormState
+		m.focusSyncField()
+		return m, textinput.Blink
+	}
+	return m, nil
+}
+
+// enqueueSync hands a reviewed plan to the transfer queue
+func (m *SFTPBrowserModel) enqueueSync(plan *sftp.SyncPlan) {
+	m.state = BrowsingState
+	if len(plan.Actions) == 0 {
+		m.successMsg = "Already in sync"
+		return
+	}
+
+	req := sftp.TransferRequest{Sync: plan}
+	if plan.Options.Direction == sftp.SyncUpload {
+		req.Upload = true
+		req.Source, req.Dest = plan.LocalRoot, plan.RemoteRoot
+	} else {
+		req.Source, req.Dest = plan.RemoteRoot, plan.LocalRoot
+	}
+	m.transfers.Enqueue(req)
+	m.successMsg = fmt.Sprintf("Queued sync of %s (t to view)", req.Source)
+}
+
+func (m *SFTPBrowserModel) viewSyncForm() string {
+	form := m.syncForm
+	var s strings.Builder
+	s.WriteString("\n\n")
+	s.WriteString(styles.TitleStyle.Render("  Sync directories") + "\n\n")
+
+	direction := "local → remote (upload)"
+	if form.download {
+		direction = "remote → local (download)"
+	}
+
+	rows := []struct {
+		label string
+		value string
+	}{
+		{"Local dir", form.localInput.View()},
+		{"Remote dir", form.remoteInput.View()},
+		{"Direction", direction},
+		{"Include", form.includeInput.View()},
+		{"Exclude", form.excludeInput.View()},
+		{"Delete extra", renderCheckbox(form.delete) + " remove destination files missing from the source"},
+		{"Checksum", renderCheckbox(form.checksum) + " compare same-size files by sha256 instead of mtime"},
+	}
+	for i, row := range rows {
+		label := fmt.Sprintf("  %-13s", row.label)
+		if i == form.focus {
+			label = styles.SelectedStyle.Render(label)
+		}
+		s.WriteString(label + " " + row.value + "\n")
+	}
+
+	if m.err != nil {
+		s.WriteString("\n" + styles.ErrorStyle.Render("  "+m.err.Error()) + "\n")
+	}
+	s.WriteString("\n" + styles.SubtleStyle.Render("  Next: tab | Toggle: space | Preview: enter | Cancel: esc") + "\n")
+	return s.String()
+}
+
+// renderCheckbox draws the state of a toggle
+func renderCheckbox(on bool) string {
+	if on {
+		return "[x]"
+	}
+	return "[ ]"
+}
+
+// syncConflictLines is how many left out entries the preview lists
+const syncConflictLines = 3
+
+func (m *SFTPBrowserModel) viewSyncPreview() string {
+	var s strings.Builder
+	s.WriteString("\n\n")
+
+	switch {
+	case m.syncPlanErr != nil:
+		s.WriteString(styles.TitleStyle.Render("  Sync preview") + "\n\n")
+		s.WriteString(styles.ErrorStyle.Render("  "+m.syncPlanErr.Error()) + "\n\n")
+		s.WriteString(styles.SubtleStyle.Render("  Back: esc") + "\n")
+		return s.String()
+	case m.syncPlan == nil:
+		s.WriteString(styles.TitleStyle.Render("  Sync preview") + "\n\n")
+		s.WriteString(styles.SubtleStyle.Render("  Comparing directories...") + "\n")
+		return s.String()
+	}
+
+	plan := m.syncPlan
+	from, to := plan.LocalRoot, plan.RemoteRoot
+	if plan.Options.Direction == sftp.SyncDownload {
+		from, to = to, from
+	}
+	s.WriteString(styles.TitleStyle.Render(fmt.Sprintf("  Sync %s → %s", from, to)) + "\n\n")
+	s.WriteString(fmt.Sprintf("  %d to copy, %d to update, %d directories to create, %d unchanged - %s to transfer\n",
+		plan.Count(sftp.SyncCopy), plan.Count(sftp.SyncUpdate), plan.Count(sftp.SyncMkdir),
+		plan.Unchanged, FormatFileSize(plan.Bytes())))
+	if n := plan.Count(sftp.SyncDelete); n > 0 {
+		s.WriteString(styles.ErrorStyle.Render(fmt.Sprintf("  %d entries will be deleted from the destination", n)) + "\n")
+	}
+	for i, c := range plan.Conflicts {
+		if i == syncConflictLines {
+			s.WriteString(styles.ErrorStyle.Render(fmt.Sprintf("  ... and %d more left out", len(plan.Conflicts)-i)) + "\n")
+			break
+		}
+		s.WriteString(styles.ErrorStyle.Render(fmt.Sprintf("  Left out %s: %s", c.Path, c.Reason)) + "\n")
+	}
+	s.WriteString("\n")
+
+	if len(plan.Actions) == 0 {
+		s.WriteString(styles.SuccessStyle.Render("  Already in sync, nothing to do") + "\n\n")
+		s.WriteString(styles.SubtleStyle.Render("  Close: enter | Back: esc") + "\n")
+		return s.String()
+	}
+
+	visible := max(1, m.height-12)
+	start := min(m.syncScroll, max(0, len(plan.Actions)-visible))
+	end := min(len(plan.Actions), start+visible)
+	width := max(30, m.width-10)
+	for _, a := range plan.Actions[start:end] {
+		name := a.Path
+		if a.IsDir {
+			name += "/"
+		}
+		detail := a.Reason
+		if !a.IsDir && a.Kind != sftp.SyncDelete {
+			detail = fmt.Sprintf("%s, %s", FormatFileSize(a.Size), a.Reason)
+		}
+		line := truncateText(fmt.Sprintf("%-7s %s  (%s)", a.Kind, name, detail), width)
+		if a.Kind == sftp.SyncDelete {
+			s.WriteString("  " + styles.ErrorStyle.Render(line) + "\n")
+		} else {
+			s.WriteString("  " + styles.ItemStyle.Render(line) + "\n")
+		}
+	}
+	if end < len(plan.Actions) {
+		s.WriteString(styles.SubtleStyle.Render(fmt.Sprintf("  ... and %d more", len(plan.Actions)-end)) + "\n")
+	}
+
+	s.WriteString("\n" + styles.SubtleStyle.Render("  Apply: enter/y | Scroll: j/k | Back: esc") + "\n")
+	return s.String()
+}
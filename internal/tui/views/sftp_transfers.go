@@ -0,0 +1,601 @@
+package views
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/steevenmentech/bifrost/internal/sftp"
+	"github.com/steevenmentech/bifrost/internal/tui/styles"
+)
+
+// transferItem is a single source/destination pair of a transfer
+type transferItem struct {
+	source string
+	dest   string
+}
+
+// transferJob is a batch of uploads or downloads started from the browser
+type transferJob struct {
+	upload   bool // local to remote, otherwise remote to local
+	move     bool // remove each source once it transferred without problems
+	resume   bool // continue partial destination files
+	symlinks sftp.SymlinkMode
+	items    []transferItem
+}
+
+// transferConflict is a destination of a pending job that already exists
+type transferConflict struct {
+	dest    string
+	partial *sftp.PartialFile // set when the destination holds the start of its source
+}
+
+// transfersUpdatedMsg is sent when the transfer manager reports a change
+type transfersUpdatedMsg struct{}
+
+// waitForTransfers returns a command that delivers the next change of the transfer queue
+func waitForTransfers(tm *sftp.TransferManager) tea.Cmd {
+	return func() tea.Msg {
+		<-tm.Updates()
+		return transfersUpdatedMsg{}
+	}
+}
+
+// transferVerb returns a past tense label for a finished transfer
+func transferVerb(req sftp.TransferRequest) string {
+	switch {
+	case req.Sync != nil:
+		return "Synced"
+	case req.Move:
+		return "Moved"
+	case req.Upload:
+		return "Uploaded"
+	default:
+		return "Downloaded"
+	}
+}
+
+// transferProbeMsg carries the destinations of a pending job that already exist
+type transferProbeMsg struct {
+	seq       int
+	conflicts []transferConflict
+}
+
+// queueTransfer queues a job, asking first if any destination already exists.
+// The destinations are looked up in the background, one round trip each.
+func (m *SFTPBrowserModel) queueTransfer(job transferJob) tea.Cmd {
+	m.transferProbeSeq++
+	m.pendingTransfer = job
+	m.transferConflicts = nil
+	m.transferProbing = true
+	m.state = TransferConfirmState
+
+	client, seq := m.client, m.transferProbeSeq
+	return func() tea.Msg {
+		var conflicts []transferConflict
+		for _, item := range job.items {
+			var err error
+			if job.upload {
+				_, err = client.Stat(item.dest)
+			} else {
+				_, err = os.Lstat(item.dest)
+			}
+			if err != nil {
+				continue
+			}
+
+			// An interrupted transfer leaves a shorter copy that can be continued
+			partial, _ := client.DetectPartial(item.source, item.dest, job.upload)
+			conflicts = append(conflicts, transferConflict{dest: item.dest, partial: partial})
+		}
+		return transferProbeMsg{seq: seq, conflicts: conflicts}
+	}
+}
+
+// finishTransferProbe queues the pending job, or asks about the destinations that exist
+func (m *SFTPBrowserModel) finishTransferProbe(msg transferProbeMsg) (tea.Model, tea.Cmd) {
+	if msg.seq != m.transferProbeSeq || !m.transferProbing {
+		return m, nil
+	}
+	m.transferProbing = false
+	m.transferConflicts = msg.conflicts
+	if len(m.transferConflicts) == 0 && m.state == TransferConfirmState {
+		m.enqueueJob(m.pendingTransfer)
+	}
+	return m, nil
+}
+
+func (m *SFTPBrowserModel) updateTransferConfirm(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		if m.transferProbing {
+			// Only cancelling makes sense until the destinations are known
+			if s := msg.String(); s == "n" || s == "N" || s == "esc" {
+				m.transferProbing = false
+				m.state = BrowsingState
+			}
+			return m, nil
+		}
+		switch msg.String() {
+		case "y", "Y": // Overwrite existing
+			m.enqueueJob(m.pendingTransfer)
+		case "r", "R": // Resume partial files
+			if m.hasPartialConflict() {
+				job := m.pendingTransfer
+				job.resume = true
+				m.enqueueJob(job)
+			}
+		case "s", "S": // Skip existing
+			conflicts := make(map[string]bool, len(m.transferConflicts))
+			for _, c := range m.transferConflicts {
+				conflicts[c.dest] = true
+			}
+			job := m.pendingTransfer
+			job.items = nil
+			for _, item := range m.pendingTransfer.items {
+				if !conflicts[item.dest] {
+					job.items = append(job.items, item)
+				}
+			}
+			if len(job.items) == 0 {
+				m.state = BrowsingState
+				m.successMsg = "Nothing to transfer"
+				return m, nil
+			}
+			m.enqueueJob(job)
+		case "n", "N", "esc":
+			m.state = BrowsingState
+		}
+	}
+	return m, nil
+}
+
+// hasPartialConflict reports whether a pending destination can be resumed
+func (m *SFTPBrowserModel) hasPartialConflict() bool {
+	for _, c := range m.transferConflicts {
+		if c.partial != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// enqueueJob hands every item of a job to the transfer manager and returns to browsing
+func (m *SFTPBrowserModel) enqueueJob(job transferJob) {
+	for _, item := range job.items {
+		m.transfers.Enqueue(sftp.TransferRequest{
+			TransferOptions: sftp.TransferOptions{Symlinks: job.symlinks, Resume: job.resume},
+			Upload:          job.upload,
+			Move:            job.move,
+			Source:          item.source,
+			Dest:            item.dest,
+		})
+	}
+
+	if job.upload && m.dualPane {
+		m.localPane.ClearMarks()
+	}
+	m.state = BrowsingState
+	m.successMsg = fmt.Sprintf("Queued %d transfers (t to view)", len(job.items))
+	if len(job.items) == 1 {
+		m.successMsg = fmt.Sprintf("Queued %s (t to view)", filepath.Base(job.items[0].source))
+	}
+}
+
+// refreshTransfers reads the queue again and announces transfers that ended since the last look
+func (m *SFTPBrowserModel) refreshTransfers() (tea.Model, tea.Cmd) {
+	m.transferList = m.transfers.Transfers()
+	if m.transferIndex >= len(m.transferList) {
+		m.transferIndex = max(0, len(m.transferList)-1)
+	}
+
+	reloadRemote := false
+	for _, t := range m.transferList {
+		prev, seen := m.transferStates[t.ID]
+		m.transferStates[t.ID] = t.State
+		if !t.Ended() || (seen && prev == t.State) {
+			continue
+		}
+
+		// Refresh whichever side gained or lost files
+		if t.Upload && (path.Dir(t.Dest) == m.currentPath || t.Sync != nil && t.Dest == m.currentPath) || !t.Upload && t.Move && path.Dir(t.Source) == m.currentPath {
+			reloadRemote = true
+		}
+		if m.dualPane {
+			m.localPane.Reload()
+		}
+
+		switch t.State {
+		case sftp.TransferDone:
+			detail := FormatFileSize(t.Progress.Bytes)
+			if t.Result != nil && t.Result.Files > 0 && t.Result.Verified == t.Result.Files {
+				detail += ", sha256 verified"
+			}
+			m.successMsg = fmt.Sprintf("%s %s (%s)", transferVerb(t.TransferRequest), filepath.Base(t.Source), detail)
+		case sftp.TransferFailed:
+			if verifyFailures(t) > 0 {
+				m.err = fmt.Errorf("verification of %s failed, the copy differs from the source (t for details)", filepath.Base(t.Source))
+			} else {
+				m.err = fmt.Errorf("transfer of %s failed (t for details)", filepath.Base(t.Source))
+			}
+		}
+	}
+
+	if reloadRemote && m.state == BrowsingState {
+		m.loadCurrentDirectory()
+	}
+
+	return m, waitForTransfers(m.transfers)
+}
+
+func (m *SFTPBrowserModel) updateTransfers(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	switch keyMsg.String() {
+	case "k", "up":
+		if m.transferIndex > 0 {
+			m.transferIndex--
+		}
+	case "j", "down":
+		if m.transferIndex < len(m.transferList)-1 {
+			m.transferIndex++
+		}
+	case "r": // Resume a failed or cancelled transfer
+		if m.transferIndex < len(m.transferList) {
+			t := m.transferList[m.transferIndex]
+			if t.State == sftp.TransferFailed || t.State == sftp.TransferCancelled {
+				req := t.TransferRequest
+				req.Resume = true
+				m.transfers.Enqueue(req)
+			}
+		}
+	case "x": // Cancel selected
+		if m.transferIndex < len(m.transferList) {
+			m.transfers.Cancel(m.transferList[m.transferIndex].ID)
+		}
+	case "X": // Cancel all
+		m.transfers.CancelAll()
+	case "c": // Clear ended
+		m.transfers.ClearEnded()
+	case "enter": // Details of an ended transfer
+		if m.transferIndex < len(m.transferList) {
+			m.showTransferResult(m.transferList[m.transferIndex])
+		}
+	case "esc", "t", "q":
+		m.state = BrowsingState
+	}
+	return m, nil
+}
+
+// showTransferResult lists the failed and skipped entries of an ended transfer
+func (m *SFTPBrowserModel) showTransferResult(t sftp.Transfer) {
+	if !t.Ended() || t.State == sftp.TransferCancelled {
+		return
+	}
+
+	r := t.Result
+	if r == nil {
+		r = &sftp.TreeResult{}
+	}
+	failed := r.Failed
+	if t.Err != nil {
+		failed = append([]sftp.FailedEntry{{Path: t.Source, Err: t.Err}}, failed...)
+	}
+	if len(failed) == 0 && len(r.Skipped) == 0 {
+		return
+	}
+
+	m.resultTitle = fmt.Sprintf("%s %d files (%s) to %s", transferVerb(t.TransferRequest), r.Files, FormatFileSize(r.Bytes), t.Dest)
+	m.resultSections = []resultSection{
+		{heading: "Failed", entries: failed},
+		{heading: "Skipped", entries: r.Skipped},
+	}
+	m.resultReturn = TransfersState
+	m.state = OperationResultState
+}
+
+// verifyFailures counts the files of a transfer whose copy did not match the source
+func verifyFailures(t sftp.Transfer) int {
+	if t.Result == nil {
+		return 0
+	}
+	n := 0
+	for _, f := range t.Result.Failed {
+		if errors.Is(f.Err, sftp.ErrVerifyFailed) {
+			n++
+		}
+	}
+	return n
+}
+
+// activeTransfers counts the queued and running transfers of the last snapshot
+func (m *SFTPBrowserModel) activeTransfers() (running, queued int) {
+	for _, t := range m.transferList {
+		switch t.State {
+		case sftp.TransferRunning:
+			running++
+		case sftp.TransferQueued:
+			queued++
+		}
+	}
+	return running, queued
+}
+
+// renderTransferStatus summarizes active transfers in one line, empty when idle
+func (m *SFTPBrowserModel) renderTransferStatus() string {
+	running, queued := m.activeTransfers()
+	if running+queued == 0 {
+		return ""
+	}
+
+	var done, total int64
+	var rate float64
+	for _, t := range m.transferList {
+		if t.State == sftp.TransferRunning {
+			done += t.Progress.Bytes
+			total += t.Total
+			rate += t.Rate()
+		}
+	}
+
+	line := fmt.Sprintf("  Transfers: %d running, %d queued", running, queued)
+	if total > 0 {
+		line += fmt.Sprintf(" - %.0f%% at %s/s", float64(done)/float64(total)*100, FormatFileSize(int64(rate)))
+	}
+	return styles.SuccessStyle.Render(line) + styles.SubtleStyle.Render(" | View: t")
+}
+
+// showDownloadConfirm asks where to download the selected directory, or
+// which local directory to download the marked entries into
+func (m *SFTPBrowserModel) showDownloadConfirm() tea.Cmd {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		m.err = fmt.Errorf("failed to get home directory: %w", err)
+		return nil
+	}
+
+	dest := filepath.Join(homeDir, "Downloads")
+	if m.bulk == nil {
+		dest = m.getUniqueFilePath(filepath.Join(dest, m.files[m.selectedIndex].Name))
+	}
+
+	m.state = DownloadConfirmState
+	m.treeSummary = nil
+	m.treeScanErr = nil
+	m.downloadInput.SetValue(dest)
+	m.downloadInput.CursorEnd()
+	m.downloadInput.Focus()
+
+	if m.bulk != nil {
+		return tea.Batch(textinput.Blink, m.scanSelection())
+	}
+	return tea.Batch(textinput.Blink, m.scanTree(m.selectedPath()))
+}
+
+func (m *SFTPBrowserModel) updateDownloadConfirm(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "enter":
+			dest := expandHome(strings.TrimSpace(m.downloadInput.Value()))
+			m.downloadInput.Blur()
+			if dest == "" || m.selectedIndex >= len(m.files) {
+				m.state = BrowsingState
+				m.bulk = nil
+				return m, nil
+			}
+			if m.bulk != nil {
+				if err := os.MkdirAll(dest, 0755); err != nil {
+					m.err = fmt.Errorf("failed to create %s: %w", dest, err)
+					m.state = BrowsingState
+					m.bulk = nil
+					return m, nil
+				}
+				job := transferJob{symlinks: m.downloadSymlinks}
+				for _, f := range m.bulk {
+					job.items = append(job.items, transferItem{source: path.Join(m.currentPath, f.Name), dest: filepath.Join(dest, f.Name)})
+				}
+				m.bulk = nil
+				m.marked = make(map[string]bool)
+				return m, m.queueTransfer(job)
+			}
+			return m, m.queueTransfer(transferJob{
+				symlinks: m.downloadSymlinks,
+				items:    []transferItem{{source: m.selectedPath(), dest: dest}},
+			})
+		case "tab":
+			m.downloadSymlinks = (m.downloadSymlinks + 1) % 3
+			return m, nil
+		case "esc":
+			m.state = BrowsingState
+			m.bulk = nil
+			m.downloadInput.Blur()
+			return m, nil
+		}
+	}
+
+	m.downloadInput, cmd = m.downloadInput.Update(msg)
+	return m, cmd
+}
+
+func (m *SFTPBrowserModel) updateUploadPick(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	m.localPicker, cmd = m.localPicker.Update(msg)
+
+	if m.localPicker.IsCancelled() {
+		m.state = BrowsingState
+		return m, nil
+	}
+
+	if m.localPicker.IsConfirmed() {
+		return m, m.queueTransfer(m.uploadJob(m.localPicker.Selected(), false))
+	}
+
+	return m, cmd
+}
+
+// uploadJob builds a job uploading local paths into the current remote directory
+func (m *SFTPBrowserModel) uploadJob(localPaths []string, move bool) transferJob {
+	job := transferJob{upload: true, move: move, symlinks: sftp.SymlinkFollow}
+	for _, p := range localPaths {
+		job.items = append(job.items, transferItem{source: p, dest: path.Join(m.currentPath, filepath.Base(p))})
+	}
+	return job
+}
+
+// expandHome replaces a leading ~ with the local home directory
+func expandHome(p string) string {
+	if p != "~" && !strings.HasPrefix(p, "~/") {
+		return p
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return p
+	}
+	return filepath.Join(homeDir, strings.TrimPrefix(p, "~"))
+}
+
+func (m *SFTPBrowserModel) viewDownloadConfirm() string {
+	var s strings.Builder
+	s.WriteString("\n\n")
+	if m.selectedIndex < len(m.files) {
+		destLabel := "Destination"
+		if m.bulk != nil {
+			s.WriteString(styles.TitleStyle.Render(fmt.Sprintf("  Download %d marked entries", len(m.bulk))) + "\n\n")
+			s.WriteString(m.renderBulkList() + "\n")
+			destLabel = "Into local directory"
+		} else {
+			s.WriteString(styles.TitleStyle.Render(fmt.Sprintf("  Download directory: %s", m.files[m.selectedIndex].Name)) + "\n\n")
+		}
+		s.WriteString(m.renderTreeSummary() + "\n\n")
+		s.WriteString("  " + destLabel + ":\n")
+		s.WriteString("  " + m.downloadInput.View() + "\n\n")
+		s.WriteString(fmt.Sprintf("  Symlinks: %s\n\n", styles.SuccessStyle.Render(m.downloadSymlinks.String())))
+		s.WriteString(styles.SubtleStyle.Render("  Download: enter | Symlinks (skip/follow/preserve): tab | Cancel: esc") + "\n")
+	}
+	return s.String()
+}
+
+func (m *SFTPBrowserModel) viewTransferConfirm() string {
+	var s strings.Builder
+	s.WriteString("\n\n")
+	if m.transferProbing {
+		s.WriteString(styles.TitleStyle.Render(fmt.Sprintf("  Checking %d destinations...", len(m.pendingTransfer.items))) + "\n\n")
+		s.WriteString(styles.SubtleStyle.Render("  Cancel: n/esc") + "\n")
+		return s.String()
+	}
+	s.WriteString(styles.TitleStyle.Render(fmt.Sprintf("  %d of %d items already exist", len(m.transferConflicts), len(m.pendingTransfer.items))) + "\n\n")
+	for i, c := range m.transferConflicts {
+		if i >= max(1, m.height-10) {
+			s.WriteString(styles.SubtleStyle.Render(fmt.Sprintf("    ... and %d more", len(m.transferConflicts)-i)) + "\n")
+			break
+		}
+		s.WriteString("    " + c.dest)
+		switch {
+		case c.partial == nil:
+		case c.partial.Complete():
+			s.WriteString(styles.SuccessStyle.Render("  (complete)"))
+		default:
+			s.WriteString(styles.SuccessStyle.Render(fmt.Sprintf("  (partial: %s of %s)", FormatFileSize(c.partial.Have), FormatFileSize(c.partial.Want))))
+		}
+		s.WriteString("\n")
+	}
+
+	s.WriteString("\n  Overwrite existing files? Directories are merged.\n\n")
+	if m.hasPartialConflict() {
+		s.WriteString(styles.SubtleStyle.Render("  Overwrite: y | Resume partial: r | Skip existing: s | Cancel: n/esc") + "\n")
+	} else {
+		s.WriteString(styles.SubtleStyle.Render("  Overwrite: y | Skip existing: s | Cancel: n/esc") + "\n")
+	}
+	return s.String()
+}
+
+func (m *SFTPBrowserModel) viewTransfers() string {
+	var s strings.Builder
+	s.WriteString("\n\n")
+	s.WriteString(styles.TitleStyle.Render("  Transfers") + "\n\n")
+
+	if len(m.transferList) == 0 {
+		s.WriteString(styles.SubtleStyle.Render("  No transfers") + "\n\n")
+	}
+
+	// Each transfer takes two lines, keep the selected one in view
+	visible := max(1, (m.height-8)/2)
+	start := max(0, m.transferIndex-visible+1)
+	end := min(len(m.transferList), start+visible)
+	width := max(30, m.width-10)
+
+	for i := start; i < end; i++ {
+		t := m.transferList[i]
+		arrow := "↓"
+		if t.Upload {
+			arrow = "↑"
+		}
+		line := truncateText(fmt.Sprintf("%s %s → %s", arrow, t.Source, t.Dest), width-12)
+		line = fmt.Sprintf("%-*s %10s", width-12, line, t.State)
+		if i == m.transferIndex {
+			s.WriteString("  " + styles.SelectedStyle.Render(line) + "\n")
+		} else {
+			s.WriteString("  " + styles.ItemStyle.Render(line) + "\n")
+		}
+		s.WriteString("     " + m.renderTransferDetail(t) + "\n")
+	}
+
+	s.WriteString("\n")
+	s.WriteString(styles.SubtleStyle.Render("  Up/Down: j/k | Cancel: x | Cancel all: X | Resume: r | Clear ended: c | Details: enter | Back: esc/t") + "\n")
+	return s.String()
+}
+
+// renderTransferDetail describes the progress or outcome of a transfer
+func (m *SFTPBrowserModel) renderTransferDetail(t sftp.Transfer) string {
+	switch t.State {
+	case sftp.TransferQueued:
+		return styles.SubtleStyle.Render("waiting")
+	case sftp.TransferRunning:
+		line := renderProgressBar(t.Progress.Bytes, t.Total, min(30, m.width-60))
+		line += styles.SubtleStyle.Render(fmt.Sprintf("  %s of %s  %s/s", FormatFileSize(t.Progress.Bytes), FormatFileSize(t.Total), FormatFileSize(int64(t.Rate()))))
+		if eta := t.ETA(); eta > 0 {
+			line += styles.SubtleStyle.Render("  ETA " + formatDuration(eta))
+		}
+		return line
+	case sftp.TransferCancelled:
+		return styles.SubtleStyle.Render(fmt.Sprintf("cancelled after %s", FormatFileSize(t.Progress.Bytes)))
+	}
+
+	line := fmt.Sprintf("%d files, %s in %s", t.Progress.Files, FormatFileSize(t.Progress.Bytes), formatDuration(t.Finished.Sub(t.Started)))
+	if t.State == sftp.TransferFailed {
+		failures := 0
+		if t.Result != nil {
+			failures = len(t.Result.Failed)
+		}
+		if t.Err != nil {
+			return styles.ErrorStyle.Render(line + " - " + t.Err.Error())
+		}
+		if n := verifyFailures(t); n > 0 {
+			return styles.ErrorStyle.Render(fmt.Sprintf("%s - %d failed, %d did not verify (enter for details)", line, failures, n))
+		}
+		return styles.ErrorStyle.Render(fmt.Sprintf("%s - %d failed (enter for details)", line, failures))
+	}
+	if t.Result != nil && t.Result.Verified > 0 {
+		line += fmt.Sprintf(", %d verified", t.Result.Verified)
+	}
+	if t.Result != nil && t.Result.Deleted > 0 {
+		line += fmt.Sprintf(", %d deleted", t.Result.Deleted)
+	}
+	if t.Result != nil && t.Result.Resumed > 0 {
+		line += fmt.Sprintf(", %d resumed", t.Result.Resumed)
+	}
+	if t.Result != nil && len(t.Result.Skipped) > 0 {
+		line += fmt.Sprintf(", %d skipped (enter for details)", len(t.Result.Skipped))
+	}
+	return styles.SuccessStyle.Render(line)
+}
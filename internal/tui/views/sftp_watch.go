@@ -0,0 +1,236 @@
+package views
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/steevenmentech/bifrost/internal/sftp"
+	"github.com/steevenmentech/bifrost/internal/tui/styles"
+)
+
+// watchLogSize is how many pushed files the live log keeps
+const watchLogSize = 200
+
+// watchSession is a running watch & push of a local directory
+type watchSession struct {
+	local   string
+	remote  string
+	cancel  context.CancelFunc
+	events  chan tea.Msg
+	log     []sftp.PushEvent
+	pushed  int
+	failed  int
+	bytes   int64
+	running bool
+	err     error // why the watch stopped on its own
+}
+
+// watchEventMsg carries a file pushed by a watch session
+type watchEventMsg struct {
+	session *watchSession
+	event   sftp.PushEvent
+}
+
+// watchStoppedMsg is sent when a watch session ends
+type watchStoppedMsg struct {
+	session *watchSession
+	err     error
+}
+
+// showWatchSetup asks which local directory to push to the current remote directory
+func (m *SFTPBrowserModel) showWatchSetup() tea.Cmd {
+	localDir := m.localPane.CurrentDir()
+	if !m.dualPane || localDir == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			wd, _ = os.UserHomeDir()
+		}
+		localDir = wd
+	}
+
+	m.watchLocalInput.SetValue(localDir)
+	m.watchLocalInput.Focus()
+	m.watchIgnoreInput.Blur()
+	m.err = nil
+	m.state = WatchSetupState
+	return textinput.Blink
+}
+
+func (m *SFTPBrowserModel) updateWatchSetup(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if keyMsg, ok := msg.(tea.KeyMsg); ok {
+		switch keyMsg.String() {
+		case "esc":
+			m.state = BrowsingState
+			return m, nil
+		case "tab", "shift+tab", "up", "down":
+			if m.watchLocalInput.Focused() {
+				m.watchLocalInput.Blur()
+				m.watchIgnoreInput.Focus()
+			} else {
+				m.watchIgnoreInput.Blur()
+				m.watchLocalInput.Focus()
+			}
+			return m, nil
+		case "enter":
+			return m, m.startWatch()
+		}
+	}
+
+	var cmd tea.Cmd
+	if m.watchLocalInput.Focused() {
+		m.watchLocalInput, cmd = m.watchLocalInput.Update(msg)
+	} else {
+		m.watchIgnoreInput, cmd = m.watchIgnoreInput.Update(msg)
+	}
+	return m, cmd
+}
+
+// startWatch starts pushing changes of the chosen local directory in the background
+func (m *SFTPBrowserModel) startWatch() tea.Cmd {
+	localDir := expandHome(strings.TrimSpace(m.watchLocalInput.Value()))
+	if info, err := os.Stat(localDir); err != nil || !info.IsDir() {
+		m.err = fmt.Errorf("%s is not a local directory", localDir)
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	session := &watchSession{
+		local:   localDir,
+		remote:  m.currentPath,
+		cancel:  cancel,
+		events:  make(chan tea.Msg, 64),
+		running: true,
+	}
+	m.watch = session
+	m.err = nil
+	m.state = WatchingState
+
+	client := m.client
+	opts := sftp.WatchOptions{Ignore: splitGlobs(m.watchIgnoreInput.Value())}
+	go func() {
+		err := client.WatchPush(ctx, session.local, session.remote, opts, func(e sftp.PushEvent) {
+			select {
+			case session.events <- watchEventMsg{session: session, event: e}:
+			case <-ctx.Done():
+			}
+		})
+		select {
+		case session.events <- watchStoppedMsg{session: session, err: err}:
+		case <-ctx.Done():
+		}
+	}()
+
+	return waitForMsg(session.events)
+}
+
+// stopWatch ends the running watch session and reports what it pushed
+func (m *SFTPBrowserModel) stopWatch() {
+	if m.watch == nil {
+		return
+	}
+	m.watch.cancel()
+	m.successMsg = fmt.Sprintf("Stopped watching %s, pushed %d files (%s)", m.watch.local, m.watch.pushed, FormatFileSize(m.watch.bytes))
+	m.watch = nil
+	m.loadCurrentDirectory()
+}
+
+// recordWatchEvent adds a pushed file to the live log
+func (m *SFTPBrowserModel) recordWatchEvent(msg watchEventMsg) tea.Cmd {
+	if msg.session != m.watch {
+		return nil
+	}
+	w := m.watch
+	if msg.event.Err != nil {
+		w.failed++
+	} else {
+		w.pushed++
+		w.bytes += msg.event.Bytes
+	}
+	w.log = append(w.log, msg.event)
+	if len(w.log) > watchLogSize {
+		w.log = w.log[len(w.log)-watchLogSize:]
+	}
+	return waitForMsg(w.events)
+}
+
+func (m *SFTPBrowserModel) updateWatching(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if keyMsg, ok := msg.(tea.KeyMsg); ok {
+		switch keyMsg.String() {
+		case "esc", "q":
+			m.stopWatch()
+			m.state = BrowsingState
+		case "c": // Clear log
+			if m.watch != nil {
+				m.watch.log = nil
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m *SFTPBrowserModel) viewWatchSetup() string {
+	var s strings.Builder
+	s.WriteString("\n\n")
+	s.WriteString(styles.TitleStyle.Render(fmt.Sprintf("  Watch & push to %s", m.currentPath)) + "\n\n")
+	s.WriteString("  Local directory:\n")
+	s.WriteString("  " + m.watchLocalInput.View() + "\n\n")
+	s.WriteString("  Also ignore (comma-separated, .gitignore files are always respected):\n")
+	s.WriteString("  " + m.watchIgnoreInput.View() + "\n\n")
+	if m.err != nil {
+		s.WriteString(styles.ErrorStyle.Render("  "+m.err.Error()) + "\n\n")
+	}
+	s.WriteString(styles.SubtleStyle.Render("  Start: enter | Next field: tab | Cancel: esc") + "\n")
+	return s.String()
+}
+
+func (m *SFTPBrowserModel) viewWatching() string {
+	var s strings.Builder
+	s.WriteString("\n\n")
+	w := m.watch
+	if w == nil {
+		return s.String()
+	}
+
+	s.WriteString(styles.TitleStyle.Render(fmt.Sprintf("  Watching %s → %s", w.local, w.remote)) + "\n\n")
+	status := fmt.Sprintf("  %d files pushed (%s)", w.pushed, FormatFileSize(w.bytes))
+	if w.failed > 0 {
+		status += fmt.Sprintf(", %d failed", w.failed)
+	}
+	switch {
+	case w.running:
+		s.WriteString(styles.SuccessStyle.Render(status) + "\n\n")
+	case w.err != nil:
+		s.WriteString(styles.ErrorStyle.Render(status+" - stopped: "+w.err.Error()) + "\n\n")
+	default:
+		s.WriteString(styles.SubtleStyle.Render(status+" - stopped") + "\n\n")
+	}
+
+	if len(w.log) == 0 {
+		s.WriteString(styles.SubtleStyle.Render("  Waiting for changes...") + "\n")
+	}
+
+	// Newest entries at the bottom, like a log tail
+	visible := max(1, m.height-9)
+	start := max(0, len(w.log)-visible)
+	width := max(30, m.width-6)
+	for _, e := range w.log[start:] {
+		stamp := e.Time.Format("15:04:05")
+		switch {
+		case e.Err != nil && e.Path == "":
+			s.WriteString(styles.ErrorStyle.Render(truncateText(fmt.Sprintf("  %s  %v", stamp, e.Err), width)) + "\n")
+		case e.Err != nil:
+			s.WriteString(styles.ErrorStyle.Render(truncateText(fmt.Sprintf("  %s  ✗ %s: %v", stamp, e.Path, e.Err), width)) + "\n")
+		default:
+			line := truncateText(fmt.Sprintf("  %s  ↑ %s", stamp, filepath.FromSlash(e.Path)), width-12)
+			s.WriteString(line + styles.SubtleStyle.Render(fmt.Sprintf("  %s", FormatFileSize(e.Bytes))) + "\n")
+		}
+	}
+
+	s.WriteString("\n" + styles.SubtleStyle.Render("  Clear log: c | Stop: esc/q") + "\n")
+	return s.String()
+}